@@ -7,18 +7,28 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
 	"magnet-feed-sync/app/bot"
+	"magnet-feed-sync/app/release"
 	"magnet-feed-sync/app/tracker"
+	"magnet-feed-sync/app/types"
 	"magnet-feed-sync/app/utils"
 )
 
 type FileParser interface {
 	Parse(ctx context.Context, url, location string) (*tracker.FileMetadata, error)
+	CanHandle(url string) bool
+}
+
+type Searcher interface {
+	Search(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error)
 }
 
 type FileStore interface {
@@ -26,36 +36,81 @@ type FileStore interface {
 	CreateOrReplace(metadata *tracker.FileMetadata) error
 	GetAll() ([]*tracker.FileMetadata, error)
 	Remove(id string) error
+	UpdateDownloadStatus(id, magnet string, status types.TorrentStatus) error
+	ClearRedownloadStartedAt(id, magnet string) error
+}
+
+type PageCache interface {
+	Forget(source string)
 }
 
 type DownloadClient interface {
-	CreateDownloadTask(url, destination string) error
+	CreateDownloadTask(url string, opts types.DownloadOptions) error
+	AddTorrentFile(data []byte, opts types.DownloadOptions) error
+	GetHashByMagnet(magnet string) (string, error)
+	SetCategory(taskID, category string) error
+	GetLocations() []types.Location
+	GetDefaultLocation() string
+	RemoveTorrent(taskID string, deleteFiles bool) error
+	GetTorrentStatus(taskID string) (types.TorrentStatus, error)
 }
 
+type instanceRouter interface {
+	HasInstance(name string) bool
+	InstanceFor(location string) string
+	TaskInstance(taskID string) string
+	GetHashByMagnetOn(instance, magnet string) (string, error)
+}
+
+const taskTag = "magnet-feed-sync"
+
+const (
+	defaultReplaceCheckInterval = 15 * time.Second
+	defaultReplaceTimeout       = 2 * time.Hour
+)
+
 type Client struct {
-	mu              sync.Mutex
-	messagesForSend chan string
-	tracker         FileParser
-	dClient         DownloadClient
-	store           FileStore
-	dryMode         bool
+	mu                   sync.Mutex
+	messagesForSend      chan string
+	tracker              FileParser
+	searcher             Searcher
+	dClient              DownloadClient
+	store                FileStore
+	pageCache            PageCache
+	dryMode              bool
+	updatePolicy         types.UpdatePolicy
+	replaceCheckInterval time.Duration
+	replaceTimeout       time.Duration
 }
 
 type ClientCtx struct {
 	MessagesForSend chan string
 	Tracker         FileParser
+	Searcher        Searcher
 	DClient         DownloadClient
 	Store           FileStore
+	PageCache       PageCache
 	DryMode         bool
+	UpdatePolicy    types.UpdatePolicy
 }
 
 func NewClient(ctx *ClientCtx) *Client {
+	updatePolicy := ctx.UpdatePolicy
+	if updatePolicy == "" {
+		updatePolicy = types.UpdatePolicyKeep
+	}
+
 	return &Client{
-		messagesForSend: ctx.MessagesForSend,
-		tracker:         ctx.Tracker,
-		dClient:         ctx.DClient,
-		dryMode:         ctx.DryMode,
-		store:           ctx.Store,
+		messagesForSend:      ctx.MessagesForSend,
+		tracker:              ctx.Tracker,
+		searcher:             ctx.Searcher,
+		dClient:              ctx.DClient,
+		dryMode:              ctx.DryMode,
+		store:                ctx.Store,
+		pageCache:            ctx.PageCache,
+		updatePolicy:         updatePolicy,
+		replaceCheckInterval: defaultReplaceCheckInterval,
+		replaceTimeout:       defaultReplaceTimeout,
 	}
 }
 
@@ -75,10 +130,15 @@ func (c *Client) OnMessage(ctx context.Context, msg bot.Message, location string
 }
 
 func (c *Client) CreateFromURL(ctx context.Context, url, location string) (*tracker.FileMetadata, error) {
+	return c.CreateFromURLOnInstance(ctx, url, location, "")
+}
+
+func (c *Client) CreateFromURLOnInstance(ctx context.Context, url, location, instance string) (*tracker.FileMetadata, error) {
 	metadata, err := c.tracker.Parse(ctx, url, location)
 	if err != nil {
 		return nil, err
 	}
+	metadata.Instance = instance
 
 	slog.DebugContext(ctx, "metadata", "metadata", metadata)
 
@@ -86,12 +146,54 @@ func (c *Client) CreateFromURL(ctx context.Context, url, location string) (*trac
 }
 
 func (c *Client) DownloadNow(ctx context.Context, source, location string) error {
+	if location == "" {
+		location = c.dClient.GetDefaultLocation()
+	}
+
 	if c.dryMode {
 		slog.InfoContext(ctx, "dry mode is enabled, skipping one-shot download", "location", location)
 		return nil
 	}
 
-	return c.dClient.CreateDownloadTask(source, location)
+	return c.dClient.CreateDownloadTask(source, types.DownloadOptions{Destination: location})
+}
+
+func (c *Client) Search(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	if c.searcher == nil {
+		return nil, types.ErrSearchNotConfigured
+	}
+
+	rules, err := release.ParseRules(query.Accept, query.Reject)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := c.searcher.Search(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	results = slices.DeleteFunc(results, func(result types.SearchResult) bool {
+		return !rules.MatchTitle(result.Title)
+	})
+	for i := range results {
+		results[i].Trackable = results[i].TrackerURL != "" && c.tracker.CanHandle(results[i].TrackerURL)
+	}
+
+	return results, nil
+}
+
+func (c *Client) DownloadTorrentFile(ctx context.Context, data []byte, location string) error {
+	if location == "" {
+		location = c.dClient.GetDefaultLocation()
+	}
+
+	if c.dryMode {
+		slog.InfoContext(ctx, "dry mode is enabled, skipping torrent file download", "location", location)
+		return nil
+	}
+
+	return c.dClient.AddTorrentFile(data, types.DownloadOptions{Destination: location})
 }
 
 func (c *Client) createWithLock(ctx context.Context, metadata *tracker.FileMetadata) (*tracker.FileMetadata, error) {
@@ -104,6 +206,29 @@ func (c *Client) createWithLock(ctx context.Context, metadata *tracker.FileMetad
 	}
 	hadActiveRow := existing != nil && !existing.DeleteAt.Valid
 
+	if metadata.UpdatePolicy == "" {
+		metadata.UpdatePolicy = c.updatePolicy
+		if hadActiveRow && existing.UpdatePolicy != "" {
+			metadata.UpdatePolicy = existing.UpdatePolicy
+		}
+	}
+	if metadata.Category == "" {
+		metadata.Category = c.categoryFor(metadata.Location)
+		if hadActiveRow && existing.Category != "" {
+			metadata.Category = existing.Category
+		}
+	}
+	if metadata.Instance == "" && hadActiveRow {
+		metadata.Instance = existing.Instance
+	}
+	if err := c.pinInstance(metadata); err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+	if metadata.Episodes.Complete() {
+		metadata.CompletedAt = time.Now()
+	}
+
 	err := c.store.CreateOrReplace(metadata)
 	if err != nil {
 		c.mu.Unlock()
@@ -116,7 +241,7 @@ func (c *Client) createWithLock(ctx context.Context, metadata *tracker.FileMetad
 		return metadata, nil
 	}
 
-	err = c.dClient.CreateDownloadTask(metadata.Magnet, metadata.Location)
+	err = c.startDownload(metadata)
 	if err != nil {
 		c.rollbackCreate(ctx, metadata.ID, existing, hadActiveRow)
 		return nil, err
@@ -127,6 +252,85 @@ func (c *Client) createWithLock(ctx context.Context, metadata *tracker.FileMetad
 	return metadata, nil
 }
 
+func (c *Client) startDownload(metadata *tracker.FileMetadata) error {
+	if len(metadata.TorrentFile) > 0 {
+		return c.dClient.AddTorrentFile(metadata.TorrentFile, downloadOptions(metadata))
+	}
+	return c.dClient.CreateDownloadTask(metadata.Magnet, downloadOptions(metadata))
+}
+
+func (c *Client) pinInstance(metadata *tracker.FileMetadata) error {
+	router, ok := c.dClient.(instanceRouter)
+	if !ok {
+		if metadata.Instance != "" {
+			return fmt.Errorf("%w: %q", types.ErrUnknownInstance, metadata.Instance)
+		}
+		return nil
+	}
+
+	if metadata.Instance == "" {
+		metadata.Instance = router.InstanceFor(metadata.Location)
+		return nil
+	}
+	if !router.HasInstance(metadata.Instance) {
+		return fmt.Errorf("%w: %q", types.ErrUnknownInstance, metadata.Instance)
+	}
+	return nil
+}
+
+func (c *Client) taskInstance(file *tracker.FileMetadata) string {
+	if file.Instance != "" {
+		return file.Instance
+	}
+
+	router, ok := c.dClient.(instanceRouter)
+	if !ok {
+		return ""
+	}
+	if taskID, err := c.dClient.GetHashByMagnet(file.Magnet); err == nil {
+		if name := router.TaskInstance(taskID); name != "" {
+			return name
+		}
+	}
+	return router.InstanceFor(file.Location)
+}
+
+func (c *Client) findTorrent(magnet, instance string) (string, error) {
+	if router, ok := c.dClient.(instanceRouter); ok && instance != "" {
+		return router.GetHashByMagnetOn(instance, magnet)
+	}
+	return c.dClient.GetHashByMagnet(magnet)
+}
+
+func (c *Client) categoryFor(location string) string {
+	for _, l := range c.dClient.GetLocations() {
+		if l.ID == location {
+			return l.Category
+		}
+	}
+	return ""
+}
+
+func downloadOptions(metadata *tracker.FileMetadata) types.DownloadOptions {
+	return types.DownloadOptions{
+		Destination: metadata.Location,
+		Category:    metadata.Category,
+		Tags:        taskTags(metadata),
+		Instance:    metadata.Instance,
+	}
+}
+
+func taskTags(metadata *tracker.FileMetadata) []string {
+	tags := []string{taskTag}
+	if u, err := url.Parse(metadata.OriginalUrl); err == nil && u.Hostname() != "" {
+		tags = append(tags, strings.TrimPrefix(u.Hostname(), "www."))
+	}
+	if id := strings.ReplaceAll(metadata.ID, ",", ""); id != "" {
+		tags = append(tags, id)
+	}
+	return tags
+}
+
 func (c *Client) rollbackCreate(ctx context.Context, id string, existing *tracker.FileMetadata, hadActiveRow bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -160,7 +364,13 @@ func (c *Client) processFileMetadata(ctx context.Context, fileMetadata *tracker.
 		return
 	}
 
-	updatedMetadata, err := c.tracker.Parse(ctx, fileMetadata.OriginalUrl, "")
+	parseCtx, commitPageCache := tracker.DeferPageCache(tracker.SkipUnchanged(ctx))
+	updatedMetadata, err := c.tracker.Parse(parseCtx, fileMetadata.OriginalUrl, "")
+	if errors.Is(err, tracker.ErrNotModified) {
+		slog.InfoContext(ctx, "tracker page unchanged, skipping parse", "id", fileMetadata.ID)
+		c.touchLastSync(ctx, fileMetadata.ID)
+		return
+	}
 	if err != nil {
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
@@ -187,13 +397,27 @@ func (c *Client) processFileMetadata(ctx context.Context, fileMetadata *tracker.
 	if current.Location != "" {
 		updatedMetadata.Location = current.Location
 	}
+	updatedMetadata.Category = current.Category
+	updatedMetadata.Instance = c.taskInstance(current)
+	updatedMetadata.UpdatePolicy = current.UpdatePolicy
+	if updatedMetadata.Episodes.Complete() {
+		updatedMetadata.CompletedAt = current.CompletedAt
+		if updatedMetadata.CompletedAt.IsZero() {
+			updatedMetadata.CompletedAt = time.Now()
+			slog.InfoContext(ctx, "final episode is out, task completed", "id", fileMetadata.ID, "episodes", updatedMetadata.Episodes.Total)
+		}
+	}
 
 	updatedMetadata.LastSyncAt = time.Now()
 	if magnetsEqual(current.Magnet, updatedMetadata.Magnet) {
+		updatedMetadata.Download = current.Download
+		updatedMetadata.RedownloadStartedAt = current.RedownloadStartedAt
 		slog.InfoContext(ctx, "magnet unchanged, updating metadata silently", "id", fileMetadata.ID)
 
 		if err := c.store.CreateOrReplace(updatedMetadata); err != nil {
 			slog.ErrorContext(ctx, "error updating metadata", "error", err)
+		} else {
+			commitPageCache()
 		}
 
 		c.mu.Unlock()
@@ -201,6 +425,10 @@ func (c *Client) processFileMetadata(ctx context.Context, fileMetadata *tracker.
 	}
 	slog.InfoContext(ctx, "magnet changed, re-downloading", "id", fileMetadata.ID)
 
+	if !c.dryMode {
+		updatedMetadata.RedownloadStartedAt = time.Now()
+	}
+
 	if err := c.store.CreateOrReplace(updatedMetadata); err != nil {
 		slog.ErrorContext(ctx, "error updating metadata", "error", err)
 		c.mu.Unlock()
@@ -211,16 +439,23 @@ func (c *Client) processFileMetadata(ctx context.Context, fileMetadata *tracker.
 
 	if c.dryMode {
 		slog.InfoContext(ctx, "dry mode is enabled, skipping download")
-		c.sendUpdateNotification(updatedMetadata)
+		commitPageCache()
+		c.sendUpdateNotification(current, updatedMetadata)
 		return
 	}
 
-	if err := c.dClient.CreateDownloadTask(updatedMetadata.Magnet, updatedMetadata.Location); err != nil {
+	previousTaskID := c.resolvePreviousTorrent(ctx, current)
+
+	if err := c.startDownload(updatedMetadata); err != nil {
 		slog.ErrorContext(ctx, "error creating download task", "error", err)
 
 		c.mu.Lock()
 		updatedMetadata.Magnet = current.Magnet
 		updatedMetadata.TorrentUpdatedAt = current.TorrentUpdatedAt
+		updatedMetadata.Download = current.Download
+		updatedMetadata.RedownloadStartedAt = current.RedownloadStartedAt
+		updatedMetadata.Episodes = current.Episodes
+		updatedMetadata.CompletedAt = current.CompletedAt
 		if storeErr := c.store.CreateOrReplace(updatedMetadata); storeErr != nil {
 			slog.ErrorContext(ctx, "error reverting metadata after download failure", "error", storeErr)
 		}
@@ -229,28 +464,129 @@ func (c *Client) processFileMetadata(ctx context.Context, fileMetadata *tracker.
 	}
 
 	slog.InfoContext(ctx, "download task created", "name", updatedMetadata.Name)
-	c.sendUpdateNotification(updatedMetadata)
+	commitPageCache()
+	c.sendUpdateNotification(current, updatedMetadata)
+
+	if previousTaskID != "" {
+		go c.replacePreviousTorrent(context.WithoutCancel(ctx), current.UpdatePolicy, previousTaskID, updatedMetadata)
+	}
+}
+
+func (c *Client) resolvePreviousTorrent(ctx context.Context, current *tracker.FileMetadata) string {
+	if current.UpdatePolicy != types.UpdatePolicyRemove && current.UpdatePolicy != types.UpdatePolicyRemoveWithData {
+		return ""
+	}
+
+	taskID, err := c.findTorrent(current.Magnet, current.Instance)
+	if err != nil {
+		slog.WarnContext(ctx, "previous torrent not found in download client, nothing to remove", "id", current.ID, "error", err)
+		return ""
+	}
+
+	return taskID
+}
+
+func (c *Client) replacePreviousTorrent(ctx context.Context, policy types.UpdatePolicy, previousTaskID string, updated *tracker.FileMetadata) {
+	ctx, cancel := context.WithTimeout(ctx, c.replaceTimeout)
+	defer cancel()
+
+	deleteFiles := policy == types.UpdatePolicyRemoveWithData
+
+	ticker := time.NewTicker(c.replaceCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		taskID, ready, err := c.replacementReady(updated, deleteFiles)
+		if err != nil {
+			slog.ErrorContext(ctx, "updated torrent failed, keeping previous torrent", "task_id", previousTaskID, "error", err)
+			c.messagesForSend <- replacementFailedToMsg(updated.Name)
+			return
+		}
+		if ready {
+			if taskID == previousTaskID {
+				slog.InfoContext(ctx, "updated magnet resolves to the same torrent, keeping it", "task_id", taskID)
+				return
+			}
+
+			if err := c.dClient.RemoveTorrent(previousTaskID, deleteFiles); err != nil {
+				slog.ErrorContext(ctx, "error removing previous torrent", "task_id", previousTaskID, "error", err)
+				return
+			}
+
+			slog.InfoContext(ctx, "previous torrent removed", "task_id", previousTaskID, "delete_files", deleteFiles)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			slog.WarnContext(ctx, "updated torrent is not ready, keeping previous torrent", "task_id", previousTaskID)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+var errReplacementFailed = errors.New("updated torrent is in error state")
+
+func (c *Client) replacementReady(updated *tracker.FileMetadata, waitForCheck bool) (string, bool, error) {
+	taskID, err := c.findTorrent(updated.Magnet, updated.Instance)
+	if err != nil {
+		return "", false, nil
+	}
+
+	if !waitForCheck {
+		return taskID, true, nil
+	}
+
+	status, err := c.dClient.GetTorrentStatus(taskID)
+	if err != nil {
+		return "", false, nil
+	}
+	if status.State == types.TorrentStateError {
+		return taskID, false, errReplacementFailed
+	}
+
+	return taskID, status.Verified(), nil
 }
 
-func (c *Client) sendUpdateNotification(metadata *tracker.FileMetadata) {
+func (c *Client) sendUpdateNotification(previous, metadata *tracker.FileMetadata) {
 	formatedMsg, err := MetadataToMsg(metadata)
 	if err != nil {
 		slog.Error("error formatting metadata", "error", err)
 		return
 	}
-	c.messagesForSend <- fmt.Sprintf("✅ Metadata updated:\n\n%s", formatedMsg)
+
+	msg := fmt.Sprintf("✅ Metadata updated%s:\n\n%s", episodesChangeToMsg(previous.Episodes, metadata.Episodes), formatedMsg)
+	if previous.CompletedAt.IsZero() && !metadata.CompletedAt.IsZero() {
+		msg += "\n\n🏁 Final episode is out, tracking stopped"
+	}
+	c.messagesForSend <- msg
 }
 
-func magnetsEqual(a, b string) bool {
-	hashA := utils.ExtractBtihHash(a)
-	hashB := utils.ExtractBtihHash(b)
-	if hashA != "" && hashB != "" {
-		return hashA == hashB
+func (c *Client) touchLastSync(ctx context.Context, id string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current, err := c.store.GetById(id)
+	if err != nil {
+		slog.ErrorContext(ctx, "error re-reading metadata", "error", err)
+		return
+	}
+	if current.DeleteAt.Valid {
+		return
+	}
+
+	current.LastSyncAt = time.Now()
+	if err := c.store.CreateOrReplace(current); err != nil {
+		slog.ErrorContext(ctx, "error updating metadata", "error", err)
 	}
-	xtA := utils.ExtractXtParam(a)
-	xtB := utils.ExtractXtParam(b)
-	if xtA != "" && xtB != "" {
-		return xtA == xtB
+}
+
+func magnetsEqual(a, b string) bool {
+	magnetA, errA := utils.ParseMagnet(a)
+	magnetB, errB := utils.ParseMagnet(b)
+	if errA == nil && errB == nil {
+		return magnetA.SameTorrent(magnetB)
 	}
 	return a == b
 }
@@ -270,14 +606,99 @@ func (c *Client) CheckForUpdates(ctx context.Context) {
 	}
 
 	for _, metadata := range filesMetadata {
+		if !metadata.CompletedAt.IsZero() {
+			slog.DebugContext(ctx, "task completed, skipping", "id", metadata.ID)
+			continue
+		}
 		c.processFileMetadata(ctx, metadata)
 	}
 }
 
+func (c *Client) SyncDownloadStatus(ctx context.Context) {
+	ctx, span := otel.Tracer("download-tasks").Start(ctx, "SyncDownloadStatus")
+	defer span.End()
+
+	filesMetadata, err := c.store.GetAll()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "error getting files metadata", "error", err)
+		return
+	}
+
+	for _, metadata := range filesMetadata {
+		status, err := c.downloadStatus(metadata)
+		if err != nil {
+			slog.WarnContext(ctx, "error getting download status", "id", metadata.ID, "error", err)
+			continue
+		}
+
+		c.mu.Lock()
+		err = c.store.UpdateDownloadStatus(metadata.ID, metadata.Magnet, status)
+		c.mu.Unlock()
+		if err != nil {
+			slog.ErrorContext(ctx, "error updating download status", "id", metadata.ID, "error", err)
+			continue
+		}
+
+		if status.State == types.TorrentStateCompleted && !metadata.RedownloadStartedAt.IsZero() {
+			c.notifyDownloadCompleted(ctx, metadata, status)
+		}
+	}
+}
+
+func (c *Client) notifyDownloadCompleted(ctx context.Context, metadata *tracker.FileMetadata, status types.TorrentStatus) {
+	c.mu.Lock()
+	err := c.store.ClearRedownloadStartedAt(metadata.ID, metadata.Magnet)
+	c.mu.Unlock()
+	if err != nil {
+		slog.ErrorContext(ctx, "error clearing redownload start time", "id", metadata.ID, "error", err)
+		return
+	}
+
+	completedAt := status.CompletedAt
+	if completedAt.IsZero() {
+		completedAt = time.Now()
+	}
+	elapsed := max(completedAt.Sub(metadata.RedownloadStartedAt), 0)
+
+	slog.InfoContext(ctx, "updated torrent downloaded", "id", metadata.ID, "elapsed", elapsed, "size", status.Size)
+	c.messagesForSend <- DownloadCompletedToMsg(metadata.Name, elapsed, status.Size)
+}
+
+func (c *Client) downloadStatus(metadata *tracker.FileMetadata) (types.TorrentStatus, error) {
+	taskID, err := c.findTorrent(metadata.Magnet, metadata.Instance)
+	if errors.Is(err, types.ErrTorrentNotFound) {
+		return types.TorrentStatus{State: types.TorrentStateMissing}, nil
+	}
+	if err != nil {
+		return types.TorrentStatus{}, err
+	}
+
+	status, err := c.dClient.GetTorrentStatus(taskID)
+	if errors.Is(err, types.ErrTorrentNotFound) {
+		return types.TorrentStatus{State: types.TorrentStateMissing}, nil
+	}
+	return status, err
+}
+
 func (c *Client) RemoveTask(id string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	return c.store.Remove(id)
+
+	file, err := c.store.GetById(id)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("get task: %w", err)
+	}
+
+	if err := c.store.Remove(id); err != nil {
+		return err
+	}
+
+	if file != nil && c.pageCache != nil {
+		c.pageCache.Forget(file.OriginalUrl)
+	}
+	return nil
 }
 
 func (c *Client) UpdateTaskLocation(id, location string) error {
@@ -293,10 +714,70 @@ func (c *Client) UpdateTaskLocation(id, location string) error {
 		return fmt.Errorf("task %s has been deleted", id)
 	}
 
+	file.Instance = c.taskInstance(file)
 	file.Location = location
 	return c.store.CreateOrReplace(file)
 }
 
+func (c *Client) UpdateTaskPolicy(id string, policy types.UpdatePolicy) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	file, err := c.store.GetById(id)
+	if err != nil {
+		return fmt.Errorf("get task: %w", err)
+	}
+
+	if file.DeleteAt.Valid {
+		return fmt.Errorf("task %s has been deleted", id)
+	}
+
+	file.UpdatePolicy = policy
+	return c.store.CreateOrReplace(file)
+}
+
+func (c *Client) UpdateTaskCategory(id, category string) error {
+	c.mu.Lock()
+
+	file, err := c.store.GetById(id)
+	if err != nil {
+		c.mu.Unlock()
+		return fmt.Errorf("get task: %w", err)
+	}
+
+	if file.DeleteAt.Valid {
+		c.mu.Unlock()
+		return fmt.Errorf("task %s has been deleted", id)
+	}
+
+	file.Category = category
+	if err := c.store.CreateOrReplace(file); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+
+	c.mu.Unlock()
+
+	if c.dryMode {
+		return nil
+	}
+
+	taskID, err := c.findTorrent(file.Magnet, file.Instance)
+	if err != nil {
+		return fmt.Errorf("find torrent: %w", err)
+	}
+
+	if err := c.dClient.SetCategory(taskID, category); err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			slog.Warn("download client does not support categories, category saved for the task only", "id", id)
+			return nil
+		}
+		return err
+	}
+
+	return nil
+}
+
 func (c *Client) CheckFileForUpdates(ctx context.Context, fileId string) {
 	metadata, err := c.store.GetById(fileId)
 	if err != nil {
@@ -307,6 +788,23 @@ func (c *Client) CheckFileForUpdates(ctx context.Context, fileId string) {
 	c.processFileMetadata(ctx, metadata)
 }
 
+func DownloadCompletedToMsg(name string, elapsed time.Duration, size int64) string {
+	name = strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(name)
+	return fmt.Sprintf("🎉 Download completed:\n\n```\n%s\nElapsed: %s\nSize: %s\n```", name, elapsed.Round(time.Second), utils.FormatSize(size))
+}
+
+func episodesChangeToMsg(previous, current types.EpisodeProgress) string {
+	if previous.Current == 0 || current.Current == 0 || previous.Current == current.Current {
+		return ""
+	}
+	return fmt.Sprintf(", episodes %d→%d", previous.Current, current.Current)
+}
+
+func replacementFailedToMsg(name string) string {
+	name = strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(name)
+	return fmt.Sprintf("⚠️ Updated torrent failed, previous torrent kept:\n\n```\n%s\n```", name)
+}
+
 func MetadataToMsg(metadata *tracker.FileMetadata) (string, error) {
 	comment := metadata.LastComment
 	runes := []rune(comment)
@@ -3,7 +3,12 @@ package download_tasks
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -11,6 +16,7 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 	"magnet-feed-sync/app/tracker"
+	"magnet-feed-sync/app/tracker/providers"
 	"magnet-feed-sync/app/types"
 
 	"github.com/stretchr/testify/assert"
@@ -18,18 +24,43 @@ import (
 )
 
 type mockFileParser struct {
-	parseFunc func(url, location string) (*tracker.FileMetadata, error)
+	parseFunc     func(url, location string) (*tracker.FileMetadata, error)
+	parseCtxFunc  func(ctx context.Context, url, location string) (*tracker.FileMetadata, error)
+	canHandleFunc func(url string) bool
 }
 
-func (m *mockFileParser) Parse(_ context.Context, url, location string) (*tracker.FileMetadata, error) {
+func (m *mockFileParser) Parse(ctx context.Context, url, location string) (*tracker.FileMetadata, error) {
+	if m.parseCtxFunc != nil {
+		return m.parseCtxFunc(ctx, url, location)
+	}
 	return m.parseFunc(url, location)
 }
 
+func (m *mockFileParser) CanHandle(url string) bool {
+	if m.canHandleFunc != nil {
+		return m.canHandleFunc(url)
+	}
+	return true
+}
+
+type mockSearcher struct {
+	results   []types.SearchResult
+	err       error
+	lastQuery types.SearchQuery
+}
+
+func (m *mockSearcher) Search(_ context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	m.lastQuery = query
+	return m.results, m.err
+}
+
 type mockFileStore struct {
 	getByIdFunc         func(id string) (*tracker.FileMetadata, error)
 	createOrReplaceFunc func(metadata *tracker.FileMetadata) error
 	getAllFunc          func() ([]*tracker.FileMetadata, error)
 	removeFunc          func(id string) error
+	updateStatusFunc    func(id, magnet string, status types.TorrentStatus) error
+	clearRedownloadFunc func(id, magnet string) error
 }
 
 func (m *mockFileStore) GetById(id string) (*tracker.FileMetadata, error) {
@@ -48,12 +79,40 @@ func (m *mockFileStore) Remove(id string) error {
 	return m.removeFunc(id)
 }
 
+func (m *mockFileStore) UpdateDownloadStatus(id, magnet string, status types.TorrentStatus) error {
+	return m.updateStatusFunc(id, magnet, status)
+}
+
+func (m *mockFileStore) ClearRedownloadStartedAt(id, magnet string) error {
+	return m.clearRedownloadFunc(id, magnet)
+}
+
 type mockDownloadClient struct {
 	createDownloadTaskFunc func(url, destination string) error
+	getHashByMagnetFunc    func(magnet string) (string, error)
+	removeTorrentFunc      func(taskID string, deleteFiles bool) error
+	getTorrentStatusFunc   func(taskID string) (types.TorrentStatus, error)
+	setCategoryFunc        func(taskID, category string) error
+	addTorrentFileFunc     func(data []byte, opts types.DownloadOptions) error
+	locations              []types.Location
+	lastOptions            types.DownloadOptions
+}
+
+func (m *mockDownloadClient) CreateDownloadTask(url string, opts types.DownloadOptions) error {
+	m.lastOptions = opts
+	return m.createDownloadTaskFunc(url, opts.Destination)
+}
+
+func (m *mockDownloadClient) AddTorrentFile(data []byte, opts types.DownloadOptions) error {
+	m.lastOptions = opts
+	return m.addTorrentFileFunc(data, opts)
 }
 
-func (m *mockDownloadClient) CreateDownloadTask(url, destination string) error {
-	return m.createDownloadTaskFunc(url, destination)
+func (m *mockDownloadClient) SetCategory(taskID, category string) error {
+	if m.setCategoryFunc != nil {
+		return m.setCategoryFunc(taskID, category)
+	}
+	return nil
 }
 
 func (m *mockDownloadClient) SetLocation(taskID, location string) error {
@@ -61,17 +120,68 @@ func (m *mockDownloadClient) SetLocation(taskID, location string) error {
 }
 
 func (m *mockDownloadClient) GetLocations() []types.Location {
-	return nil
+	return m.locations
 }
 
 func (m *mockDownloadClient) GetHashByMagnet(magnet string) (string, error) {
+	if m.getHashByMagnetFunc != nil {
+		return m.getHashByMagnetFunc(magnet)
+	}
 	return "", nil
 }
 
+func (m *mockDownloadClient) RemoveTorrent(taskID string, deleteFiles bool) error {
+	if m.removeTorrentFunc != nil {
+		return m.removeTorrentFunc(taskID, deleteFiles)
+	}
+	return nil
+}
+
+func (m *mockDownloadClient) GetTorrentStatus(taskID string) (types.TorrentStatus, error) {
+	if m.getTorrentStatusFunc != nil {
+		return m.getTorrentStatusFunc(taskID)
+	}
+	return types.TorrentStatus{State: types.TorrentStateDownloading}, nil
+}
+
 func (m *mockDownloadClient) GetDefaultLocation() string {
 	return "/downloads"
 }
 
+type mockRouterClient struct {
+	*mockDownloadClient
+	routes        map[string]string
+	scopedLookups []string
+}
+
+func (m *mockRouterClient) HasInstance(name string) bool {
+	return name == "nas" || name == "seedbox"
+}
+
+func (m *mockRouterClient) InstanceFor(location string) string {
+	if name, ok := m.routes[location]; ok {
+		return name
+	}
+	return "nas"
+}
+
+func (m *mockRouterClient) TaskInstance(taskID string) string {
+	name, _, ok := strings.Cut(taskID, ":")
+	if !ok || !m.HasInstance(name) {
+		return ""
+	}
+	return name
+}
+
+func (m *mockRouterClient) GetHashByMagnetOn(instance, magnet string) (string, error) {
+	m.scopedLookups = append(m.scopedLookups, instance)
+	hash, err := m.GetHashByMagnet(magnet)
+	if err != nil {
+		return "", err
+	}
+	return instance + ":" + hash, nil
+}
+
 func TestDownloadNow_DryMode_SkipsDownloadClient(t *testing.T) {
 	downloadCalled := false
 	dClient := &mockDownloadClient{
@@ -120,6 +230,24 @@ func TestDownloadNow_ForwardsSourceAndLocation(t *testing.T) {
 	assert.Equal(t, "/downloads/movies", gotDestination, "location should be forwarded verbatim")
 }
 
+func TestDownloadNow_EmptyLocationUsesDefault(t *testing.T) {
+	var gotDestination string
+	dClient := &mockDownloadClient{
+		createDownloadTaskFunc: func(url, destination string) error {
+			gotDestination = destination
+			return nil
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		DClient:         dClient,
+	})
+
+	require.NoError(t, client.DownloadNow(context.Background(), "magnet:?xt=urn:btih:abc123", ""))
+	assert.Equal(t, "/downloads", gotDestination, "empty location should fall back to the client default")
+}
+
 func TestDownloadNow_PropagatesError(t *testing.T) {
 	dClient := &mockDownloadClient{
 		createDownloadTaskFunc: func(url, destination string) error {
@@ -139,6 +267,46 @@ func TestDownloadNow_PropagatesError(t *testing.T) {
 	assert.Contains(t, err.Error(), "qbittorrent unavailable")
 }
 
+func TestDownloadTorrentFile_ForwardsDataAndLocation(t *testing.T) {
+	var gotData []byte
+	dClient := &mockDownloadClient{
+		addTorrentFileFunc: func(data []byte, opts types.DownloadOptions) error {
+			gotData = data
+			return nil
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		DClient:         dClient,
+	})
+
+	err := client.DownloadTorrentFile(context.Background(), []byte("d4:infodee"), "/downloads/movies")
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte("d4:infodee"), gotData)
+	assert.Equal(t, "/downloads/movies", dClient.lastOptions.Destination)
+}
+
+func TestDownloadTorrentFile_DryMode_SkipsDownloadClient(t *testing.T) {
+	called := false
+	dClient := &mockDownloadClient{
+		addTorrentFileFunc: func(data []byte, opts types.DownloadOptions) error {
+			called = true
+			return nil
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		DClient:         dClient,
+		DryMode:         true,
+	})
+
+	require.NoError(t, client.DownloadTorrentFile(context.Background(), []byte("d4:infodee"), "/downloads"))
+	assert.False(t, called, "download client should not be called in dry mode")
+}
+
 func TestProcessFileMetadata_SameMagnetDifferentDate_NoRedownload(t *testing.T) {
 	magnet := "magnet:?xt=urn:btih:abc123"
 	oldDate := time.Date(2026, 3, 20, 10, 0, 0, 0, time.UTC)
@@ -272,6 +440,7 @@ func TestProcessFileMetadata_DifferentMagnet_RedownloadTriggered(t *testing.T) {
 	assert.Equal(t, newMagnet, savedMetadata.Magnet, "new magnet should be stored")
 	assert.Equal(t, "Test Torrent v2", savedMetadata.Name, "new name should be stored")
 	assert.Equal(t, "/downloads", savedMetadata.Location, "location should be preserved")
+	assert.WithinDuration(t, time.Now(), savedMetadata.RedownloadStartedAt, time.Minute, "redownload start should be recorded")
 
 	select {
 	case msg := <-msgChan:
@@ -281,6 +450,232 @@ func TestProcessFileMetadata_DifferentMagnet_RedownloadTriggered(t *testing.T) {
 	}
 }
 
+type removal struct {
+	taskID      string
+	deleteFiles bool
+}
+
+func newReplacementTest(t *testing.T, policy types.UpdatePolicy, dClient *mockDownloadClient) (*Client, *tracker.FileMetadata) {
+	t.Helper()
+
+	current := &tracker.FileMetadata{
+		ID:           "3304959",
+		OriginalUrl:  "https://rutracker.org/forum/viewtopic.php?t=3304959",
+		Magnet:       "magnet:?xt=urn:btih:abc123",
+		Name:         "Test Torrent",
+		Location:     "/downloads",
+		UpdatePolicy: policy,
+	}
+
+	var saved *tracker.FileMetadata
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			copied := *current
+			return &copied, nil
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+			saved = metadata
+			return nil
+		},
+	}
+	t.Cleanup(func() {
+		if saved != nil {
+			assert.Equal(t, policy, saved.UpdatePolicy, "update policy should be carried over")
+		}
+	})
+
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{
+				ID:          "3304959",
+				OriginalUrl: url,
+				Magnet:      "magnet:?xt=urn:btih:def456",
+				Name:        "Test Torrent v2",
+			}, nil
+		},
+	}
+
+	if dClient.createDownloadTaskFunc == nil {
+		dClient.createDownloadTaskFunc = func(url, destination string) error { return nil }
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		Tracker:         parser,
+		DClient:         dClient,
+		Store:           store,
+	})
+	client.replaceCheckInterval = time.Millisecond
+	client.replaceTimeout = time.Second
+
+	return client, current
+}
+
+func hashesByMagnet(magnet string) (string, error) {
+	switch magnet {
+	case "magnet:?xt=urn:btih:abc123":
+		return "ABC123", nil
+	case "magnet:?xt=urn:btih:def456":
+		return "DEF456", nil
+	default:
+		return "", fmt.Errorf("torrent not found")
+	}
+}
+
+func TestProcessFileMetadata_UpdatePolicyKeep_PreviousTorrentKept(t *testing.T) {
+	hashLookups := 0
+	removed := make(chan removal, 1)
+	dClient := &mockDownloadClient{
+		getHashByMagnetFunc: func(magnet string) (string, error) {
+			hashLookups++
+			return hashesByMagnet(magnet)
+		},
+		removeTorrentFunc: func(taskID string, deleteFiles bool) error {
+			removed <- removal{taskID, deleteFiles}
+			return nil
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyKeep, dClient)
+
+	client.processFileMetadata(context.Background(), current)
+
+	assert.Zero(t, hashLookups, "previous torrent should not be looked up")
+	select {
+	case r := <-removed:
+		t.Fatalf("previous torrent should be kept, got removal of %s", r.taskID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestProcessFileMetadata_UpdatePolicyRemove_PreviousTorrentRemovedKeepingFiles(t *testing.T) {
+	removed := make(chan removal, 1)
+	dClient := &mockDownloadClient{
+		getHashByMagnetFunc: hashesByMagnet,
+		removeTorrentFunc: func(taskID string, deleteFiles bool) error {
+			removed <- removal{taskID, deleteFiles}
+			return nil
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyRemove, dClient)
+
+	client.processFileMetadata(context.Background(), current)
+
+	select {
+	case r := <-removed:
+		assert.Equal(t, removal{taskID: "ABC123", deleteFiles: false}, r)
+	case <-time.After(time.Second):
+		t.Fatal("previous torrent should be removed")
+	}
+}
+
+func TestProcessFileMetadata_UpdatePolicyRemoveWithData_WaitsForCheck(t *testing.T) {
+	var statusCalls atomic.Int32
+	removed := make(chan removal, 1)
+	dClient := &mockDownloadClient{
+		getHashByMagnetFunc: hashesByMagnet,
+		getTorrentStatusFunc: func(taskID string) (types.TorrentStatus, error) {
+			assert.Equal(t, "DEF456", taskID, "status should be checked for the new torrent")
+			if statusCalls.Add(1) < 3 {
+				return types.TorrentStatus{State: types.TorrentStateChecking}, nil
+			}
+			return types.TorrentStatus{State: types.TorrentStateDownloading}, nil
+		},
+		removeTorrentFunc: func(taskID string, deleteFiles bool) error {
+			removed <- removal{taskID, deleteFiles}
+			return nil
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyRemoveWithData, dClient)
+
+	client.processFileMetadata(context.Background(), current)
+
+	select {
+	case r := <-removed:
+		assert.Equal(t, removal{taskID: "ABC123", deleteFiles: true}, r)
+		assert.GreaterOrEqual(t, statusCalls.Load(), int32(3), "removal should wait until checking finishes")
+	case <-time.After(time.Second):
+		t.Fatal("previous torrent should be removed after the new one is checked")
+	}
+}
+
+func TestProcessFileMetadata_UpdatePolicyRemoveWithData_KeepsPreviousWhenNeverChecked(t *testing.T) {
+	removed := make(chan removal, 1)
+	dClient := &mockDownloadClient{
+		getHashByMagnetFunc: hashesByMagnet,
+		getTorrentStatusFunc: func(taskID string) (types.TorrentStatus, error) {
+			return types.TorrentStatus{State: types.TorrentStateChecking}, nil
+		},
+		removeTorrentFunc: func(taskID string, deleteFiles bool) error {
+			removed <- removal{taskID, deleteFiles}
+			return nil
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyRemoveWithData, dClient)
+	client.replaceTimeout = 20 * time.Millisecond
+
+	client.processFileMetadata(context.Background(), current)
+
+	select {
+	case r := <-removed:
+		t.Fatalf("previous torrent should be kept, got removal of %s", r.taskID)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestProcessFileMetadata_UpdatePolicyRemoveWithData_KeepsPreviousWhenNewTorrentErrors(t *testing.T) {
+	removed := make(chan removal, 1)
+	dClient := &mockDownloadClient{
+		getHashByMagnetFunc: hashesByMagnet,
+		getTorrentStatusFunc: func(taskID string) (types.TorrentStatus, error) {
+			return types.TorrentStatus{State: types.TorrentStateError}, nil
+		},
+		removeTorrentFunc: func(taskID string, deleteFiles bool) error {
+			removed <- removal{taskID, deleteFiles}
+			return nil
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyRemoveWithData, dClient)
+
+	client.processFileMetadata(context.Background(), current)
+
+	require.Contains(t, <-client.messagesForSend, "Metadata updated")
+	select {
+	case msg := <-client.messagesForSend:
+		assert.Contains(t, msg, "Updated torrent failed, previous torrent kept")
+		assert.Contains(t, msg, "Test Torrent v2")
+	case <-time.After(time.Second):
+		t.Fatal("user should be notified that the updated torrent failed")
+	}
+	select {
+	case r := <-removed:
+		t.Fatalf("previous torrent should be kept, got removal of %s", r.taskID)
+	default:
+	}
+}
+
+func TestProcessFileMetadata_UpdatePolicyRemove_DownloadFails_PreviousTorrentKept(t *testing.T) {
+	removed := make(chan removal, 1)
+	dClient := &mockDownloadClient{
+		createDownloadTaskFunc: func(url, destination string) error {
+			return fmt.Errorf("qbittorrent unavailable")
+		},
+		getHashByMagnetFunc: hashesByMagnet,
+		removeTorrentFunc: func(taskID string, deleteFiles bool) error {
+			removed <- removal{taskID, deleteFiles}
+			return nil
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyRemove, dClient)
+
+	client.processFileMetadata(context.Background(), current)
+
+	select {
+	case r := <-removed:
+		t.Fatalf("previous torrent should be kept, got removal of %s", r.taskID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 func TestProcessFileMetadata_SameMagnetSameDate_MetadataUpdated(t *testing.T) {
 	magnet := "magnet:?xt=urn:btih:abc123"
 	date := time.Date(2026, 3, 20, 10, 0, 0, 0, time.UTC)
@@ -311,6 +706,7 @@ func TestProcessFileMetadata_SameMagnetSameDate_MetadataUpdated(t *testing.T) {
 				Magnet:           magnet,
 				Name:             "Test Torrent",
 				TorrentUpdatedAt: date,
+				Torrent:          types.TorrentInfo{Size: 2048, Seeders: 12, Leechers: 3, Category: "TV", FileCount: 10},
 			}, nil
 		},
 	}
@@ -341,6 +737,7 @@ func TestProcessFileMetadata_SameMagnetSameDate_MetadataUpdated(t *testing.T) {
 	assert.False(t, downloadCalled, "download should not be triggered")
 	assert.Empty(t, msgChan, "no notification should be sent")
 	require.NotNil(t, savedMetadata, "metadata should still be saved (last_sync_at updated)")
+	assert.Equal(t, types.TorrentInfo{Size: 2048, Seeders: 12, Leechers: 3, Category: "TV", FileCount: 10}, savedMetadata.Torrent)
 }
 
 func TestProcessFileMetadata_ParseError_NoCrash(t *testing.T) {
@@ -370,6 +767,52 @@ func TestProcessFileMetadata_ParseError_NoCrash(t *testing.T) {
 	assert.Empty(t, msgChan, "no notification should be sent on parse error")
 }
 
+func TestProcessFileMetadata_PageNotModified_OnlyLastSyncUpdated(t *testing.T) {
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			return nil, fmt.Errorf("failed to fetch kinozal page: %w", tracker.ErrNotModified)
+		},
+	}
+
+	lastSync := time.Now().Add(-time.Hour)
+	var saved *tracker.FileMetadata
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{
+				ID:          "1977338",
+				Magnet:      "magnet:?xt=urn:btih:old",
+				Name:        "Old title",
+				LastComment: "old comment",
+				LastSyncAt:  lastSync,
+			}, nil
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+			saved = metadata
+			return nil
+		},
+	}
+
+	msgChan := make(chan string, 10)
+	client := NewClient(&ClientCtx{
+		MessagesForSend: msgChan,
+		Tracker:         parser,
+		DClient:         &mockDownloadClient{},
+		Store:           store,
+	})
+
+	client.processFileMetadata(context.Background(), &tracker.FileMetadata{
+		ID:          "1977338",
+		OriginalUrl: "https://kinozal.tv/details.php?id=1977338",
+	})
+
+	require.NotNil(t, saved)
+	assert.True(t, saved.LastSyncAt.After(lastSync))
+	assert.Equal(t, "magnet:?xt=urn:btih:old", saved.Magnet)
+	assert.Equal(t, "Old title", saved.Name)
+	assert.Equal(t, "old comment", saved.LastComment)
+	assert.Empty(t, msgChan)
+}
+
 func TestProcessFileMetadata_EmptyOriginalUrl_Skipped(t *testing.T) {
 	parser := &mockFileParser{
 		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
@@ -567,6 +1010,54 @@ func TestProcessFileMetadata_DifferentMagnet_DownloadFails_MagnetReverted(t *tes
 	assert.Equal(t, oldDate, lastSavedMetadata.TorrentUpdatedAt, "torrent_updated_at should be reverted")
 }
 
+func TestProcessFileMetadata_DownloadFails_RetriedOnNextCheck(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "topic page with a new magnet")
+	}))
+	defer server.Close()
+
+	cache, err := providers.NewPageCache(providers.PageCacheConfig{Dir: t.TempDir()})
+	require.NoError(t, err)
+	fetcher, err := providers.NewFetcher(providers.FetcherConfig{Cache: cache})
+	require.NoError(t, err)
+
+	var parses int
+	parser := &mockFileParser{
+		parseCtxFunc: func(ctx context.Context, url, location string) (*tracker.FileMetadata, error) {
+			if _, err := fetcher.FetchIfChanged(ctx, url); err != nil {
+				return nil, err
+			}
+			parses++
+			return &tracker.FileMetadata{ID: "3304959", OriginalUrl: url, Magnet: "magnet:?xt=urn:btih:def456"}, nil
+		},
+	}
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: "3304959", OriginalUrl: server.URL, Magnet: "magnet:?xt=urn:btih:abc123"}, nil
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error { return nil },
+	}
+	var downloads int
+	dClient := &mockDownloadClient{
+		createDownloadTaskFunc: func(url, destination string) error {
+			downloads++
+			if downloads == 1 {
+				return errors.New("download client unavailable")
+			}
+			return nil
+		},
+	}
+	client := NewClient(&ClientCtx{MessagesForSend: make(chan string, 10), Tracker: parser, DClient: dClient, Store: store})
+	task := &tracker.FileMetadata{ID: "3304959", OriginalUrl: server.URL}
+
+	client.processFileMetadata(context.Background(), task)
+	client.processFileMetadata(context.Background(), task)
+	client.processFileMetadata(context.Background(), task)
+
+	assert.Equal(t, 2, downloads, "failed update should be retried on the next check")
+	assert.Equal(t, 2, parses, "page should be skipped once the update succeeded")
+}
+
 func TestProcessFileMetadata_SameBtihDifferentTrackerUrl_NoRedownload(t *testing.T) {
 	storedMagnet := "magnet:?xt=urn:btih:ABC123&tr=http://bt3.t-ru.org/ann"
 	parsedMagnet := "magnet:?xt=urn:btih:abc123&tr=http://bt4.t-ru.org/ann"
@@ -683,6 +1174,8 @@ func TestMagnetsEqual(t *testing.T) {
 		{"no btih different magnet", "magnet:?xt=urn:btmh:1220abc", "magnet:?xt=urn:btmh:1220def", false},
 		{"same btmh different tracker", "magnet:?xt=urn:btmh:1220abc&tr=http://a.com", "magnet:?xt=urn:btmh:1220abc&tr=http://b.com", true},
 		{"one has btih other doesnt", "magnet:?xt=urn:btih:abc123", "magnet:?xt=urn:btmh:1220abc", false},
+		{"base32 and hex btih", "magnet:?xt=urn:btih:EVTOFMAS5IPPSCDUMW6JPJ5MIRE7J4G6", "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de", true},
+		{"hybrid and v2 only", "magnet:?xt=urn:btih:abc123&xt=urn:btmh:1220abc", "magnet:?xt=urn:btmh:1220abc&tr=http://b.com", true},
 		{"both empty", "", "", true},
 	}
 
@@ -780,19 +1273,742 @@ func TestCheckForUpdates_CreatesTracingSpan(t *testing.T) {
 	assert.Equal(t, "CheckForUpdates", spans[0].Name)
 }
 
-func TestCheckForUpdates_NoopTracingNoCrash(t *testing.T) {
-	otel.SetTracerProvider(otel.GetTracerProvider())
+func TestProcessFileMetadata_NewEpisode_NotifiesProgress(t *testing.T) {
+	tests := []struct {
+		name          string
+		episodes      types.EpisodeProgress
+		wantMsg       string
+		wantCompleted bool
+	}{
+		{name: "ongoing season", episodes: types.EpisodeProgress{Current: 6, Total: 10}, wantMsg: "Metadata updated, episodes 5→6:"},
+		{name: "final episode", episodes: types.EpisodeProgress{Current: 10, Total: 10}, wantMsg: "Metadata updated, episodes 5→10:", wantCompleted: true},
+	}
 
-	store := &mockFileStore{
-		getAllFunc: func() ([]*tracker.FileMetadata, error) {
-			return nil, nil
-		},
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *tracker.FileMetadata
+			store := &mockFileStore{
+				getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+					return &tracker.FileMetadata{
+						ID:       "6810475",
+						Magnet:   "magnet:?xt=urn:btih:abc123",
+						Episodes: types.EpisodeProgress{Current: 5, Total: 10},
+					}, nil
+				},
+				createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+					saved = metadata
+					return nil
+				},
+			}
+			parser := &mockFileParser{
+				parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+					return &tracker.FileMetadata{ID: "6810475", Magnet: "magnet:?xt=urn:btih:def456", Episodes: tt.episodes}, nil
+				},
+			}
+			msgChan := make(chan string, 10)
+			client := NewClient(&ClientCtx{
+				MessagesForSend: msgChan,
+				Tracker:         parser,
+				DClient:         &mockDownloadClient{createDownloadTaskFunc: func(url, destination string) error { return nil }},
+				Store:           store,
+			})
+
+			client.processFileMetadata(context.Background(), &tracker.FileMetadata{ID: "6810475", OriginalUrl: "https://rutracker.org/forum/viewtopic.php?t=6810475"})
+
+			require.NotNil(t, saved)
+			assert.Equal(t, tt.episodes, saved.Episodes)
+			assert.Equal(t, tt.wantCompleted, !saved.CompletedAt.IsZero())
+			require.Len(t, msgChan, 1)
+			msg := <-msgChan
+			assert.Contains(t, msg, tt.wantMsg)
+			assert.Equal(t, tt.wantCompleted, strings.Contains(msg, "Final episode is out"))
+		})
 	}
+}
 
-	client := NewClient(&ClientCtx{
-		MessagesForSend: make(chan string, 10),
+func TestProcessFileMetadata_FinalEpisodeDownloadFails_CompletionReverted(t *testing.T) {
+	var saved []tracker.FileMetadata
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: "6810475", Magnet: "magnet:?xt=urn:btih:abc123", Episodes: types.EpisodeProgress{Current: 9, Total: 10}}, nil
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+			saved = append(saved, *metadata)
+			return nil
+		},
+	}
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: "6810475", Magnet: "magnet:?xt=urn:btih:def456", Episodes: types.EpisodeProgress{Current: 10, Total: 10}}, nil
+		},
+	}
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		Tracker:         parser,
+		DClient:         &mockDownloadClient{createDownloadTaskFunc: func(url, destination string) error { return errors.New("client unavailable") }},
+		Store:           store,
+	})
+
+	client.processFileMetadata(context.Background(), &tracker.FileMetadata{ID: "6810475", OriginalUrl: "https://rutracker.org/forum/viewtopic.php?t=6810475"})
+
+	require.Len(t, saved, 2)
+	assert.False(t, saved[0].CompletedAt.IsZero())
+	assert.True(t, saved[1].CompletedAt.IsZero(), "failed final episode is retried on the next check")
+	assert.Equal(t, types.EpisodeProgress{Current: 9, Total: 10}, saved[1].Episodes)
+}
+
+func TestCheckForUpdates_SkipsCompletedTasks(t *testing.T) {
+	var parsed []string
+	store := &mockFileStore{
+		getAllFunc: func() ([]*tracker.FileMetadata, error) {
+			return []*tracker.FileMetadata{
+				{ID: "1", OriginalUrl: "https://rutracker.org/forum/viewtopic.php?t=1", CompletedAt: time.Now()},
+				{ID: "2", OriginalUrl: "https://rutracker.org/forum/viewtopic.php?t=2"},
+			}, nil
+		},
+	}
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			parsed = append(parsed, url)
+			return nil, tracker.ErrNotModified
+		},
+	}
+	store.getByIdFunc = func(id string) (*tracker.FileMetadata, error) { return &tracker.FileMetadata{ID: id}, nil }
+	store.createOrReplaceFunc = func(metadata *tracker.FileMetadata) error { return nil }
+
+	client := NewClient(&ClientCtx{MessagesForSend: make(chan string, 10), Tracker: parser, Store: store})
+
+	client.CheckForUpdates(context.Background())
+
+	assert.Equal(t, []string{"https://rutracker.org/forum/viewtopic.php?t=2"}, parsed)
+}
+
+func TestCreateFromURL_CompleteSeasonMarkedCompleted(t *testing.T) {
+	var saved *tracker.FileMetadata
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) { return nil, sql.ErrNoRows },
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+			saved = metadata
+			return nil
+		},
+	}
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: "6810475", Magnet: "magnet:?xt=urn:btih:abc123", Episodes: types.EpisodeProgress{Current: 10, Total: 10}}, nil
+		},
+	}
+	client := NewClient(&ClientCtx{Tracker: parser, DClient: &mockDownloadClient{}, Store: store, DryMode: true})
+
+	_, err := client.CreateFromURL(context.Background(), "https://rutracker.org/forum/viewtopic.php?t=6810475", "")
+	require.NoError(t, err)
+
+	require.NotNil(t, saved)
+	assert.False(t, saved.CompletedAt.IsZero())
+}
+
+func TestCheckForUpdates_NoopTracingNoCrash(t *testing.T) {
+	otel.SetTracerProvider(otel.GetTracerProvider())
+
+	store := &mockFileStore{
+		getAllFunc: func() ([]*tracker.FileMetadata, error) {
+			return nil, nil
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
 		Store:           store,
 	})
 
 	client.CheckForUpdates(context.Background())
 }
+
+func TestCreateFromURL_AppliesDefaultUpdatePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing *tracker.FileMetadata
+		want     types.UpdatePolicy
+	}{
+		{name: "new task uses configured default", want: types.UpdatePolicyRemove},
+		{
+			name:     "re-added task keeps its policy",
+			existing: &tracker.FileMetadata{ID: "3304959", UpdatePolicy: types.UpdatePolicyRemoveWithData},
+			want:     types.UpdatePolicyRemoveWithData,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *tracker.FileMetadata
+			store := &mockFileStore{
+				getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+					if tt.existing == nil {
+						return nil, sql.ErrNoRows
+					}
+					return tt.existing, nil
+				},
+				createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+					saved = metadata
+					return nil
+				},
+			}
+			parser := &mockFileParser{
+				parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+					return &tracker.FileMetadata{ID: "3304959", Magnet: "magnet:?xt=urn:btih:abc123", Location: location}, nil
+				},
+			}
+
+			client := NewClient(&ClientCtx{
+				MessagesForSend: make(chan string, 10),
+				Tracker:         parser,
+				DClient:         &mockDownloadClient{createDownloadTaskFunc: func(url, destination string) error { return nil }},
+				Store:           store,
+				UpdatePolicy:    types.UpdatePolicyRemove,
+			})
+
+			_, err := client.CreateFromURL(context.Background(), "https://rutracker.org/forum/viewtopic.php?t=3304959", "/downloads")
+
+			require.NoError(t, err)
+			require.NotNil(t, saved)
+			assert.Equal(t, tt.want, saved.UpdatePolicy)
+		})
+	}
+}
+
+func TestCreateFromURL_CategoryAndTags(t *testing.T) {
+	var saved *tracker.FileMetadata
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return nil, sql.ErrNoRows
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+			saved = metadata
+			return nil
+		},
+	}
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{
+				ID:          "6810475",
+				OriginalUrl: "https://www.rutracker.org/forum/viewtopic.php?t=6810475",
+				Magnet:      "magnet:?xt=urn:btih:abc123",
+				Location:    location,
+			}, nil
+		},
+	}
+	dClient := &mockDownloadClient{
+		createDownloadTaskFunc: func(url, destination string) error { return nil },
+		locations: []types.Location{
+			{ID: "/downloads/movies", Name: "Movies", Category: "movies"},
+			{ID: "/downloads/tv shows", Name: "TV Shows", Category: "tv"},
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		Tracker:         parser,
+		DClient:         dClient,
+		Store:           store,
+	})
+
+	_, err := client.CreateFromURL(context.Background(), "https://rutracker.org/forum/viewtopic.php?t=6810475", "/downloads/tv shows")
+
+	require.NoError(t, err)
+	require.NotNil(t, saved)
+	assert.Equal(t, "tv", saved.Category, "category should come from the location")
+	assert.Equal(t, types.DownloadOptions{
+		Destination: "/downloads/tv shows",
+		Category:    "tv",
+		Tags:        []string{"magnet-feed-sync", "rutracker.org", "6810475"},
+	}, dClient.lastOptions)
+}
+
+func TestCreateFromURL_AddsTrackerTorrentFile(t *testing.T) {
+	torrent := []byte("d4:infod6:lengthi5e4:name7:privateee")
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return nil, sql.ErrNoRows
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error { return nil },
+	}
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: "42", Magnet: "magnet:?xt=urn:btih:abc123", TorrentFile: torrent, Location: location}, nil
+		},
+	}
+	var added []byte
+	dClient := &mockDownloadClient{
+		createDownloadTaskFunc: func(url, destination string) error {
+			t.Fatal("magnet should not be sent when the tracker provided a torrent file")
+			return nil
+		},
+		addTorrentFileFunc: func(data []byte, opts types.DownloadOptions) error {
+			added = data
+			return nil
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		Tracker:         parser,
+		DClient:         dClient,
+		Store:           store,
+	})
+
+	_, err := client.CreateFromURL(context.Background(), "https://rutracker.org/forum/viewtopic.php?t=42", "/downloads/movies")
+
+	require.NoError(t, err)
+	assert.Equal(t, torrent, added)
+	assert.Equal(t, "/downloads/movies", dClient.lastOptions.Destination)
+}
+
+func TestCreateFromURL_PinsInstance(t *testing.T) {
+	tests := []struct {
+		name         string
+		location     string
+		instance     string
+		router       bool
+		wantInstance string
+		wantErr      error
+	}{
+		{name: "routed location", location: "/downloads/movies", router: true, wantInstance: "seedbox"},
+		{name: "unrouted location", location: "/downloads/tv shows", router: true, wantInstance: "nas"},
+		{name: "explicit instance", location: "/downloads/movies", instance: "nas", router: true, wantInstance: "nas"},
+		{name: "unknown instance", instance: "backup", router: true, wantErr: types.ErrUnknownInstance},
+		{name: "single client", location: "/downloads/movies"},
+		{name: "instance without router", instance: "nas", wantErr: types.ErrUnknownInstance},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *tracker.FileMetadata
+			store := &mockFileStore{
+				getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+					return nil, sql.ErrNoRows
+				},
+				createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+					saved = metadata
+					return nil
+				},
+			}
+			parser := &mockFileParser{
+				parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+					return &tracker.FileMetadata{ID: "1", Magnet: "magnet:?xt=urn:btih:abc123", Location: location}, nil
+				},
+			}
+			mock := &mockDownloadClient{createDownloadTaskFunc: func(url, destination string) error { return nil }}
+			var dClient DownloadClient = mock
+			if tt.router {
+				dClient = &mockRouterClient{mockDownloadClient: mock, routes: map[string]string{"/downloads/movies": "seedbox"}}
+			}
+
+			client := NewClient(&ClientCtx{
+				MessagesForSend: make(chan string, 10),
+				Tracker:         parser,
+				DClient:         dClient,
+				Store:           store,
+			})
+
+			_, err := client.CreateFromURLOnInstance(context.Background(), "https://rutracker.org/forum/viewtopic.php?t=1", tt.location, tt.instance)
+
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, saved, "nothing should be stored for an unknown instance")
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, saved)
+			assert.Equal(t, tt.wantInstance, saved.Instance)
+			assert.Equal(t, tt.wantInstance, mock.lastOptions.Instance)
+		})
+	}
+}
+
+func TestProcessFileMetadata_UnpinnedTask_RedownloadsOnOwningInstance(t *testing.T) {
+	mock := &mockDownloadClient{
+		getHashByMagnetFunc: func(magnet string) (string, error) {
+			hash, err := hashesByMagnet(magnet)
+			return "seedbox:" + hash, err
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyKeep, mock)
+	client.dClient = &mockRouterClient{mockDownloadClient: mock}
+
+	client.processFileMetadata(context.Background(), current)
+
+	assert.Equal(t, "seedbox", mock.lastOptions.Instance, "the update should go to the instance that has the previous torrent")
+}
+
+func TestProcessFileMetadata_PinnedTask_KeepsInstance(t *testing.T) {
+	mock := &mockDownloadClient{}
+	client, current := newReplacementTest(t, types.UpdatePolicyKeep, mock)
+	client.dClient = &mockRouterClient{mockDownloadClient: mock, routes: map[string]string{"/downloads": "nas"}}
+	current.Instance = "seedbox"
+
+	client.processFileMetadata(context.Background(), current)
+
+	assert.Equal(t, "seedbox", mock.lastOptions.Instance)
+}
+
+func TestProcessFileMetadata_PinnedTask_RemovesPreviousTorrentOnItsInstance(t *testing.T) {
+	removed := make(chan removal, 1)
+	mock := &mockDownloadClient{
+		getHashByMagnetFunc: hashesByMagnet,
+		removeTorrentFunc: func(taskID string, deleteFiles bool) error {
+			removed <- removal{taskID, deleteFiles}
+			return nil
+		},
+	}
+	client, current := newReplacementTest(t, types.UpdatePolicyRemove, mock)
+	router := &mockRouterClient{mockDownloadClient: mock}
+	client.dClient = router
+	current.Instance = "seedbox"
+
+	client.processFileMetadata(context.Background(), current)
+
+	select {
+	case r := <-removed:
+		assert.Equal(t, removal{taskID: "seedbox:ABC123", deleteFiles: false}, r)
+	case <-time.After(time.Second):
+		t.Fatal("previous torrent should be removed")
+	}
+	assert.Equal(t, "seedbox", router.scopedLookups[0], "a pinned task should only be looked up on its instance")
+}
+
+func TestSyncDownloadStatus_PinnedTaskUsesItsInstance(t *testing.T) {
+	var statusTaskID string
+	router := &mockRouterClient{
+		mockDownloadClient: &mockDownloadClient{
+			getHashByMagnetFunc: hashesByMagnet,
+			getTorrentStatusFunc: func(taskID string) (types.TorrentStatus, error) {
+				statusTaskID = taskID
+				return types.TorrentStatus{State: types.TorrentStateDownloading}, nil
+			},
+		},
+	}
+	store := &mockFileStore{
+		getAllFunc: func() ([]*tracker.FileMetadata, error) {
+			return []*tracker.FileMetadata{{ID: "1", Magnet: "magnet:?xt=urn:btih:abc123", Instance: "seedbox"}}, nil
+		},
+		updateStatusFunc: func(id, magnet string, status types.TorrentStatus) error { return nil },
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		DClient:         router,
+		Store:           store,
+	})
+
+	client.SyncDownloadStatus(context.Background())
+
+	assert.Equal(t, "seedbox:ABC123", statusTaskID)
+	assert.Equal(t, []string{"seedbox"}, router.scopedLookups)
+}
+
+type mockPageCache struct {
+	forgotten []string
+}
+
+func (m *mockPageCache) Forget(source string) {
+	m.forgotten = append(m.forgotten, source)
+}
+
+func TestRemoveTask_ForgetsCachedPages(t *testing.T) {
+	var removedID string
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: id, OriginalUrl: "https://rutracker.org/forum/viewtopic.php?t=3304959"}, nil
+		},
+		removeFunc: func(id string) error {
+			removedID = id
+			return nil
+		},
+	}
+	cache := &mockPageCache{}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		DClient:         &mockDownloadClient{},
+		Store:           store,
+		PageCache:       cache,
+	})
+
+	require.NoError(t, client.RemoveTask("3304959"))
+
+	assert.Equal(t, "3304959", removedID)
+	assert.Equal(t, []string{"https://rutracker.org/forum/viewtopic.php?t=3304959"}, cache.forgotten)
+}
+
+func TestUpdateTaskLocation_PinsInstance(t *testing.T) {
+	var saved *tracker.FileMetadata
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: id, Magnet: "magnet:?xt=urn:btih:abc123", Location: "/downloads/movies"}, nil
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+			saved = metadata
+			return nil
+		},
+	}
+	dClient := &mockRouterClient{
+		mockDownloadClient: &mockDownloadClient{
+			getHashByMagnetFunc: func(magnet string) (string, error) { return "seedbox:ABC123", nil },
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		DClient:         dClient,
+		Store:           store,
+	})
+
+	require.NoError(t, client.UpdateTaskLocation("3304959", "/downloads/tv shows"))
+
+	require.NotNil(t, saved)
+	assert.Equal(t, "/downloads/tv shows", saved.Location)
+	assert.Equal(t, "seedbox", saved.Instance, "a moved task should stay on the instance that has the torrent")
+}
+
+func TestProcessFileMetadata_DifferentMagnet_KeepsCategory(t *testing.T) {
+	dClient := &mockDownloadClient{}
+	client, current := newReplacementTest(t, types.UpdatePolicyKeep, dClient)
+	current.Category = "tv"
+
+	client.processFileMetadata(context.Background(), current)
+
+	assert.Equal(t, "tv", dClient.lastOptions.Category)
+	assert.Contains(t, dClient.lastOptions.Tags, "3304959")
+}
+
+func TestUpdateTaskCategory(t *testing.T) {
+	tests := []struct {
+		name           string
+		setCategoryErr error
+		wantErr        bool
+	}{
+		{name: "applies category to the torrent"},
+		{name: "unsupported client keeps stored category", setCategoryErr: fmt.Errorf("set category: %w", errors.ErrUnsupported)},
+		{name: "client error is returned", setCategoryErr: fmt.Errorf("qbittorrent unavailable"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var saved *tracker.FileMetadata
+			store := &mockFileStore{
+				getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+					return &tracker.FileMetadata{ID: id, Magnet: "magnet:?xt=urn:btih:abc123", Category: "tv"}, nil
+				},
+				createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+					saved = metadata
+					return nil
+				},
+			}
+			var gotTaskID, gotCategory string
+			dClient := &mockDownloadClient{
+				getHashByMagnetFunc: hashesByMagnet,
+				setCategoryFunc: func(taskID, category string) error {
+					gotTaskID, gotCategory = taskID, category
+					return tt.setCategoryErr
+				},
+			}
+
+			client := NewClient(&ClientCtx{
+				MessagesForSend: make(chan string, 10),
+				DClient:         dClient,
+				Store:           store,
+			})
+
+			err := client.UpdateTaskCategory("3304959", "anime")
+
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.NotNil(t, saved)
+			assert.Equal(t, "anime", saved.Category)
+			assert.Equal(t, "ABC123", gotTaskID)
+			assert.Equal(t, "anime", gotCategory)
+		})
+	}
+}
+
+func TestSyncDownloadStatus(t *testing.T) {
+	files := []*tracker.FileMetadata{
+		{ID: "1", Magnet: "magnet:?xt=urn:btih:aaa"},
+		{ID: "2", Magnet: "magnet:?xt=urn:btih:bbb"},
+		{ID: "3", Magnet: "magnet:?xt=urn:btih:ccc"},
+	}
+
+	saved := map[string]types.TorrentStatus{}
+	store := &mockFileStore{
+		getAllFunc: func() ([]*tracker.FileMetadata, error) { return files, nil },
+		updateStatusFunc: func(id, magnet string, status types.TorrentStatus) error {
+			saved[id] = status
+			return nil
+		},
+	}
+
+	dClient := &mockDownloadClient{
+		getHashByMagnetFunc: func(magnet string) (string, error) {
+			switch magnet {
+			case "magnet:?xt=urn:btih:aaa":
+				return "AAA", nil
+			case "magnet:?xt=urn:btih:bbb":
+				return "", types.ErrTorrentNotFound
+			default:
+				return "", fmt.Errorf("connection refused")
+			}
+		},
+		getTorrentStatusFunc: func(taskID string) (types.TorrentStatus, error) {
+			return types.TorrentStatus{State: types.TorrentStateDownloading, Progress: 0.42, Size: 1024, ETA: 60}, nil
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		DClient:         dClient,
+		Store:           store,
+	})
+
+	client.SyncDownloadStatus(context.Background())
+
+	assert.Equal(t, map[string]types.TorrentStatus{
+		"1": {State: types.TorrentStateDownloading, Progress: 0.42, Size: 1024, ETA: 60},
+		"2": {State: types.TorrentStateMissing},
+	}, saved, "unreachable client must not overwrite the last known status")
+}
+
+func TestProcessFileMetadata_SameMagnet_KeepsDownloadStatus(t *testing.T) {
+	magnet := "magnet:?xt=urn:btih:abc123"
+	status := types.TorrentStatus{State: types.TorrentStateCompleted, Progress: 1, Size: 2048}
+
+	var savedMetadata *tracker.FileMetadata
+	store := &mockFileStore{
+		getByIdFunc: func(id string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: id, OriginalUrl: "https://rutracker.org/forum/viewtopic.php?t=1", Magnet: magnet, Download: status}, nil
+		},
+		createOrReplaceFunc: func(metadata *tracker.FileMetadata) error {
+			savedMetadata = metadata
+			return nil
+		},
+	}
+	parser := &mockFileParser{
+		parseFunc: func(url, location string) (*tracker.FileMetadata, error) {
+			return &tracker.FileMetadata{ID: "1", OriginalUrl: url, Magnet: magnet}, nil
+		},
+	}
+
+	client := NewClient(&ClientCtx{
+		MessagesForSend: make(chan string, 10),
+		Tracker:         parser,
+		DClient:         &mockDownloadClient{},
+		Store:           store,
+	})
+
+	client.processFileMetadata(context.Background(), &tracker.FileMetadata{
+		ID:          "1",
+		OriginalUrl: "https://rutracker.org/forum/viewtopic.php?t=1",
+		Magnet:      magnet,
+	})
+
+	require.NotNil(t, savedMetadata)
+	assert.Equal(t, status, savedMetadata.Download)
+}
+
+func TestSyncDownloadStatus_NotifiesWhenRedownloadCompletes(t *testing.T) {
+	startedAt := time.Date(2026, 10, 18, 10, 0, 0, 0, time.UTC)
+	completedAt := startedAt.Add(90 * time.Minute)
+
+	files := []*tracker.FileMetadata{
+		{ID: "1", Name: "Severance S02E05", Magnet: "magnet:?xt=urn:btih:aaa", RedownloadStartedAt: startedAt},
+		{ID: "2", Name: "Tracked From Start", Magnet: "magnet:?xt=urn:btih:bbb"},
+		{ID: "3", Name: "Still Downloading", Magnet: "magnet:?xt=urn:btih:ccc", RedownloadStartedAt: startedAt},
+	}
+
+	var cleared []string
+	store := &mockFileStore{
+		getAllFunc:       func() ([]*tracker.FileMetadata, error) { return files, nil },
+		updateStatusFunc: func(id, magnet string, status types.TorrentStatus) error { return nil },
+		clearRedownloadFunc: func(id, magnet string) error {
+			cleared = append(cleared, id)
+			return nil
+		},
+	}
+
+	dClient := &mockDownloadClient{
+		getHashByMagnetFunc: func(magnet string) (string, error) { return magnet, nil },
+		getTorrentStatusFunc: func(taskID string) (types.TorrentStatus, error) {
+			if taskID == "magnet:?xt=urn:btih:ccc" {
+				return types.TorrentStatus{State: types.TorrentStateDownloading, Progress: 0.3}, nil
+			}
+			return types.TorrentStatus{State: types.TorrentStateCompleted, Progress: 1, Size: 3 << 30, CompletedAt: completedAt}, nil
+		},
+	}
+
+	msgChan := make(chan string, 10)
+	client := NewClient(&ClientCtx{
+		MessagesForSend: msgChan,
+		DClient:         dClient,
+		Store:           store,
+	})
+
+	client.SyncDownloadStatus(context.Background())
+
+	assert.Equal(t, []string{"1"}, cleared, "only completed redownloads should be notified")
+	require.Len(t, msgChan, 1)
+	msg := <-msgChan
+	assert.Contains(t, msg, "Download completed")
+	assert.Contains(t, msg, "Severance S02E05")
+	assert.Contains(t, msg, "Elapsed: 1h30m0s")
+	assert.Contains(t, msg, "Size: 3.0 GiB")
+}
+
+func TestSearch_MarksTrackableResults(t *testing.T) {
+	searcher := &mockSearcher{results: []types.SearchResult{
+		{Title: "Rutracker", TrackerURL: "https://rutracker.org/forum/viewtopic.php?t=6810475", Magnet: "magnet:?xt=urn:btih:abc"},
+		{Title: "Unknown tracker", TrackerURL: "https://example.org/torrent/1", Magnet: "magnet:?xt=urn:btih:def"},
+		{Title: "No tracker page", Link: "https://jackett.example.com/dl/rutor/?path=x"},
+	}}
+	parser := &mockFileParser{
+		canHandleFunc: func(url string) bool {
+			return strings.HasPrefix(url, "https://rutracker.org/")
+		},
+	}
+
+	client := NewClient(&ClientCtx{Tracker: parser, Searcher: searcher})
+
+	results, err := client.Search(context.Background(), types.SearchQuery{Query: "severance", Indexer: "rutracker"})
+	require.NoError(t, err)
+
+	assert.Equal(t, types.SearchQuery{Query: "severance", Indexer: "rutracker"}, searcher.lastQuery)
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Trackable)
+	assert.False(t, results[1].Trackable)
+	assert.False(t, results[2].Trackable)
+}
+
+func TestSearch_FiltersByReleaseRules(t *testing.T) {
+	searcher := &mockSearcher{results: []types.SearchResult{
+		{Title: "Разделение / Severance / Сезон: 2 / Серии: 1-10 из 10 [2025, WEB-DL 2160p] MVO"},
+		{Title: "Разделение / Severance / Сезон: 2 / Серии: 1-10 из 10 [2025, WEB-DL 1080p] MVO"},
+		{Title: "Разделение / Severance / Сезон: 1 / Серии: 1-9 из 9 [2022, WEB-DL 2160p] MVO"},
+	}}
+	client := NewClient(&ClientCtx{Tracker: &mockFileParser{}, Searcher: searcher})
+
+	results, err := client.Search(context.Background(), types.SearchQuery{Query: "severance", Accept: "season:2", Reject: "resolution:1080p"})
+	require.NoError(t, err)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, searcher.results[0].Title, results[0].Title)
+
+	_, err = client.Search(context.Background(), types.SearchQuery{Query: "severance", Accept: "quality:hd"})
+	require.Error(t, err)
+}
+
+func TestSearch_NotConfigured(t *testing.T) {
+	client := NewClient(&ClientCtx{Tracker: &mockFileParser{}})
+
+	_, err := client.Search(context.Background(), types.SearchQuery{Query: "severance"})
+	require.ErrorIs(t, err, types.ErrSearchNotConfigured)
+}
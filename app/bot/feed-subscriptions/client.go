@@ -0,0 +1,296 @@
+package feed_subscriptions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mmcdole/gofeed"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"magnet-feed-sync/app/release"
+	"magnet-feed-sync/app/tracker"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+type FeedStore interface {
+	Create(feed *types.Feed) error
+	GetAll() ([]*types.Feed, error)
+	Remove(id int64) error
+	HasItem(feedID int64, guid string) (bool, error)
+	AddItem(feedID int64, guid, title string) error
+	UpdateLastChecked(id int64, checkedAt time.Time) error
+}
+
+type TaskCreator interface {
+	CreateFromURL(ctx context.Context, url, location string) (*tracker.FileMetadata, error)
+	DownloadNow(ctx context.Context, source, location string) error
+}
+
+type Fetcher interface {
+	FetchRaw(ctx context.Context, url string) ([]byte, string, error)
+}
+
+type Client struct {
+	mu              sync.Mutex
+	messagesForSend chan string
+	store           FeedStore
+	tasks           TaskCreator
+	fetcher         Fetcher
+}
+
+type ClientCtx struct {
+	MessagesForSend chan string
+	Store           FeedStore
+	Tasks           TaskCreator
+	Fetcher         Fetcher
+}
+
+func NewClient(ctx *ClientCtx) *Client {
+	return &Client{
+		messagesForSend: ctx.MessagesForSend,
+		store:           ctx.Store,
+		tasks:           ctx.Tasks,
+		fetcher:         ctx.Fetcher,
+	}
+}
+
+func (c *Client) Subscribe(feed *types.Feed) error {
+	u, err := url.Parse(feed.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an http(s) URL", types.ErrInvalidFeed)
+	}
+	if _, err := types.ParseFeedMode(string(feed.Mode)); err != nil {
+		return fmt.Errorf("%w: %v", types.ErrInvalidFeed, err)
+	}
+	if _, err := newFilter(feed); err != nil {
+		return fmt.Errorf("%w: %v", types.ErrInvalidFeed, err)
+	}
+
+	return c.store.Create(feed)
+}
+
+func (c *Client) Feeds() ([]*types.Feed, error) {
+	return c.store.GetAll()
+}
+
+func (c *Client) Unsubscribe(id int64) error {
+	return c.store.Remove(id)
+}
+
+func (c *Client) CheckFeeds(ctx context.Context) {
+	ctx, span := otel.Tracer("feed-subscriptions").Start(ctx, "CheckFeeds")
+	defer span.End()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	feeds, err := c.store.GetAll()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		slog.ErrorContext(ctx, "error getting feeds", "error", err)
+		return
+	}
+
+	for _, feed := range feeds {
+		if err := c.checkFeed(ctx, feed); err != nil {
+			slog.ErrorContext(ctx, "failed to check feed", "feedId", feed.ID, "url", utils.RedactURL(feed.URL), "error", err)
+		}
+	}
+}
+
+func (c *Client) checkFeed(ctx context.Context, feed *types.Feed) error {
+	ctx, span := otel.Tracer("feed-subscriptions").Start(ctx, "checkFeed")
+	defer span.End()
+
+	filter, err := newFilter(feed)
+	if err != nil {
+		return err
+	}
+
+	data, _, err := c.fetcher.FetchRaw(ctx, feed.URL)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to fetch feed: %w", err)
+	}
+
+	parsed, err := gofeed.NewParser().Parse(bytes.NewReader(data))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return fmt.Errorf("failed to parse feed: %w", err)
+	}
+
+	firstCheck := feed.LastCheckedAt.IsZero()
+	items := slices.Clone(parsed.Items)
+	slices.Reverse(items)
+
+	for _, item := range items {
+		guid := itemGUID(item)
+		if guid == "" {
+			continue
+		}
+
+		seen, err := c.store.HasItem(feed.ID, guid)
+		if err != nil {
+			return fmt.Errorf("failed to check feed item: %w", err)
+		}
+		if seen {
+			continue
+		}
+
+		if !firstCheck && filter.match(item.Title) {
+			if err := c.createTask(ctx, feed, item); err != nil {
+				if !errors.Is(err, tracker.ErrProviderNotFound) && !errors.Is(err, errNoSource) {
+					slog.ErrorContext(ctx, "failed to create task from feed item", "feedId", feed.ID, "title", item.Title, "error", err)
+					continue
+				}
+				slog.WarnContext(ctx, "skipping feed item", "feedId", feed.ID, "title", item.Title, "error", err)
+			} else {
+				slog.InfoContext(ctx, "task created from feed item", "feedId", feed.ID, "title", item.Title, "mode", feed.Mode)
+				c.sendNotification(feed, item)
+			}
+		}
+
+		if err := c.store.AddItem(feed.ID, guid, item.Title); err != nil {
+			return fmt.Errorf("failed to store feed item: %w", err)
+		}
+	}
+
+	if err := c.store.UpdateLastChecked(feed.ID, time.Now()); err != nil {
+		return fmt.Errorf("failed to update feed: %w", err)
+	}
+
+	return nil
+}
+
+var errNoSource = errors.New("feed item has no magnet or link")
+
+func (c *Client) createTask(ctx context.Context, feed *types.Feed, item *gofeed.Item) error {
+	if feed.Mode == types.FeedModeTrack {
+		err := errNoSource
+		for _, candidate := range trackerURLs(item) {
+			if _, err = c.tasks.CreateFromURL(ctx, candidate, feed.Location); !errors.Is(err, tracker.ErrProviderNotFound) {
+				return err
+			}
+		}
+		return err
+	}
+
+	source := itemSource(item)
+	if source == "" {
+		return errNoSource
+	}
+	return c.tasks.DownloadNow(ctx, source, feed.Location)
+}
+
+func (c *Client) sendNotification(feed *types.Feed, item *gofeed.Item) {
+	if c.messagesForSend == nil {
+		return
+	}
+
+	name := feed.Name
+	if name == "" {
+		name = feed.URL
+	}
+	replacer := strings.NewReplacer("\\", "\\\\", "`", "\\`")
+	c.messagesForSend <- fmt.Sprintf("📰 New feed item:\n\n```\n%s\nFeed: %s\n```", replacer.Replace(item.Title), replacer.Replace(name))
+}
+
+type filter struct {
+	include *regexp.Regexp
+	exclude *regexp.Regexp
+	rules   release.Rules
+}
+
+func newFilter(feed *types.Feed) (*filter, error) {
+	var f filter
+	var err error
+	if feed.Include != "" {
+		if f.include, err = regexp.Compile("(?i)" + feed.Include); err != nil {
+			return nil, fmt.Errorf("invalid include filter: %w", err)
+		}
+	}
+	if feed.Exclude != "" {
+		if f.exclude, err = regexp.Compile("(?i)" + feed.Exclude); err != nil {
+			return nil, fmt.Errorf("invalid exclude filter: %w", err)
+		}
+	}
+	if f.rules, err = release.ParseRules(feed.Accept, feed.Reject); err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+func (f *filter) match(title string) bool {
+	if f.include != nil && !f.include.MatchString(title) {
+		return false
+	}
+	if f.exclude != nil && f.exclude.MatchString(title) {
+		return false
+	}
+	return f.rules.MatchTitle(title)
+}
+
+func itemGUID(item *gofeed.Item) string {
+	if item.GUID != "" {
+		return item.GUID
+	}
+	if item.Link != "" {
+		return item.Link
+	}
+	return item.Title
+}
+
+func itemSource(item *gofeed.Item) string {
+	if strings.HasPrefix(item.Link, "magnet:") {
+		return item.Link
+	}
+	for _, enclosure := range item.Enclosures {
+		if strings.HasPrefix(enclosure.URL, "magnet:") {
+			return enclosure.URL
+		}
+	}
+	if magnet := torznabAttr(item, "magneturl"); strings.HasPrefix(magnet, "magnet:") {
+		return magnet
+	}
+	for _, enclosure := range item.Enclosures {
+		if strings.HasPrefix(enclosure.URL, "http") {
+			return enclosure.URL
+		}
+	}
+	if strings.HasPrefix(item.Link, "http") {
+		return item.Link
+	}
+	return ""
+}
+
+func trackerURLs(item *gofeed.Item) []string {
+	var urls []string
+	for _, candidate := range []string{item.Link, item.GUID} {
+		if strings.HasPrefix(candidate, "http") && !slices.Contains(urls, candidate) {
+			urls = append(urls, candidate)
+		}
+	}
+	return urls
+}
+
+func torznabAttr(item *gofeed.Item, name string) string {
+	for _, attr := range item.Extensions["torznab"]["attr"] {
+		if attr.Attrs["name"] == name {
+			return attr.Attrs["value"]
+		}
+	}
+	return ""
+}
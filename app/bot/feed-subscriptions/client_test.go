@@ -0,0 +1,221 @@
+package feed_subscriptions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/tracker"
+	"magnet-feed-sync/app/types"
+)
+
+type mockFeedStore struct {
+	feeds       []*types.Feed
+	items       map[string]string
+	lastChecked map[int64]time.Time
+}
+
+func newMockFeedStore(feeds ...*types.Feed) *mockFeedStore {
+	return &mockFeedStore{feeds: feeds, items: map[string]string{}, lastChecked: map[int64]time.Time{}}
+}
+
+func (m *mockFeedStore) Create(feed *types.Feed) error {
+	for _, existing := range m.feeds {
+		if existing.URL == feed.URL {
+			return types.ErrFeedExists
+		}
+	}
+	feed.ID = int64(len(m.feeds) + 1)
+	m.feeds = append(m.feeds, feed)
+	return nil
+}
+
+func (m *mockFeedStore) GetAll() ([]*types.Feed, error) { return m.feeds, nil }
+
+func (m *mockFeedStore) Remove(id int64) error { return nil }
+
+func (m *mockFeedStore) HasItem(feedID int64, guid string) (bool, error) {
+	_, ok := m.items[fmt.Sprintf("%d/%s", feedID, guid)]
+	return ok, nil
+}
+
+func (m *mockFeedStore) AddItem(feedID int64, guid, title string) error {
+	m.items[fmt.Sprintf("%d/%s", feedID, guid)] = title
+	return nil
+}
+
+func (m *mockFeedStore) UpdateLastChecked(id int64, checkedAt time.Time) error {
+	m.lastChecked[id] = checkedAt
+	for _, feed := range m.feeds {
+		if feed.ID == id {
+			feed.LastCheckedAt = checkedAt
+		}
+	}
+	return nil
+}
+
+type mockTaskCreator struct {
+	createdURLs  []string
+	downloads    []string
+	locations    []string
+	handledURLs  map[string]bool
+	downloadErrs map[string]error
+}
+
+func (m *mockTaskCreator) CreateFromURL(_ context.Context, url, location string) (*tracker.FileMetadata, error) {
+	if !m.handledURLs[url] {
+		return nil, fmt.Errorf("%w for url: %s", tracker.ErrProviderNotFound, url)
+	}
+	m.createdURLs = append(m.createdURLs, url)
+	m.locations = append(m.locations, location)
+	return &tracker.FileMetadata{OriginalUrl: url}, nil
+}
+
+func (m *mockTaskCreator) DownloadNow(_ context.Context, source, location string) error {
+	if err := m.downloadErrs[source]; err != nil {
+		return err
+	}
+	m.downloads = append(m.downloads, source)
+	m.locations = append(m.locations, location)
+	return nil
+}
+
+type mockFetcher struct {
+	body string
+}
+
+func (m *mockFetcher) FetchRaw(_ context.Context, _ string) ([]byte, string, error) {
+	return []byte(m.body), "application/rss+xml", nil
+}
+
+func rssFeed(items ...string) string {
+	return `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+  <channel>
+    <title>Test feed</title>
+    ` + strings.Join(items, "\n    ") + `
+  </channel>
+</rss>`
+}
+
+func TestCheckFeeds_FirstCheckOnlyRemembersItems(t *testing.T) {
+	feed := &types.Feed{ID: 1, URL: "https://example.com/rss", Mode: types.FeedModeOnce}
+	store := newMockFeedStore(feed)
+	tasks := &mockTaskCreator{}
+	c := NewClient(&ClientCtx{Store: store, Tasks: tasks, Fetcher: &mockFetcher{body: rssFeed(
+		`<item><title>Severance S02E01</title><guid>a</guid><link>magnet:?xt=urn:btih:aaa</link></item>`,
+	)}})
+
+	c.CheckFeeds(context.Background())
+
+	assert.Empty(t, tasks.downloads)
+	assert.Equal(t, map[string]string{"1/a": "Severance S02E01"}, store.items)
+	assert.False(t, store.lastChecked[1].IsZero())
+}
+
+func TestCheckFeeds_OnceModeDownloadsNewMatchingItems(t *testing.T) {
+	feed := &types.Feed{
+		ID:            1,
+		URL:           "https://example.com/rss",
+		Include:       "severance.*2160p",
+		Exclude:       "hdr",
+		Location:      "/downloads/tv shows",
+		Mode:          types.FeedModeOnce,
+		LastCheckedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMockFeedStore(feed)
+	store.items["1/seen"] = "Severance S02E01 2160p"
+	tasks := &mockTaskCreator{downloadErrs: map[string]error{
+		"magnet:?xt=urn:btih:fail": errors.New("client unavailable"),
+	}}
+	messages := make(chan string, 10)
+	c := NewClient(&ClientCtx{MessagesForSend: messages, Store: store, Tasks: tasks, Fetcher: &mockFetcher{body: rssFeed(
+		`<item><title>Severance S02E04 2160p</title><guid>fail</guid><link>magnet:?xt=urn:btih:fail</link></item>`,
+		`<item><title>Severance S02E03 2160p</title><guid>torznab</guid><link>https://jackett/dl/1</link><torznab:attr name="magneturl" value="magnet:?xt=urn:btih:ccc"/></item>`,
+		`<item><title>Severance S02E02 2160p HDR</title><guid>excluded</guid><link>magnet:?xt=urn:btih:bbb</link></item>`,
+		`<item><title>SEVERANCE S02E02 2160P</title><guid>enclosure</guid><enclosure url="https://example.com/e02.torrent" type="application/x-bittorrent"/></item>`,
+		`<item><title>Severance S02E01 2160p</title><guid>seen</guid><link>magnet:?xt=urn:btih:aaa</link></item>`,
+	)}})
+
+	c.CheckFeeds(context.Background())
+
+	assert.Equal(t, []string{"https://example.com/e02.torrent", "magnet:?xt=urn:btih:ccc"}, tasks.downloads)
+	assert.Equal(t, []string{"/downloads/tv shows", "/downloads/tv shows"}, tasks.locations)
+	assert.Contains(t, store.items, "1/excluded", "non-matching items are remembered")
+	assert.NotContains(t, store.items, "1/fail", "failed items are retried on the next check")
+	require.Len(t, messages, 2)
+	assert.Contains(t, <-messages, "SEVERANCE S02E02 2160P")
+}
+
+func TestCheckFeeds_TrackModeCreatesTrackedTasks(t *testing.T) {
+	feed := &types.Feed{
+		ID:            1,
+		URL:           "https://nnmclub.to/forum/rss.php",
+		Mode:          types.FeedModeTrack,
+		Location:      "/downloads/movies",
+		LastCheckedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMockFeedStore(feed)
+	tasks := &mockTaskCreator{handledURLs: map[string]bool{"https://nnmclub.to/forum/viewtopic.php?t=1": true}}
+	c := NewClient(&ClientCtx{Store: store, Tasks: tasks, Fetcher: &mockFetcher{body: rssFeed(
+		`<item><title>Movie</title><guid>https://nnmclub.to/forum/viewtopic.php?t=1</guid><link>https://nnmclub.to/forum/download.php?id=1</link></item>`,
+		`<item><title>Other</title><guid>https://unknown.example/topic/2</guid><link>https://unknown.example/topic/2</link></item>`,
+	)}})
+
+	c.CheckFeeds(context.Background())
+
+	assert.Equal(t, []string{"https://nnmclub.to/forum/viewtopic.php?t=1"}, tasks.createdURLs)
+	assert.Equal(t, []string{"/downloads/movies"}, tasks.locations)
+	assert.Contains(t, store.items, "1/https://unknown.example/topic/2", "unsupported items are not retried")
+}
+
+func TestCheckFeeds_AppliesReleaseRules(t *testing.T) {
+	feed := &types.Feed{
+		ID:            1,
+		URL:           "https://rutracker.org/forum/atom/f-2366.atom",
+		Accept:        "season:2 episode:9-",
+		Reject:        "resolution:720p",
+		Mode:          types.FeedModeOnce,
+		LastCheckedAt: time.Now().Add(-time.Hour),
+	}
+	store := newMockFeedStore(feed)
+	tasks := &mockTaskCreator{}
+	c := NewClient(&ClientCtx{Store: store, Tasks: tasks, Fetcher: &mockFetcher{body: rssFeed(
+		`<item><title>Разделение / Severance / Сезон: 2 / Серии: 1-10 из 10 [2025, WEB-DL 720p] MVO</title><guid>hd</guid><link>magnet:?xt=urn:btih:ccc</link></item>`,
+		`<item><title>Разделение / Severance / Сезон: 2 / Серии: 1-10 из 10 [2025, WEB-DL 2160p] MVO</title><guid>full</guid><link>magnet:?xt=urn:btih:bbb</link></item>`,
+		`<item><title>Разделение / Severance / Сезон: 2 / Серии: 1-8 из 10 [2025, WEB-DL 2160p] MVO</title><guid>partial</guid><link>magnet:?xt=urn:btih:aaa</link></item>`,
+	)}})
+
+	c.CheckFeeds(context.Background())
+
+	assert.Equal(t, []string{"magnet:?xt=urn:btih:bbb"}, tasks.downloads)
+	assert.Len(t, store.items, 3)
+}
+
+func TestSubscribe(t *testing.T) {
+	store := newMockFeedStore()
+	c := NewClient(&ClientCtx{Store: store})
+
+	feed := &types.Feed{URL: "https://example.com/rss", Include: "2160p", Mode: types.FeedModeOnce}
+	require.NoError(t, c.Subscribe(feed))
+	assert.Equal(t, int64(1), feed.ID)
+
+	assert.ErrorIs(t, c.Subscribe(&types.Feed{URL: "https://example.com/rss", Mode: types.FeedModeOnce}), types.ErrFeedExists)
+
+	invalid := []*types.Feed{
+		{URL: "ftp://example.com/rss", Mode: types.FeedModeOnce},
+		{URL: "https://example.com/other", Mode: "always"},
+		{URL: "https://example.com/other", Include: "(", Mode: types.FeedModeOnce},
+		{URL: "https://example.com/other", Exclude: "[", Mode: types.FeedModeTrack},
+		{URL: "https://example.com/other", Accept: "quality:hd", Mode: types.FeedModeOnce},
+		{URL: "https://example.com/other", Reject: "episode:x", Mode: types.FeedModeTrack},
+	}
+	for _, feed := range invalid {
+		assert.ErrorIs(t, c.Subscribe(feed), types.ErrInvalidFeed, feed.URL)
+	}
+}
@@ -1,17 +1,163 @@
 package config
 
 import (
+	"fmt"
 	"log/slog"
+	"net/url"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 	"github.com/joho/godotenv"
+	"magnet-feed-sync/app/types"
 )
 
 type QBittorrentConfig struct {
-	URL         string `env:"QBITTORRENT_URL"`
-	Username    string `env:"QBITTORRENT_USERNAME"`
-	Password    string `env:"QBITTORRENT_PASSWORD"`
-	Destination string `env:"QBITTORRENT_DESTINATION"`
+	URL             string               `env:"QBITTORRENT_URL"`
+	Username        string               `env:"QBITTORRENT_USERNAME"`
+	Password        string               `env:"QBITTORRENT_PASSWORD"`
+	Destination     string               `env:"QBITTORRENT_DESTINATION"`
+	Instances       QBittorrentInstances `env:"QBITTORRENT_INSTANCES"`
+	Routes          map[string]string    `env:"QBITTORRENT_ROUTES"`
+	DefaultInstance string               `env:"QBITTORRENT_DEFAULT_INSTANCE"`
+	Categories      map[string]string    `env:"QBITTORRENT_CATEGORIES"`
+	Locations       []types.Location
+}
+
+type QBittorrentInstance struct {
+	Name     string
+	URL      string
+	Username string
+	Password string
+}
+
+type QBittorrentInstances []QBittorrentInstance
+
+func (i *QBittorrentInstances) SetValue(value string) error {
+	var instances QBittorrentInstances
+	seen := map[string]bool{}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, rawURL, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return fmt.Errorf("invalid qbittorrent instance %q, expected name=url", item)
+		}
+		if seen[name] {
+			return fmt.Errorf("duplicate qbittorrent instance %q", name)
+		}
+		seen[name] = true
+
+		u, err := url.Parse(strings.TrimSpace(rawURL))
+		if err != nil || u.Host == "" {
+			return fmt.Errorf("invalid url for qbittorrent instance %q", name)
+		}
+
+		instance := QBittorrentInstance{Name: name}
+		if u.User != nil {
+			instance.Username = u.User.Username()
+			instance.Password, _ = u.User.Password()
+			u.User = nil
+		}
+		instance.URL = u.String()
+
+		instances = append(instances, instance)
+	}
+
+	*i = instances
+	return nil
+}
+
+type Locations []types.Location
+
+var commandPattern = regexp.MustCompile(`^[a-z0-9_]{1,32}$`)
+
+func (l *Locations) SetValue(value string) error {
+	var locations Locations
+	ids := map[string]bool{}
+	commands := map[string]bool{}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		fields := strings.Split(item, "|")
+		if len(fields) > 4 {
+			return fmt.Errorf("invalid location %q, expected id|name|command|category", item)
+		}
+		for i := range fields {
+			fields[i] = strings.TrimSpace(fields[i])
+		}
+		fields = append(fields, make([]string, 4-len(fields))...)
+
+		location := types.Location{
+			ID:       fields[0],
+			Name:     fields[1],
+			Command:  strings.TrimPrefix(fields[2], "/"),
+			Category: fields[3],
+		}
+		if location.ID != "/" {
+			location.ID = strings.TrimRight(location.ID, "/")
+		}
+		if location.ID == "" {
+			return fmt.Errorf("invalid location %q, id is required", item)
+		}
+		if ids[location.ID] {
+			return fmt.Errorf("duplicate location %q", location.ID)
+		}
+		ids[location.ID] = true
+
+		if location.Name == "" {
+			location.Name = path.Base(location.ID)
+		}
+
+		if location.Command != "" {
+			if !commandPattern.MatchString(location.Command) {
+				return fmt.Errorf("invalid command %q for location %q, expected 1-32 lowercase letters, digits or underscores", location.Command, location.ID)
+			}
+			if commands[location.Command] {
+				return fmt.Errorf("duplicate command %q", location.Command)
+			}
+			commands[location.Command] = true
+		}
+
+		locations = append(locations, location)
+	}
+
+	*l = locations
+	return nil
+}
+
+type TransmissionConfig struct {
+	URL         string `env:"TRANSMISSION_URL"`
+	Username    string `env:"TRANSMISSION_USERNAME"`
+	Password    string `env:"TRANSMISSION_PASSWORD"`
+	Destination string `env:"TRANSMISSION_DESTINATION"`
+	Locations   []types.Location
+}
+
+type DelugeConfig struct {
+	URL         string `env:"DELUGE_URL"`
+	Password    string `env:"DELUGE_PASSWORD"`
+	Destination string `env:"DELUGE_DESTINATION"`
+	Locations   []types.Location
+}
+
+type BlackholeConfig struct {
+	Dir         string `env:"BLACKHOLE_DIR" env-default:"blackhole"`
+	Destination string `env:"BLACKHOLE_DESTINATION" env-default:"tv shows"`
+	Locations   []types.Location
 }
 
 type TelegramConfig struct {
@@ -25,19 +171,163 @@ type HttpConfig struct {
 }
 
 type JackettConfig struct {
-	URL string `env:"JACKETT_URL"`
+	URL    string `env:"JACKETT_URL"`
+	APIKey string `env:"JACKETT_API_KEY"`
+}
+
+type ProwlarrConfig struct {
+	URL    string `env:"PROWLARR_URL"`
+	APIKey string `env:"PROWLARR_API_KEY"`
+}
+
+type RateLimit struct {
+	RequestsPerMinute int
+	MinInterval       time.Duration
+	Concurrency       int
+}
+
+type RateLimitOverride struct {
+	RequestsPerMinute *int
+	MinInterval       *time.Duration
+	Concurrency       *int
+}
+
+type RateLimits map[string]RateLimitOverride
+
+var rateLimitTrackers = []string{"rutracker", "nnm", "kinozal", "rutor", "jackett", "prowlarr", "feeds"}
+
+func (r *RateLimits) SetValue(value string) error {
+	limits := RateLimits{}
+
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		name, spec, ok := strings.Cut(item, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || !slices.Contains(rateLimitTrackers, name) {
+			return fmt.Errorf("invalid rate limit %q, expected tracker=requests_per_minute/min_interval/concurrency with tracker one of %s", item, strings.Join(rateLimitTrackers, ", "))
+		}
+		if _, exists := limits[name]; exists {
+			return fmt.Errorf("duplicate rate limit for %q", name)
+		}
+
+		fields := strings.Split(spec, "/")
+		if len(fields) > 3 {
+			return fmt.Errorf("invalid rate limit %q, expected tracker=requests_per_minute/min_interval/concurrency", item)
+		}
+		fields = append(fields, make([]string, 3-len(fields))...)
+
+		var limit RateLimitOverride
+		if f := strings.TrimSpace(fields[0]); f != "" {
+			requests, err := strconv.Atoi(f)
+			if err != nil || requests < 0 {
+				return fmt.Errorf("invalid requests per minute %q for %q", f, name)
+			}
+			limit.RequestsPerMinute = &requests
+		}
+		if f := strings.TrimSpace(fields[1]); f != "" {
+			interval, err := time.ParseDuration(f)
+			if err != nil || interval < 0 {
+				return fmt.Errorf("invalid min interval %q for %q", f, name)
+			}
+			limit.MinInterval = &interval
+		}
+		if f := strings.TrimSpace(fields[2]); f != "" {
+			concurrency, err := strconv.Atoi(f)
+			if err != nil || concurrency < 0 {
+				return fmt.Errorf("invalid concurrency %q for %q", f, name)
+			}
+			limit.Concurrency = &concurrency
+		}
+		limits[name] = limit
+	}
+
+	*r = limits
+	return nil
+}
+
+type TrackerConfig struct {
+	Proxy             string        `env:"TRACKER_PROXY"`
+	UserAgent         string        `env:"TRACKER_USER_AGENT"`
+	Timeout           time.Duration `env:"TRACKER_TIMEOUT" env-default:"30s"`
+	Retries           int           `env:"TRACKER_RETRIES" env-default:"2"`
+	RequestsPerMinute int           `env:"TRACKER_REQUESTS_PER_MINUTE" env-default:"30"`
+	MinInterval       time.Duration `env:"TRACKER_MIN_INTERVAL" env-default:"1s"`
+	Concurrency       int           `env:"TRACKER_CONCURRENCY" env-default:"2"`
+	RateLimits        RateLimits    `env:"TRACKER_RATE_LIMITS"`
+	Cache             bool          `env:"TRACKER_CACHE" env-default:"true"`
+	CacheDir          string        `env:"TRACKER_CACHE_DIR" env-default:".db/page-cache"`
+	CacheMaxAge       time.Duration `env:"TRACKER_CACHE_MAX_AGE" env-default:"720h"`
+	CacheMaxEntries   int           `env:"TRACKER_CACHE_MAX_ENTRIES" env-default:"1000"`
+}
+
+func (c TrackerConfig) RateLimitFor(tracker string) RateLimit {
+	limit := RateLimit{
+		RequestsPerMinute: c.RequestsPerMinute,
+		MinInterval:       c.MinInterval,
+		Concurrency:       c.Concurrency,
+	}
+
+	override := c.RateLimits[tracker]
+	if override.RequestsPerMinute != nil {
+		limit.RequestsPerMinute = *override.RequestsPerMinute
+	}
+	if override.MinInterval != nil {
+		limit.MinInterval = *override.MinInterval
+	}
+	if override.Concurrency != nil {
+		limit.Concurrency = *override.Concurrency
+	}
+	return limit
+}
+
+type RutrackerConfig struct {
+	Username string `env:"RUTRACKER_USERNAME"`
+	Password string `env:"RUTRACKER_PASSWORD"`
+	Mirror   string `env:"RUTRACKER_MIRROR"`
+	Proxy    string `env:"RUTRACKER_PROXY"`
+}
+
+type NnmConfig struct {
+	Mirror string `env:"NNM_MIRROR"`
+	Proxy  string `env:"NNM_PROXY"`
+}
+
+type KinozalConfig struct {
+	Proxy string `env:"KINOZAL_PROXY"`
+}
+
+type RutorConfig struct {
+	Proxy string `env:"RUTOR_PROXY"`
 }
 
 type Config struct {
-	QBittorrent     QBittorrentConfig
-	Telegram        TelegramConfig
-	Http            HttpConfig
-	Jackett         JackettConfig
-	DryMode         bool   `env:"DRY_MODE" env-default:"false"`
-	Cron            string `env:"CRON" env-default:"0 * * * *"`
-	OtelServiceName string `env:"OTEL_SERVICE_NAME" env-default:"magnet-feed-sync"`
-	OtelEndpoint    string `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
-	LokiURL         string `env:"LOKI_URL"`
+	DownloadClient     string `env:"DOWNLOAD_CLIENT" env-default:"qbittorrent"`
+	QBittorrent        QBittorrentConfig
+	Transmission       TransmissionConfig
+	Deluge             DelugeConfig
+	Blackhole          BlackholeConfig
+	Telegram           TelegramConfig
+	Http               HttpConfig
+	Jackett            JackettConfig
+	Prowlarr           ProwlarrConfig
+	Tracker            TrackerConfig
+	Rutracker          RutrackerConfig
+	Nnm                NnmConfig
+	Kinozal            KinozalConfig
+	Rutor              RutorConfig
+	Locations          Locations          `env:"LOCATIONS"`
+	UpdatePolicy       types.UpdatePolicy `env:"UPDATE_POLICY" env-default:"keep"`
+	DryMode            bool               `env:"DRY_MODE" env-default:"false"`
+	Cron               string             `env:"CRON" env-default:"0 * * * *"`
+	StatusSyncInterval time.Duration      `env:"STATUS_SYNC_INTERVAL" env-default:"1m"`
+	FeedPollInterval   time.Duration      `env:"FEED_POLL_INTERVAL" env-default:"15m"`
+	OtelServiceName    string             `env:"OTEL_SERVICE_NAME" env-default:"magnet-feed-sync"`
+	OtelEndpoint       string             `env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	LokiURL            string             `env:"LOKI_URL"`
 }
 
 func Init() (*Config, error) {
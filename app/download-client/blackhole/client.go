@@ -0,0 +1,253 @@
+package blackhole
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+const (
+	magnetExt          = ".magnet"
+	torrentExt         = ".torrent"
+	maxTorrentFileSize = 10 * 1024 * 1024
+)
+
+type Client struct {
+	httpClient         *http.Client
+	root               string
+	defaultDestination string
+	locations          []types.Location
+}
+
+func NewClient(config config.BlackholeConfig) *Client {
+	return &Client{
+		httpClient:         &http.Client{Timeout: 30 * time.Second},
+		root:               config.Dir,
+		defaultDestination: config.Destination,
+		locations:          config.Locations,
+	}
+}
+
+func (c *Client) CreateDownloadTask(url string, opts types.DownloadOptions) error {
+	var (
+		name    string
+		content []byte
+	)
+
+	if strings.HasPrefix(url, "magnet:") {
+		name = taskName(url) + magnetExt
+		content = []byte(url + "\n")
+	} else {
+		data, err := c.fetchTorrent(url)
+		if err != nil {
+			return fmt.Errorf("fetch torrent: %w", err)
+		}
+		hash, err := utils.TorrentInfoHash(data)
+		if err != nil {
+			return fmt.Errorf("fetch torrent: %w", err)
+		}
+		name = hash + torrentExt
+		content = data
+	}
+
+	if err := c.writeFile(c.resolve(opts.Destination), name, content); err != nil {
+		return fmt.Errorf("write task file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) AddTorrentFile(data []byte, opts types.DownloadOptions) error {
+	hash, err := utils.TorrentInfoHash(data)
+	if err != nil {
+		return fmt.Errorf("add torrent file: %w", err)
+	}
+	if err := c.writeFile(c.resolve(opts.Destination), hash+torrentExt, data); err != nil {
+		return fmt.Errorf("write task file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetHashByMagnet(magnet string) (string, error) {
+	parsed, err := utils.ParseMagnet(magnet)
+	if err != nil {
+		return "", types.ErrTorrentNotFound
+	}
+
+	wanted := parsed.Hash()
+	if _, err := c.findPending(wanted); err != nil {
+		return "", err
+	}
+
+	return wanted, nil
+}
+
+func (c *Client) SetLocation(taskID, location string) error {
+	current, err := c.findPending(taskID)
+	if err != nil {
+		return fmt.Errorf("set location: %w", err)
+	}
+
+	dir := c.resolve(location)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("set location: %w", err)
+	}
+
+	if err := os.Rename(current, filepath.Join(dir, filepath.Base(current))); err != nil {
+		return fmt.Errorf("set location: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) SetCategory(_, _ string) error {
+	return fmt.Errorf("set category: %w", errors.ErrUnsupported)
+}
+
+func (c *Client) RemoveTorrent(taskID string, _ bool) error {
+	current, err := c.findPending(taskID)
+	if err != nil {
+		return fmt.Errorf("remove torrent: %w", err)
+	}
+
+	if err := os.Remove(current); err != nil {
+		return fmt.Errorf("remove torrent: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetTorrentStatus(taskID string) (types.TorrentStatus, error) {
+	if _, err := c.findPending(taskID); err != nil {
+		return types.TorrentStatus{}, err
+	}
+
+	return types.TorrentStatus{State: types.TorrentStateQueued}, nil
+}
+
+func (c *Client) GetLocations() []types.Location {
+	if len(c.locations) > 0 {
+		return slices.Clone(c.locations)
+	}
+
+	entries, err := os.ReadDir(c.root)
+	if err != nil {
+		slog.Error("failed to read blackhole directory", "dir", c.root, "error", err)
+		return nil
+	}
+
+	locations := make([]types.Location, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		locations = append(locations, types.Location{ID: entry.Name(), Name: entry.Name()})
+	}
+
+	return locations
+}
+
+func (c *Client) GetDefaultLocation() string {
+	return c.defaultDestination
+}
+
+func (c *Client) resolve(location string) string {
+	if location == "" {
+		location = c.defaultDestination
+	}
+	return filepath.Join(c.root, filepath.Clean("/"+location))
+}
+
+func (c *Client) findPending(taskID string) (string, error) {
+	var found string
+	err := filepath.WalkDir(c.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := d.Name()
+		if name == taskID+magnetExt || name == taskID+torrentExt {
+			found = path
+			return fs.SkipAll
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("scan blackhole directory: %w", err)
+	}
+
+	if found == "" {
+		return "", types.ErrTorrentNotFound
+	}
+
+	return found, nil
+}
+
+func (c *Client) writeFile(dir, name string, content []byte) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".pending-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			slog.Error("failed to remove temporary file", "file", tmp.Name(), "error", err)
+		}
+	}()
+
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath.Join(dir, name))
+}
+
+func (c *Client) fetchTorrent(url string) ([]byte, error) {
+	resp, err := c.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxTorrentFileSize))
+}
+
+func taskName(magnet string) string {
+	if parsed, err := utils.ParseMagnet(magnet); err == nil {
+		return parsed.Hash()
+	}
+	sum := sha1.Sum([]byte(magnet))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,208 @@
+package blackhole
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+const testMagnet = "magnet:?xt=urn:btih:2566E2B012EA1EF9087465BC97A7AC4449F4F0DE&dn=Some.Name"
+
+func newTestClient(t *testing.T) (*Client, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	return NewClient(config.BlackholeConfig{Dir: root, Destination: "tv shows"}), root
+}
+
+func TestCreateDownloadTask_Magnet(t *testing.T) {
+	client, root := newTestClient(t)
+
+	err := client.CreateDownloadTask(testMagnet, types.DownloadOptions{Destination: "movies"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(root, "movies", "2566e2b012ea1ef9087465bc97a7ac4449f4f0de.magnet"))
+	require.NoError(t, err)
+	assert.Equal(t, testMagnet+"\n", string(data))
+
+	entries, err := os.ReadDir(filepath.Join(root, "movies"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "no temporary files should be left behind")
+}
+
+func TestCreateDownloadTask_DefaultLocation(t *testing.T) {
+	client, root := newTestClient(t)
+
+	require.NoError(t, client.CreateDownloadTask(testMagnet, types.DownloadOptions{}))
+
+	_, err := os.Stat(filepath.Join(root, "tv shows", "2566e2b012ea1ef9087465bc97a7ac4449f4f0de.magnet"))
+	assert.NoError(t, err)
+}
+
+func TestCreateDownloadTask_LocationCannotEscapeRoot(t *testing.T) {
+	client, root := newTestClient(t)
+
+	require.NoError(t, client.CreateDownloadTask(testMagnet, types.DownloadOptions{Destination: "../../etc"}))
+
+	_, err := os.Stat(filepath.Join(root, "etc", "2566e2b012ea1ef9087465bc97a7ac4449f4f0de.magnet"))
+	assert.NoError(t, err)
+}
+
+func TestCreateDownloadTask_TorrentURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-bittorrent")
+		_, _ = w.Write([]byte("d4:infod4:name4:testee"))
+	}))
+	defer server.Close()
+
+	client, root := newTestClient(t)
+
+	require.NoError(t, client.CreateDownloadTask(server.URL+"/dl/file.torrent", types.DownloadOptions{Destination: "/downloads/movies"}))
+
+	matches, err := filepath.Glob(filepath.Join(root, "downloads", "movies", "*.torrent"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	data, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	assert.Equal(t, "d4:infod4:name4:testee", string(data))
+}
+
+func TestCreateDownloadTask_TorrentURLError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, _ := newTestClient(t)
+
+	err := client.CreateDownloadTask(server.URL+"/dl/missing.torrent", types.DownloadOptions{Destination: "movies"})
+	require.Error(t, err)
+}
+
+func TestAddTorrentFile(t *testing.T) {
+	client, root := newTestClient(t)
+
+	require.NoError(t, client.AddTorrentFile([]byte("d4:infod4:name4:testee"), types.DownloadOptions{Destination: "movies"}))
+
+	matches, err := filepath.Glob(filepath.Join(root, "movies", "*.torrent"))
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+
+	data, err := os.ReadFile(matches[0])
+	require.NoError(t, err)
+	assert.Equal(t, "d4:infod4:name4:testee", string(data))
+}
+
+func TestAddTorrentFile_FoundByInfoHash(t *testing.T) {
+	client, root := newTestClient(t)
+	torrent := []byte("d8:announce19:http://t.example/an4:infod4:name4:testee")
+	hash, err := utils.TorrentInfoHash(torrent)
+	require.NoError(t, err)
+
+	require.NoError(t, client.AddTorrentFile(torrent, types.DownloadOptions{Destination: "movies"}))
+	assert.FileExists(t, filepath.Join(root, "movies", hash+".torrent"))
+
+	taskID, err := client.GetHashByMagnet("magnet:?xt=urn:btih:" + hash)
+	require.NoError(t, err)
+	assert.Equal(t, hash, taskID)
+
+	status, err := client.GetTorrentStatus(taskID)
+	require.NoError(t, err)
+	assert.Equal(t, types.TorrentStateQueued, status.State)
+
+	require.NoError(t, client.SetLocation(taskID, "tv"))
+	require.NoError(t, client.RemoveTorrent(taskID, false))
+	assert.NoFileExists(t, filepath.Join(root, "tv", hash+".torrent"))
+}
+
+func TestAddTorrentFile_NotATorrent(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	require.Error(t, client.AddTorrentFile([]byte("<html></html>"), types.DownloadOptions{Destination: "movies"}))
+}
+
+func TestGetHashByMagnet(t *testing.T) {
+	client, _ := newTestClient(t)
+	require.NoError(t, client.CreateDownloadTask(testMagnet, types.DownloadOptions{Destination: "movies"}))
+
+	hash, err := client.GetHashByMagnet("magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de")
+	require.NoError(t, err)
+	assert.Equal(t, "2566e2b012ea1ef9087465bc97a7ac4449f4f0de", hash)
+
+	_, err = client.GetHashByMagnet("magnet:?xt=urn:btih:deadbeef")
+	assert.Error(t, err)
+
+	_, err = client.GetHashByMagnet("magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a")
+	assert.Error(t, err)
+}
+
+func TestSetLocation_MovesPendingFile(t *testing.T) {
+	client, root := newTestClient(t)
+	require.NoError(t, client.CreateDownloadTask(testMagnet, types.DownloadOptions{Destination: "movies"}))
+
+	hash, err := client.GetHashByMagnet(testMagnet)
+	require.NoError(t, err)
+
+	require.NoError(t, client.SetLocation(hash, "anime"))
+
+	_, err = os.Stat(filepath.Join(root, "movies", hash+".magnet"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = os.Stat(filepath.Join(root, "anime", hash+".magnet"))
+	assert.NoError(t, err)
+}
+
+func TestSetLocation_AlreadyPickedUp(t *testing.T) {
+	client, _ := newTestClient(t)
+
+	err := client.SetLocation("2566e2b012ea1ef9087465bc97a7ac4449f4f0de", "anime")
+	require.Error(t, err)
+}
+
+func TestRemoveTorrent_RemovesPendingFile(t *testing.T) {
+	client, root := newTestClient(t)
+	require.NoError(t, client.CreateDownloadTask(testMagnet, types.DownloadOptions{Destination: "movies"}))
+
+	status, err := client.GetTorrentStatus("2566e2b012ea1ef9087465bc97a7ac4449f4f0de")
+	require.NoError(t, err)
+	assert.Equal(t, types.TorrentStateQueued, status.State)
+
+	require.NoError(t, client.RemoveTorrent("2566e2b012ea1ef9087465bc97a7ac4449f4f0de", true))
+
+	_, err = os.Stat(filepath.Join(root, "movies", "2566e2b012ea1ef9087465bc97a7ac4449f4f0de.magnet"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = client.GetTorrentStatus("2566e2b012ea1ef9087465bc97a7ac4449f4f0de")
+	assert.Error(t, err)
+}
+
+func TestGetLocations(t *testing.T) {
+	client, root := newTestClient(t)
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "movies"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "tv shows"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, ".hidden"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "stray.magnet"), []byte("magnet:?"), 0o644))
+
+	assert.Equal(t, []types.Location{
+		{ID: "movies", Name: "movies"},
+		{ID: "tv shows", Name: "tv shows"},
+	}, client.GetLocations())
+}
+
+func TestGetLocations_Configured(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "unlisted"), 0o755))
+	locations := []types.Location{{ID: "movies", Name: "Movies", Command: "movies"}}
+
+	client := NewClient(config.BlackholeConfig{Dir: root, Locations: locations})
+
+	assert.Equal(t, locations, client.GetLocations())
+}
@@ -0,0 +1,335 @@
+package deluge
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+const notAuthenticatedCode = 1
+
+var errNotAuthenticated = errors.New("not authenticated")
+
+type Client struct {
+	httpClient         *http.Client
+	jsonURL            string
+	password           string
+	defaultDestination string
+	locations          []types.Location
+
+	mu     sync.Mutex
+	nextID int
+}
+
+func NewClient(config config.DelugeConfig) *Client {
+	jar, _ := cookiejar.New(nil)
+
+	return &Client{
+		httpClient:         &http.Client{Timeout: 30 * time.Second, Jar: jar},
+		jsonURL:            jsonURL(config.URL),
+		password:           config.Password,
+		defaultDestination: config.Destination,
+		locations:          config.Locations,
+	}
+}
+
+func jsonURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	if !strings.HasSuffix(u.Path, "/json") {
+		u.Path = strings.TrimRight(u.Path, "/") + "/json"
+	}
+	return u.String()
+}
+
+func (c *Client) CreateDownloadTask(url string, opts types.DownloadOptions) error {
+	options := map[string]any{}
+	if opts.Destination != "" {
+		options["download_location"] = opts.Destination
+	}
+
+	method := "core.add_torrent_url"
+	if strings.HasPrefix(url, "magnet:") {
+		method = "core.add_torrent_magnet"
+	}
+
+	if err := c.addTorrent(method, []any{url, options}); err != nil {
+		return fmt.Errorf("add torrent: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) AddTorrentFile(data []byte, opts types.DownloadOptions) error {
+	options := map[string]any{}
+	if opts.Destination != "" {
+		options["download_location"] = opts.Destination
+	}
+
+	sum := sha1.Sum(data)
+	filename := hex.EncodeToString(sum[:]) + ".torrent"
+	if err := c.addTorrent("core.add_torrent_file", []any{filename, base64.StdEncoding.EncodeToString(data), options}); err != nil {
+		return fmt.Errorf("add torrent file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) addTorrent(method string, params []any) error {
+	var torrentID *string
+	if err := c.call(method, params, &torrentID); err != nil {
+		return err
+	}
+
+	if torrentID == nil || *torrentID == "" {
+		return fmt.Errorf("torrent was not added")
+	}
+
+	return nil
+}
+
+func (c *Client) GetHashByMagnet(magnet string) (string, error) {
+	var torrents map[string]torrentStatus
+	if err := c.call("core.get_torrents_status", []any{map[string]any{}, []string{"hash", "name"}}, &torrents); err != nil {
+		return "", fmt.Errorf("get torrents: %w", err)
+	}
+
+	parsed, err := utils.ParseMagnet(magnet)
+	if err != nil {
+		return "", types.ErrTorrentNotFound
+	}
+	wanted := parsed.Hash()
+	for id, torrent := range torrents {
+		if strings.EqualFold(id, wanted) || strings.EqualFold(torrent.Hash, wanted) {
+			return id, nil
+		}
+	}
+
+	return "", types.ErrTorrentNotFound
+}
+
+func (c *Client) SetLocation(taskID, location string) error {
+	if err := c.call("core.move_storage", []any{[]string{taskID}, location}, nil); err != nil {
+		return fmt.Errorf("set location: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) SetCategory(_, _ string) error {
+	return fmt.Errorf("set category: %w", errors.ErrUnsupported)
+}
+
+func (c *Client) RemoveTorrent(taskID string, deleteFiles bool) error {
+	var removed bool
+	if err := c.call("core.remove_torrent", []any{taskID, deleteFiles}, &removed); err != nil {
+		return fmt.Errorf("remove torrent: %w", err)
+	}
+	if !removed {
+		return fmt.Errorf("remove torrent: torrent was not removed")
+	}
+
+	return nil
+}
+
+func (c *Client) GetTorrentStatus(taskID string) (types.TorrentStatus, error) {
+	var torrent torrentStatus
+	fields := []string{"hash", "state", "progress", "total_wanted", "eta", "completed_time"}
+	if err := c.call("core.get_torrent_status", []any{taskID, fields}, &torrent); err != nil {
+		return types.TorrentStatus{}, fmt.Errorf("get torrent status: %w", err)
+	}
+	if torrent.Hash == "" {
+		return types.TorrentStatus{}, types.ErrTorrentNotFound
+	}
+
+	status := types.TorrentStatus{
+		State:    toTorrentState(torrent),
+		Progress: torrent.Progress / 100,
+		Size:     torrent.TotalWanted,
+	}
+	if torrent.ETA > 0 {
+		status.ETA = int64(torrent.ETA)
+	}
+	if torrent.CompletedTime > 0 {
+		status.CompletedAt = time.Unix(int64(torrent.CompletedTime), 0)
+	}
+
+	return status, nil
+}
+
+func (c *Client) GetLocations() []types.Location {
+	return types.LocationsOrDefault(c.locations)
+}
+
+func (c *Client) GetDefaultLocation() string {
+	return c.defaultDestination
+}
+
+func (c *Client) call(method string, params []any, out any) error {
+	err := c.rawCall(method, params, out)
+	if !errors.Is(err, errNotAuthenticated) {
+		return err
+	}
+
+	if err := c.login(); err != nil {
+		return err
+	}
+
+	return c.rawCall(method, params, out)
+}
+
+func (c *Client) login() error {
+	var ok bool
+	if err := c.rawCall("auth.login", []any{c.password}, &ok); err != nil {
+		return fmt.Errorf("login: %w", err)
+	}
+	if !ok {
+		return fmt.Errorf("login: invalid password")
+	}
+
+	var connected bool
+	if err := c.rawCall("web.connected", []any{}, &connected); err != nil {
+		return fmt.Errorf("check daemon connection: %w", err)
+	}
+	if connected {
+		return nil
+	}
+
+	var hosts [][]any
+	if err := c.rawCall("web.get_hosts", []any{}, &hosts); err != nil {
+		return fmt.Errorf("get daemon hosts: %w", err)
+	}
+	if len(hosts) == 0 || len(hosts[0]) == 0 {
+		return fmt.Errorf("no deluge daemon configured in web ui")
+	}
+
+	hostID, _ := hosts[0][0].(string)
+	if err := c.rawCall("web.connect", []any{hostID}, nil); err != nil {
+		return fmt.Errorf("connect to daemon: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) rawCall(method string, params []any, out any) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	c.mu.Unlock()
+
+	payload, err := json.Marshal(rpcRequest{Method: method, Params: params, ID: id})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, c.jsonURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	if rpcResp.Error != nil {
+		if rpcResp.Error.Code == notAuthenticatedCode {
+			return errNotAuthenticated
+		}
+		return fmt.Errorf("rpc error: %s", rpcResp.Error.Message)
+	}
+
+	if out == nil || len(rpcResp.Result) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+		return fmt.Errorf("decode result: %w", err)
+	}
+
+	return nil
+}
+
+type rpcRequest struct {
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+	ID     int    `json:"id"`
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+	ID     int             `json:"id"`
+}
+
+type rpcError struct {
+	Message string `json:"message"`
+	Code    int    `json:"code"`
+}
+
+type torrentStatus struct {
+	Hash          string  `json:"hash"`
+	Name          string  `json:"name"`
+	State         string  `json:"state"`
+	Progress      float64 `json:"progress"`
+	TotalWanted   int64   `json:"total_wanted"`
+	ETA           float64 `json:"eta"`
+	CompletedTime float64 `json:"completed_time"`
+}
+
+func toTorrentState(torrent torrentStatus) types.TorrentState {
+	switch torrent.State {
+	case "Queued", "Allocating":
+		return types.TorrentStateQueued
+	case "Checking":
+		return types.TorrentStateChecking
+	case "Downloading":
+		return types.TorrentStateDownloading
+	case "Seeding":
+		return types.TorrentStateCompleted
+	case "Paused":
+		if torrent.Progress >= 100 {
+			return types.TorrentStateCompleted
+		}
+		return types.TorrentStatePaused
+	case "Error":
+		return types.TorrentStateError
+	default:
+		return types.TorrentStateUnknown
+	}
+}
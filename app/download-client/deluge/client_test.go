@@ -0,0 +1,382 @@
+package deluge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/types"
+)
+
+type rpcCall struct {
+	Method string `json:"method"`
+	Params []any  `json:"params"`
+}
+
+type fakeDeluge struct {
+	server    *httptest.Server
+	password  string
+	connected bool
+	torrents  map[string]map[string]any
+
+	calls        []rpcCall
+	addResult    any
+	removeResult bool
+	rpcErrors    map[string]string
+}
+
+func newFakeDeluge(t *testing.T) *fakeDeluge {
+	t.Helper()
+
+	f := &fakeDeluge{
+		password:     "deluge",
+		connected:    true,
+		addResult:    "abc123",
+		removeResult: true,
+		rpcErrors:    map[string]string{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		var call rpcCall
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&call))
+		f.calls = append(f.calls, call)
+
+		w.Header().Set("Content-Type", "application/json")
+		writeResult := func(result any) {
+			_ = json.NewEncoder(w).Encode(map[string]any{"result": result, "error": nil, "id": 1})
+		}
+		writeError := func(code int, message string) {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"result": nil,
+				"error":  map[string]any{"message": message, "code": code},
+				"id":     1,
+			})
+		}
+
+		if call.Method == "auth.login" {
+			if len(call.Params) == 1 && call.Params[0] == f.password {
+				http.SetCookie(w, &http.Cookie{Name: "_session_id", Value: "session", Path: "/"})
+				writeResult(true)
+				return
+			}
+			writeResult(false)
+			return
+		}
+
+		if _, err := r.Cookie("_session_id"); err != nil {
+			writeError(notAuthenticatedCode, "Not authenticated")
+			return
+		}
+
+		if message, ok := f.rpcErrors[call.Method]; ok {
+			writeError(2, message)
+			return
+		}
+
+		switch call.Method {
+		case "web.connected":
+			writeResult(f.connected)
+		case "web.get_hosts":
+			writeResult([][]any{{"host-1", "127.0.0.1", 58846, "Online"}})
+		case "web.connect":
+			f.connected = true
+			writeResult(nil)
+		case "core.add_torrent_magnet", "core.add_torrent_url", "core.add_torrent_file":
+			writeResult(f.addResult)
+		case "core.get_torrents_status":
+			writeResult(f.torrents)
+		case "core.get_torrent_status":
+			id, _ := call.Params[0].(string)
+			torrent, ok := f.torrents[id]
+			if !ok {
+				torrent = map[string]any{}
+			}
+			writeResult(torrent)
+		case "core.move_storage":
+			writeResult(nil)
+		case "core.remove_torrent":
+			writeResult(f.removeResult)
+		default:
+			writeError(2, "unknown method")
+		}
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeDeluge) client() *Client {
+	return NewClient(config.DelugeConfig{
+		URL:         f.server.URL,
+		Password:    "deluge",
+		Destination: "/downloads/default",
+	})
+}
+
+func (f *fakeDeluge) methods() []string {
+	methods := make([]string, len(f.calls))
+	for i, call := range f.calls {
+		methods[i] = call.Method
+	}
+	return methods
+}
+
+func (f *fakeDeluge) lastCall() rpcCall {
+	return f.calls[len(f.calls)-1]
+}
+
+func TestCreateDownloadTask(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		wantMethod string
+	}{
+		{name: "magnet uses add_torrent_magnet", source: "magnet:?xt=urn:btih:abc", wantMethod: "core.add_torrent_magnet"},
+		{name: "http url uses add_torrent_url", source: "https://jackett.example/dl/file.torrent", wantMethod: "core.add_torrent_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeDeluge(t)
+
+			err := fake.client().CreateDownloadTask(tt.source, types.DownloadOptions{Destination: "/downloads/movies"})
+
+			require.NoError(t, err)
+			call := fake.lastCall()
+			assert.Equal(t, tt.wantMethod, call.Method)
+			assert.Equal(t, tt.source, call.Params[0])
+			assert.Equal(t, map[string]any{"download_location": "/downloads/movies"}, call.Params[1])
+		})
+	}
+}
+
+func TestCreateDownloadTask_NotAdded(t *testing.T) {
+	fake := newFakeDeluge(t)
+	fake.addResult = nil
+
+	err := fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads/movies"})
+
+	require.Error(t, err)
+}
+
+func TestCall_LogsInOnceAndReusesSession(t *testing.T) {
+	fake := newFakeDeluge(t)
+	client := fake.client()
+
+	require.NoError(t, client.CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads"}))
+	require.NoError(t, client.CreateDownloadTask("magnet:?xt=urn:btih:def", types.DownloadOptions{Destination: "/downloads"}))
+
+	assert.Equal(t, []string{
+		"core.add_torrent_magnet",
+		"auth.login",
+		"web.connected",
+		"core.add_torrent_magnet",
+		"core.add_torrent_magnet",
+	}, fake.methods())
+}
+
+func TestCall_ConnectsToDaemonWhenDisconnected(t *testing.T) {
+	fake := newFakeDeluge(t)
+	fake.connected = false
+
+	require.NoError(t, fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads"}))
+
+	assert.Equal(t, []string{
+		"core.add_torrent_magnet",
+		"auth.login",
+		"web.connected",
+		"web.get_hosts",
+		"web.connect",
+		"core.add_torrent_magnet",
+	}, fake.methods())
+	assert.Equal(t, []any{"host-1"}, fake.calls[4].Params)
+}
+
+func TestCall_InvalidPassword(t *testing.T) {
+	fake := newFakeDeluge(t)
+	fake.password = "other"
+
+	err := fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid password")
+}
+
+func TestGetHashByMagnet(t *testing.T) {
+	tests := []struct {
+		name     string
+		torrents map[string]map[string]any
+		magnet   string
+		wantHash string
+		wantErr  bool
+	}{
+		{
+			name: "matches torrent id ignoring case",
+			torrents: map[string]map[string]any{
+				"2566e2b012ea1ef9087465bc97a7ac4449f4f0de": {"hash": "2566e2b012ea1ef9087465bc97a7ac4449f4f0de", "name": "Some.Name"},
+			},
+			magnet:   "magnet:?xt=urn:btih:2566E2B012EA1EF9087465BC97A7AC4449F4F0DE&dn=Some.Name",
+			wantHash: "2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+		},
+		{
+			name: "not found when no torrent matches",
+			torrents: map[string]map[string]any{
+				"deadbeef": {"hash": "deadbeef"},
+			},
+			magnet:  "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+			wantErr: true,
+		},
+		{
+			name:     "no false match when queried magnet has no btih hash",
+			torrents: map[string]map[string]any{},
+			magnet:   "magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeDeluge(t)
+			fake.torrents = tt.torrents
+
+			hash, err := fake.client().GetHashByMagnet(tt.magnet)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHash, hash)
+		})
+	}
+}
+
+func TestSetLocation(t *testing.T) {
+	fake := newFakeDeluge(t)
+
+	err := fake.client().SetLocation("HASH1", "/downloads/tv shows")
+
+	require.NoError(t, err)
+	call := fake.lastCall()
+	assert.Equal(t, "core.move_storage", call.Method)
+	assert.Equal(t, []any{[]any{"HASH1"}, "/downloads/tv shows"}, call.Params)
+}
+
+func TestSetLocation_RPCError(t *testing.T) {
+	fake := newFakeDeluge(t)
+	fake.rpcErrors["core.move_storage"] = "torrent not found"
+
+	err := fake.client().SetLocation("HASH1", "/downloads/tv shows")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "torrent not found")
+}
+
+func TestRemoveTorrent(t *testing.T) {
+	fake := newFakeDeluge(t)
+
+	err := fake.client().RemoveTorrent("HASH1", true)
+
+	require.NoError(t, err)
+	call := fake.lastCall()
+	assert.Equal(t, "core.remove_torrent", call.Method)
+	assert.Equal(t, []any{"HASH1", true}, call.Params)
+}
+
+func TestRemoveTorrent_NotRemoved(t *testing.T) {
+	fake := newFakeDeluge(t)
+	fake.removeResult = false
+
+	err := fake.client().RemoveTorrent("HASH1", false)
+
+	require.Error(t, err)
+}
+
+func TestGetTorrentStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		progress  float64
+		wantState types.TorrentState
+	}{
+		{name: "checking", state: "Checking", progress: 40, wantState: types.TorrentStateChecking},
+		{name: "downloading", state: "Downloading", progress: 40, wantState: types.TorrentStateDownloading},
+		{name: "paused before completion", state: "Paused", progress: 40, wantState: types.TorrentStatePaused},
+		{name: "paused after completion", state: "Paused", progress: 100, wantState: types.TorrentStateCompleted},
+		{name: "seeding", state: "Seeding", progress: 100, wantState: types.TorrentStateCompleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeDeluge(t)
+			fake.torrents = map[string]map[string]any{
+				"hash1": {"hash": "hash1", "state": tt.state, "progress": tt.progress},
+			}
+
+			status, err := fake.client().GetTorrentStatus("hash1")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, status.State)
+		})
+	}
+}
+
+func TestAddTorrentFile(t *testing.T) {
+	fake := newFakeDeluge(t)
+	data := []byte("d4:infod4:name4:testee")
+
+	err := fake.client().AddTorrentFile(data, types.DownloadOptions{Destination: "/downloads/movies"})
+
+	require.NoError(t, err)
+	call := fake.lastCall()
+	assert.Equal(t, "core.add_torrent_file", call.Method)
+	assert.True(t, strings.HasSuffix(call.Params[0].(string), ".torrent"))
+	assert.Equal(t, base64.StdEncoding.EncodeToString(data), call.Params[1])
+	assert.Equal(t, map[string]any{"download_location": "/downloads/movies"}, call.Params[2])
+}
+
+func TestGetTorrentStatus_Details(t *testing.T) {
+	fake := newFakeDeluge(t)
+	fake.torrents = map[string]map[string]any{
+		"hash1": {"hash": "hash1", "state": "Downloading", "progress": 50.0, "total_wanted": 4096, "eta": 90, "completed_time": 0},
+	}
+
+	status, err := fake.client().GetTorrentStatus("hash1")
+
+	require.NoError(t, err)
+	assert.Equal(t, types.TorrentStatus{State: types.TorrentStateDownloading, Progress: 0.5, Size: 4096, ETA: 90}, status)
+}
+
+func TestGetTorrentStatus_NotFound(t *testing.T) {
+	fake := newFakeDeluge(t)
+
+	_, err := fake.client().GetTorrentStatus("hash1")
+
+	require.Error(t, err)
+}
+
+func TestJSONURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "bare host gets json path", url: "http://nas:8112", want: "http://nas:8112/json"},
+		{name: "subpath deployment gets json path", url: "https://example.com/deluge/", want: "https://example.com/deluge/json"},
+		{name: "explicit json path is kept", url: "http://nas:8112/json", want: "http://nas:8112/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, jsonURL(tt.url))
+		})
+	}
+}
@@ -1,7 +1,10 @@
 package qbittorrent
 
 import (
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	qbt "github.com/autobrr/go-qbittorrent"
 	"magnet-feed-sync/app/config"
@@ -9,12 +12,21 @@ import (
 	"magnet-feed-sync/app/utils"
 )
 
+const infiniteETA = 8640000
+
 type Client struct {
 	qbt                *qbt.Client
 	defaultDestination string
+	categories         map[string]string
+	locations          []types.Location
 }
 
 func NewClient(config config.QBittorrentConfig) *Client {
+	categories := make(map[string]string, len(config.Categories))
+	for location, category := range config.Categories {
+		categories[strings.TrimRight(location, "/")] = category
+	}
+
 	return &Client{
 		qbt: qbt.NewClient(qbt.Config{
 			Host:     config.URL,
@@ -22,34 +34,61 @@ func NewClient(config config.QBittorrentConfig) *Client {
 			Password: config.Password,
 		}),
 		defaultDestination: config.Destination,
+		categories:         categories,
+		locations:          config.Locations,
 	}
 }
 
-func (c *Client) CreateDownloadTask(url, destination string) error {
-	if _, err := c.qbt.AddTorrentFromUrl(url, map[string]string{"savepath": destination}); err != nil {
+func (c *Client) CreateDownloadTask(url string, opts types.DownloadOptions) error {
+	if _, err := c.qbt.AddTorrentFromUrl(url, addOptions(opts)); err != nil {
 		return fmt.Errorf("add torrent: %w", err)
 	}
 
 	return nil
 }
 
+func (c *Client) AddTorrentFile(data []byte, opts types.DownloadOptions) error {
+	if _, err := c.qbt.AddTorrentFromMemory(data, addOptions(opts)); err != nil {
+		return fmt.Errorf("add torrent file: %w", err)
+	}
+
+	return nil
+}
+
+func addOptions(opts types.DownloadOptions) map[string]string {
+	options := map[string]string{"savepath": opts.Destination}
+	if opts.Category != "" {
+		options["category"] = opts.Category
+	}
+	if len(opts.Tags) > 0 {
+		options["tags"] = strings.Join(opts.Tags, ",")
+	}
+	return options
+}
+
 func (c *Client) GetHashByMagnet(magnet string) (string, error) {
 	torrents, err := c.qbt.GetTorrents(qbt.TorrentFilterOptions{})
 	if err != nil {
 		return "", fmt.Errorf("get torrents: %w", err)
 	}
 
-	wanted := utils.ExtractBtihHash(magnet)
-	if wanted == "" {
-		return "", fmt.Errorf("torrent not found")
+	wanted, err := utils.ParseMagnet(magnet)
+	if err != nil {
+		return "", types.ErrTorrentNotFound
 	}
 	for _, torrent := range torrents {
-		if utils.ExtractBtihHash(torrent.MagnetURI) == wanted {
+		if torrent.Hash == "" {
+			continue
+		}
+		if strings.EqualFold(torrent.Hash, wanted.Hash()) {
+			return torrent.Hash, nil
+		}
+		if current, err := utils.ParseMagnet(torrent.MagnetURI); err == nil && current.SameTorrent(wanted) {
 			return torrent.Hash, nil
 		}
 	}
 
-	return "", fmt.Errorf("torrent not found")
+	return "", types.ErrTorrentNotFound
 }
 
 func (c *Client) SetLocation(taskID, location string) error {
@@ -60,21 +99,88 @@ func (c *Client) SetLocation(taskID, location string) error {
 	return nil
 }
 
+func (c *Client) RemoveTorrent(taskID string, deleteFiles bool) error {
+	if err := c.qbt.DeleteTorrents([]string{taskID}, deleteFiles); err != nil {
+		return fmt.Errorf("delete torrent: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetTorrentStatus(taskID string) (types.TorrentStatus, error) {
+	torrents, err := c.qbt.GetTorrents(qbt.TorrentFilterOptions{Hashes: []string{taskID}})
+	if err != nil {
+		return types.TorrentStatus{}, fmt.Errorf("get torrents: %w", err)
+	}
+	if len(torrents) == 0 {
+		return types.TorrentStatus{}, types.ErrTorrentNotFound
+	}
+
+	torrent := torrents[0]
+	status := types.TorrentStatus{
+		State:    toTorrentState(torrent.State),
+		Progress: torrent.Progress,
+		Size:     torrent.Size,
+	}
+	if torrent.ETA > 0 && torrent.ETA < infiniteETA {
+		status.ETA = torrent.ETA
+	}
+	if torrent.CompletionOn > 0 {
+		status.CompletedAt = time.Unix(torrent.CompletionOn, 0)
+	}
+
+	return status, nil
+}
+
+func (c *Client) SetCategory(taskID, category string) error {
+	err := c.qbt.SetCategory([]string{taskID}, category)
+	if errors.Is(err, qbt.ErrCategoryDoesNotExist) {
+		if err := c.qbt.CreateCategory(category, ""); err != nil {
+			return fmt.Errorf("create category: %w", err)
+		}
+		err = c.qbt.SetCategory([]string{taskID}, category)
+	}
+	if err != nil {
+		return fmt.Errorf("set category: %w", err)
+	}
+
+	return nil
+}
+
 func (c *Client) GetLocations() []types.Location {
-	return []types.Location{
-		{ID: "/downloads/tv shows", Name: "TV Shows"},
-		{ID: "/downloads/other", Name: "Other"},
-		{ID: "/downloads/movies", Name: "Movies"},
-		{ID: "/downloads/me", Name: "Me"},
-		{ID: "/downloads/books", Name: "Books"},
-		{ID: "/downloads/audiobooks", Name: "Audiobooks"},
-		{ID: "/downloads/music", Name: "Music"},
-		{ID: "/downloads/comics", Name: "Comics"},
-		{ID: "/downloads/podcasts", Name: "Podcasts"},
-		{ID: "/downloads/anime", Name: "Anime"},
+	locations := types.LocationsOrDefault(c.locations)
+	for i, location := range locations {
+		if location.Category == "" {
+			locations[i].Category = c.categories[location.ID]
+		}
 	}
+	return locations
 }
 
 func (c *Client) GetDefaultLocation() string {
 	return c.defaultDestination
 }
+
+func toTorrentState(state qbt.TorrentState) types.TorrentState {
+	switch state {
+	case qbt.TorrentStateQueuedDl, qbt.TorrentStateAllocating:
+		return types.TorrentStateQueued
+	case qbt.TorrentStateMetaDl:
+		return types.TorrentStateMetadata
+	case qbt.TorrentStateCheckingDl, qbt.TorrentStateCheckingUp, qbt.TorrentStateCheckingResumeData:
+		return types.TorrentStateChecking
+	case qbt.TorrentStateDownloading, qbt.TorrentStateForcedDl:
+		return types.TorrentStateDownloading
+	case qbt.TorrentStateStalledDl:
+		return types.TorrentStateStalled
+	case qbt.TorrentStatePausedDl, qbt.TorrentStateStoppedDl:
+		return types.TorrentStatePaused
+	case qbt.TorrentStateUploading, qbt.TorrentStateForcedUp, qbt.TorrentStateStalledUp,
+		qbt.TorrentStateQueuedUp, qbt.TorrentStatePausedUp, qbt.TorrentStateStoppedUp:
+		return types.TorrentStateCompleted
+	case qbt.TorrentStateError, qbt.TorrentStateMissingFiles:
+		return types.TorrentStateError
+	default:
+		return types.TorrentStateUnknown
+	}
+}
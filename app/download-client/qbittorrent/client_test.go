@@ -2,25 +2,39 @@ package qbittorrent
 
 import (
 	"encoding/json"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/types"
 )
 
 type fakeQbit struct {
 	server   *httptest.Server
-	torrents []map[string]string
+	torrents []map[string]any
 
 	addSavePath string
 	addURL      string
+	addCategory string
+	addTags     string
+	addFile     []byte
+
+	categories        map[string]bool
+	setCategoryHashes string
+	setCategory       string
 
 	setLocationHashes   string
 	setLocationLocation string
 
+	deleteHashes string
+	deleteFiles  string
+
 	addStatus         int
 	setLocationStatus int
 	torrentsStatus    int
@@ -29,7 +43,12 @@ type fakeQbit struct {
 func newFakeQbit(t *testing.T) *fakeQbit {
 	t.Helper()
 
-	f := &fakeQbit{addStatus: http.StatusOK, setLocationStatus: http.StatusOK, torrentsStatus: http.StatusOK}
+	f := &fakeQbit{
+		addStatus:         http.StatusOK,
+		setLocationStatus: http.StatusOK,
+		torrentsStatus:    http.StatusOK,
+		categories:        map[string]bool{},
+	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/api/v2/auth/login", func(w http.ResponseWriter, r *http.Request) {
@@ -37,9 +56,19 @@ func newFakeQbit(t *testing.T) *fakeQbit {
 		w.WriteHeader(http.StatusNoContent)
 	})
 	mux.HandleFunc("/api/v2/torrents/add", func(w http.ResponseWriter, r *http.Request) {
-		require.NoError(t, r.ParseForm())
+		if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			file, _, err := r.FormFile("torrents")
+			require.NoError(t, err)
+			f.addFile, err = io.ReadAll(file)
+			require.NoError(t, err)
+		} else {
+			require.NoError(t, r.ParseForm())
+		}
 		f.addSavePath = r.FormValue("savepath")
 		f.addURL = r.FormValue("urls")
+		f.addCategory = r.FormValue("category")
+		f.addTags = r.FormValue("tags")
 
 		if f.addStatus != http.StatusOK {
 			w.WriteHeader(f.addStatus)
@@ -58,8 +87,17 @@ func newFakeQbit(t *testing.T) *fakeQbit {
 			w.WriteHeader(f.torrentsStatus)
 			return
 		}
+		torrents := f.torrents
+		if hashes := r.URL.Query().Get("hashes"); hashes != "" {
+			torrents = nil
+			for _, torrent := range f.torrents {
+				if torrent["hash"] == hashes {
+					torrents = append(torrents, torrent)
+				}
+			}
+		}
 		w.Header().Set("Content-Type", "application/json")
-		_ = json.NewEncoder(w).Encode(f.torrents)
+		_ = json.NewEncoder(w).Encode(torrents)
 	})
 	mux.HandleFunc("/api/v2/torrents/setLocation", func(w http.ResponseWriter, r *http.Request) {
 		require.NoError(t, r.ParseForm())
@@ -68,6 +106,28 @@ func newFakeQbit(t *testing.T) *fakeQbit {
 		w.WriteHeader(f.setLocationStatus)
 	})
 
+	mux.HandleFunc("/api/v2/torrents/createCategory", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		f.categories[r.FormValue("category")] = true
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/v2/torrents/setCategory", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		if category := r.FormValue("category"); category != "" && !f.categories[category] {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.setCategoryHashes = r.FormValue("hashes")
+		f.setCategory = r.FormValue("category")
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/v2/torrents/delete", func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		f.deleteHashes = r.FormValue("hashes")
+		f.deleteFiles = r.FormValue("deleteFiles")
+		w.WriteHeader(http.StatusOK)
+	})
+
 	f.server = httptest.NewServer(mux)
 	t.Cleanup(f.server.Close)
 	return f
@@ -79,6 +139,7 @@ func (f *fakeQbit) client() *Client {
 		Username:    "admin",
 		Password:    "adminpass",
 		Destination: "/downloads/default",
+		Categories:  map[string]string{"/downloads/tv shows/": "tv"},
 	})
 }
 
@@ -97,7 +158,7 @@ func TestCreateDownloadTask(t *testing.T) {
 			fake := newFakeQbit(t)
 			fake.addStatus = tt.status
 
-			err := fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", "/downloads/movies")
+			err := fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads/movies"})
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -110,17 +171,87 @@ func TestCreateDownloadTask(t *testing.T) {
 	}
 }
 
+func TestCreateDownloadTask_CategoryAndTags(t *testing.T) {
+	fake := newFakeQbit(t)
+
+	err := fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{
+		Destination: "/downloads/tv shows",
+		Category:    "tv",
+		Tags:        []string{"magnet-feed-sync", "rutracker.org", "6810475"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, "tv", fake.addCategory)
+	assert.Equal(t, "magnet-feed-sync,rutracker.org,6810475", fake.addTags)
+}
+
+func TestAddTorrentFile(t *testing.T) {
+	fake := newFakeQbit(t)
+	data := []byte("d4:infod4:name4:testee")
+
+	err := fake.client().AddTorrentFile(data, types.DownloadOptions{Destination: "/downloads/movies", Category: "movies"})
+
+	require.NoError(t, err)
+	assert.Equal(t, data, fake.addFile)
+	assert.Equal(t, "/downloads/movies", fake.addSavePath)
+	assert.Equal(t, "movies", fake.addCategory)
+	assert.Empty(t, fake.addURL)
+}
+
+func TestSetCategory_CreatesMissingCategory(t *testing.T) {
+	fake := newFakeQbit(t)
+
+	err := fake.client().SetCategory("HASH1", "tv")
+
+	require.NoError(t, err)
+	assert.True(t, fake.categories["tv"], "missing category should be created")
+	assert.Equal(t, "HASH1", fake.setCategoryHashes)
+	assert.Equal(t, "tv", fake.setCategory)
+}
+
+func TestGetLocations_IncludesConfiguredCategories(t *testing.T) {
+	fake := newFakeQbit(t)
+
+	locations := fake.client().GetLocations()
+
+	require.NotEmpty(t, locations)
+	for _, location := range locations {
+		if location.ID == "/downloads/tv shows" {
+			assert.Equal(t, "tv", location.Category)
+			continue
+		}
+		assert.Empty(t, location.Category, location.ID)
+	}
+}
+
+func TestGetLocations_Configured(t *testing.T) {
+	fake := newFakeQbit(t)
+	client := NewClient(config.QBittorrentConfig{
+		URL:        fake.server.URL,
+		Categories: map[string]string{"/data/series": "tv", "/data/films": "ignored"},
+		Locations: []types.Location{
+			{ID: "/data/series", Name: "Series", Command: "series"},
+			{ID: "/data/films", Name: "Films", Command: "films", Category: "movies"},
+		},
+	})
+
+	assert.Equal(t, []types.Location{
+		{ID: "/data/series", Name: "Series", Command: "series", Category: "tv"},
+		{ID: "/data/films", Name: "Films", Command: "films", Category: "movies"},
+	}, client.GetLocations())
+}
+
 func TestGetHashByMagnet(t *testing.T) {
 	tests := []struct {
 		name     string
-		torrents []map[string]string
+		torrents []map[string]any
 		magnet   string
 		wantHash string
 		wantErr  bool
 	}{
 		{
 			name: "matches by btih hash ignoring dn and case",
-			torrents: []map[string]string{
+			torrents: []map[string]any{
 				{"hash": "HASH1", "magnet_uri": "magnet:?xt=urn:btih:2566E2B012EA1EF9087465BC97A7AC4449F4F0DE&dn=Some.Name"},
 			},
 			magnet:   "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
@@ -128,7 +259,7 @@ func TestGetHashByMagnet(t *testing.T) {
 		},
 		{
 			name: "not found when no torrent matches",
-			torrents: []map[string]string{
+			torrents: []map[string]any{
 				{"hash": "HASH1", "magnet_uri": "magnet:?xt=urn:btih:deadbeef"},
 			},
 			magnet:  "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
@@ -136,12 +267,28 @@ func TestGetHashByMagnet(t *testing.T) {
 		},
 		{
 			name: "no false match when queried magnet has no btih hash",
-			torrents: []map[string]string{
+			torrents: []map[string]any{
 				{"hash": "HASH1", "magnet_uri": "magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a"},
 			},
 			magnet:  "magnet:?xt=urn:btmh:1220ffffffffffffffffffffffffffffffffffff",
 			wantErr: true,
 		},
+		{
+			name: "matches base32 hash",
+			torrents: []map[string]any{
+				{"hash": "2566e2b012ea1ef9087465bc97a7ac4449f4f0de", "magnet_uri": ""},
+			},
+			magnet:   "magnet:?xt=urn:btih:EVTOFMAS5IPPSCDUMW6JPJ5MIRE7J4G6",
+			wantHash: "2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+		},
+		{
+			name: "matches hybrid torrent by v2 hash",
+			torrents: []map[string]any{
+				{"hash": "HASH1", "magnet_uri": "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de&xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a"},
+			},
+			magnet:   "magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a",
+			wantHash: "HASH1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -197,3 +344,84 @@ func TestSetLocation(t *testing.T) {
 		})
 	}
 }
+
+func TestRemoveTorrent(t *testing.T) {
+	tests := []struct {
+		name        string
+		deleteFiles bool
+		want        string
+	}{
+		{name: "keeps files", deleteFiles: false, want: "false"},
+		{name: "deletes files", deleteFiles: true, want: "true"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQbit(t)
+
+			err := fake.client().RemoveTorrent("HASH1", tt.deleteFiles)
+
+			require.NoError(t, err)
+			assert.Equal(t, "HASH1", fake.deleteHashes)
+			assert.Equal(t, tt.want, fake.deleteFiles)
+		})
+	}
+}
+
+func TestGetTorrentStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		state     string
+		wantState types.TorrentState
+	}{
+		{name: "fetching metadata", state: "metaDL", wantState: types.TorrentStateMetadata},
+		{name: "checking", state: "checkingDL", wantState: types.TorrentStateChecking},
+		{name: "downloading", state: "downloading", wantState: types.TorrentStateDownloading},
+		{name: "seeding", state: "stalledUP", wantState: types.TorrentStateCompleted},
+		{name: "missing files", state: "missingFiles", wantState: types.TorrentStateError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeQbit(t)
+			fake.torrents = []map[string]any{
+				{"hash": "OTHER", "state": "downloading"},
+				{"hash": "HASH1", "state": tt.state},
+			}
+
+			status, err := fake.client().GetTorrentStatus("HASH1")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, status.State)
+		})
+	}
+}
+
+func TestGetTorrentStatus_Details(t *testing.T) {
+	fake := newFakeQbit(t)
+	fake.torrents = []map[string]any{
+		{"hash": "HASH1", "state": "downloading", "progress": 0.25, "size": 4096, "eta": 120, "completion_on": -1},
+		{"hash": "HASH2", "state": "uploading", "progress": 1, "size": 2048, "eta": 8640000, "completion_on": 1760000000},
+	}
+
+	status, err := fake.client().GetTorrentStatus("HASH1")
+	require.NoError(t, err)
+	assert.Equal(t, types.TorrentStatus{State: types.TorrentStateDownloading, Progress: 0.25, Size: 4096, ETA: 120}, status)
+
+	status, err = fake.client().GetTorrentStatus("HASH2")
+	require.NoError(t, err)
+	assert.Equal(t, types.TorrentStatus{
+		State:       types.TorrentStateCompleted,
+		Progress:    1,
+		Size:        2048,
+		CompletedAt: time.Unix(1760000000, 0),
+	}, status)
+}
+
+func TestGetTorrentStatus_NotFound(t *testing.T) {
+	fake := newFakeQbit(t)
+
+	_, err := fake.client().GetTorrentStatus("HASH1")
+
+	require.Error(t, err)
+}
@@ -0,0 +1,251 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"magnet-feed-sync/app/types"
+)
+
+const taskIDSeparator = ":"
+
+type Backend interface {
+	CreateDownloadTask(url string, opts types.DownloadOptions) error
+	AddTorrentFile(data []byte, opts types.DownloadOptions) error
+	GetHashByMagnet(magnet string) (string, error)
+	SetLocation(taskID, location string) error
+	SetCategory(taskID, category string) error
+	RemoveTorrent(taskID string, deleteFiles bool) error
+	GetTorrentStatus(taskID string) (types.TorrentStatus, error)
+	GetLocations() []types.Location
+	GetDefaultLocation() string
+}
+
+type Instance struct {
+	Name   string
+	Client Backend
+}
+
+type Client struct {
+	instances       map[string]Backend
+	order           []string
+	routes          map[string]string
+	defaultInstance string
+}
+
+func NewClient(instances []Instance, routes map[string]string, defaultInstance string) (*Client, error) {
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no download client instances configured")
+	}
+
+	c := &Client{
+		instances: make(map[string]Backend, len(instances)),
+		routes:    make(map[string]string, len(routes)),
+	}
+
+	for _, instance := range instances {
+		if strings.Contains(instance.Name, taskIDSeparator) {
+			return nil, fmt.Errorf("instance name %q must not contain %q", instance.Name, taskIDSeparator)
+		}
+		if _, exists := c.instances[instance.Name]; exists {
+			return nil, fmt.Errorf("duplicate instance %q", instance.Name)
+		}
+		c.instances[instance.Name] = instance.Client
+		c.order = append(c.order, instance.Name)
+	}
+
+	c.defaultInstance = defaultInstance
+	if c.defaultInstance == "" {
+		c.defaultInstance = c.order[0]
+	}
+	if _, ok := c.instances[c.defaultInstance]; !ok {
+		return nil, fmt.Errorf("unknown default instance %q", c.defaultInstance)
+	}
+
+	for location, name := range routes {
+		if _, ok := c.instances[name]; !ok {
+			return nil, fmt.Errorf("location %q routed to unknown instance %q", location, name)
+		}
+		c.routes[normalizeLocation(location)] = name
+	}
+
+	return c, nil
+}
+
+func (c *Client) CreateDownloadTask(url string, opts types.DownloadOptions) error {
+	name, err := c.instanceForOptions(opts)
+	if err != nil {
+		return err
+	}
+	if err := c.instances[name].CreateDownloadTask(url, opts); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (c *Client) AddTorrentFile(data []byte, opts types.DownloadOptions) error {
+	name, err := c.instanceForOptions(opts)
+	if err != nil {
+		return err
+	}
+	if err := c.instances[name].AddTorrentFile(data, opts); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetHashByMagnet(magnet string) (string, error) {
+	var errs []error
+	for _, name := range c.order {
+		hash, err := c.instances[name].GetHashByMagnet(magnet)
+		if err == nil {
+			return name + taskIDSeparator + hash, nil
+		}
+		if !errors.Is(err, types.ErrTorrentNotFound) {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if len(errs) == 0 {
+		return "", types.ErrTorrentNotFound
+	}
+	return "", errors.Join(errs...)
+}
+
+func (c *Client) GetHashByMagnetOn(instance, magnet string) (string, error) {
+	backend, ok := c.instances[instance]
+	if !ok {
+		return "", fmt.Errorf("%w: %q", types.ErrUnknownInstance, instance)
+	}
+
+	hash, err := backend.GetHashByMagnet(magnet)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", instance, err)
+	}
+	return instance + taskIDSeparator + hash, nil
+}
+
+func (c *Client) SetLocation(taskID, location string) error {
+	owner, hash, err := c.splitTaskID(taskID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.instances[owner].SetLocation(hash, location); err != nil {
+		return fmt.Errorf("%s: %w", owner, err)
+	}
+
+	return nil
+}
+
+func (c *Client) SetCategory(taskID, category string) error {
+	owner, hash, err := c.splitTaskID(taskID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.instances[owner].SetCategory(hash, category); err != nil {
+		return fmt.Errorf("%s: %w", owner, err)
+	}
+
+	return nil
+}
+
+func (c *Client) RemoveTorrent(taskID string, deleteFiles bool) error {
+	owner, hash, err := c.splitTaskID(taskID)
+	if err != nil {
+		return err
+	}
+
+	if err := c.instances[owner].RemoveTorrent(hash, deleteFiles); err != nil {
+		return fmt.Errorf("%s: %w", owner, err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetTorrentStatus(taskID string) (types.TorrentStatus, error) {
+	owner, hash, err := c.splitTaskID(taskID)
+	if err != nil {
+		return types.TorrentStatus{}, err
+	}
+
+	status, err := c.instances[owner].GetTorrentStatus(hash)
+	if err != nil {
+		return types.TorrentStatus{}, fmt.Errorf("%s: %w", owner, err)
+	}
+
+	return status, nil
+}
+
+func (c *Client) GetLocations() []types.Location {
+	seen := map[string]bool{}
+	var locations []types.Location
+
+	for _, name := range c.order {
+		for _, location := range c.instances[name].GetLocations() {
+			if seen[location.ID] {
+				continue
+			}
+			seen[location.ID] = true
+			locations = append(locations, location)
+		}
+	}
+
+	return locations
+}
+
+func (c *Client) GetDefaultLocation() string {
+	return c.instances[c.defaultInstance].GetDefaultLocation()
+}
+
+func (c *Client) HasInstance(name string) bool {
+	_, ok := c.instances[name]
+	return ok
+}
+
+func (c *Client) InstanceFor(location string) string {
+	if location == "" {
+		location = c.GetDefaultLocation()
+	}
+	if name, ok := c.routes[normalizeLocation(location)]; ok {
+		return name
+	}
+	return c.defaultInstance
+}
+
+func (c *Client) TaskInstance(taskID string) string {
+	name, _, err := c.splitTaskID(taskID)
+	if err != nil {
+		return ""
+	}
+	return name
+}
+
+func (c *Client) instanceForOptions(opts types.DownloadOptions) (string, error) {
+	if opts.Instance == "" {
+		return c.InstanceFor(opts.Destination), nil
+	}
+	if !c.HasInstance(opts.Instance) {
+		return "", fmt.Errorf("%w: %q", types.ErrUnknownInstance, opts.Instance)
+	}
+	return opts.Instance, nil
+}
+
+func (c *Client) splitTaskID(taskID string) (string, string, error) {
+	name, hash, ok := strings.Cut(taskID, taskIDSeparator)
+	if !ok {
+		return "", "", fmt.Errorf("invalid task id %q", taskID)
+	}
+	if _, exists := c.instances[name]; !exists {
+		return "", "", fmt.Errorf("unknown instance %q in task id", name)
+	}
+	return name, hash, nil
+}
+
+func normalizeLocation(location string) string {
+	return strings.TrimRight(strings.TrimSpace(location), "/")
+}
@@ -0,0 +1,294 @@
+package router
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/types"
+)
+
+type fakeBackend struct {
+	hashes          map[string]string
+	locations       []types.Location
+	defaultLocation string
+	hashErr         error
+
+	created     []string
+	createdDest []string
+	files       [][]byte
+	moved       map[string]string
+	removed     map[string]bool
+}
+
+func newFakeBackend(defaultLocation string, locations ...types.Location) *fakeBackend {
+	return &fakeBackend{
+		hashes:          map[string]string{},
+		locations:       locations,
+		defaultLocation: defaultLocation,
+		moved:           map[string]string{},
+		removed:         map[string]bool{},
+	}
+}
+
+func (f *fakeBackend) CreateDownloadTask(url string, opts types.DownloadOptions) error {
+	f.created = append(f.created, url)
+	f.createdDest = append(f.createdDest, opts.Destination)
+	return nil
+}
+
+func (f *fakeBackend) AddTorrentFile(data []byte, opts types.DownloadOptions) error {
+	f.files = append(f.files, data)
+	f.createdDest = append(f.createdDest, opts.Destination)
+	return nil
+}
+
+func (f *fakeBackend) GetHashByMagnet(magnet string) (string, error) {
+	if f.hashErr != nil {
+		return "", f.hashErr
+	}
+	if hash, ok := f.hashes[magnet]; ok {
+		return hash, nil
+	}
+	return "", types.ErrTorrentNotFound
+}
+
+func (f *fakeBackend) SetLocation(taskID, location string) error {
+	f.moved[taskID] = location
+	return nil
+}
+
+func (f *fakeBackend) SetCategory(taskID, category string) error {
+	return nil
+}
+
+func (f *fakeBackend) RemoveTorrent(taskID string, deleteFiles bool) error {
+	f.removed[taskID] = deleteFiles
+	return nil
+}
+
+func (f *fakeBackend) GetTorrentStatus(taskID string) (types.TorrentStatus, error) {
+	return types.TorrentStatus{State: types.TorrentStateDownloading}, nil
+}
+
+func (f *fakeBackend) GetLocations() []types.Location { return f.locations }
+func (f *fakeBackend) GetDefaultLocation() string     { return f.defaultLocation }
+
+func newTestRouter(t *testing.T) (*Client, *fakeBackend, *fakeBackend) {
+	t.Helper()
+
+	nas := newFakeBackend("/downloads/tv shows",
+		types.Location{ID: "/downloads/tv shows", Name: "TV Shows"},
+		types.Location{ID: "/downloads/movies", Name: "Movies"},
+	)
+	seedbox := newFakeBackend("/downloads/movies",
+		types.Location{ID: "/downloads/movies", Name: "Movies"},
+		types.Location{ID: "/downloads/anime", Name: "Anime"},
+	)
+
+	c, err := NewClient(
+		[]Instance{{Name: "nas", Client: nas}, {Name: "seedbox", Client: seedbox}},
+		map[string]string{"/downloads/movies/": "seedbox", "/downloads/anime": "seedbox"},
+		"",
+	)
+	require.NoError(t, err)
+
+	return c, nas, seedbox
+}
+
+func TestNewClient_Validation(t *testing.T) {
+	backend := newFakeBackend("/downloads")
+
+	tests := []struct {
+		name            string
+		instances       []Instance
+		routes          map[string]string
+		defaultInstance string
+	}{
+		{name: "no instances"},
+		{
+			name:      "duplicate instance",
+			instances: []Instance{{Name: "nas", Client: backend}, {Name: "nas", Client: backend}},
+		},
+		{
+			name:      "separator in instance name",
+			instances: []Instance{{Name: "nas:1", Client: backend}},
+		},
+		{
+			name:            "unknown default instance",
+			instances:       []Instance{{Name: "nas", Client: backend}},
+			defaultInstance: "seedbox",
+		},
+		{
+			name:      "route to unknown instance",
+			instances: []Instance{{Name: "nas", Client: backend}},
+			routes:    map[string]string{"/downloads/movies": "seedbox"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(tt.instances, tt.routes, tt.defaultInstance)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCreateDownloadTask_RoutesByLocation(t *testing.T) {
+	tests := []struct {
+		name        string
+		destination string
+		wantSeedbox bool
+	}{
+		{name: "routed location", destination: "/downloads/movies", wantSeedbox: true},
+		{name: "routed location with trailing slash", destination: "/downloads/anime/", wantSeedbox: true},
+		{name: "unrouted location uses default instance", destination: "/downloads/tv shows"},
+		{name: "empty location uses default instance", destination: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, nas, seedbox := newTestRouter(t)
+
+			require.NoError(t, c.CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: tt.destination}))
+
+			if tt.wantSeedbox {
+				assert.Len(t, seedbox.created, 1)
+				assert.Empty(t, nas.created)
+				return
+			}
+			assert.Len(t, nas.created, 1)
+			assert.Empty(t, seedbox.created)
+		})
+	}
+}
+
+func TestCreateDownloadTask_PinnedInstance(t *testing.T) {
+	c, nas, seedbox := newTestRouter(t)
+
+	require.NoError(t, c.CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads/movies", Instance: "nas"}))
+	assert.Equal(t, []string{"/downloads/movies"}, nas.createdDest)
+	assert.Empty(t, seedbox.created)
+
+	err := c.CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Instance: "unknown"})
+	require.ErrorIs(t, err, types.ErrUnknownInstance)
+}
+
+func TestInstanceLookups(t *testing.T) {
+	c, _, _ := newTestRouter(t)
+
+	assert.Equal(t, "seedbox", c.InstanceFor("/downloads/anime"))
+	assert.Equal(t, "nas", c.InstanceFor(""))
+	assert.Equal(t, "seedbox", c.TaskInstance("seedbox:MOVIEHASH"))
+	assert.Empty(t, c.TaskInstance("MOVIEHASH"))
+	assert.Empty(t, c.TaskInstance("unknown:MOVIEHASH"))
+	assert.True(t, c.HasInstance("nas"))
+	assert.False(t, c.HasInstance("unknown"))
+}
+
+func TestAddTorrentFile_RoutesByLocation(t *testing.T) {
+	c, nas, seedbox := newTestRouter(t)
+
+	require.NoError(t, c.AddTorrentFile([]byte("d4:infodee"), types.DownloadOptions{Destination: "/downloads/anime"}))
+
+	assert.Len(t, seedbox.files, 1)
+	assert.Empty(t, nas.files)
+}
+
+func TestGetHashByMagnet_NamespacesHashByInstance(t *testing.T) {
+	c, nas, seedbox := newTestRouter(t)
+	nas.hashes["magnet:?xt=urn:btih:tv"] = "TVHASH"
+	seedbox.hashes["magnet:?xt=urn:btih:movie"] = "MOVIEHASH"
+
+	hash, err := c.GetHashByMagnet("magnet:?xt=urn:btih:movie")
+	require.NoError(t, err)
+	assert.Equal(t, "seedbox:MOVIEHASH", hash)
+
+	hash, err = c.GetHashByMagnet("magnet:?xt=urn:btih:tv")
+	require.NoError(t, err)
+	assert.Equal(t, "nas:TVHASH", hash)
+
+	_, err = c.GetHashByMagnet("magnet:?xt=urn:btih:missing")
+	require.ErrorIs(t, err, types.ErrTorrentNotFound)
+}
+
+func TestGetHashByMagnet_ReportsUnreachableInstance(t *testing.T) {
+	c, nas, _ := newTestRouter(t)
+	nas.hashErr = fmt.Errorf("connection refused")
+
+	_, err := c.GetHashByMagnet("magnet:?xt=urn:btih:missing")
+
+	require.Error(t, err)
+	assert.NotErrorIs(t, err, types.ErrTorrentNotFound, "a missing torrent must not be reported while an instance is unreachable")
+	assert.Contains(t, err.Error(), "connection refused")
+}
+
+func TestGetHashByMagnet_FoundDespiteOtherInstanceError(t *testing.T) {
+	c, nas, seedbox := newTestRouter(t)
+	nas.hashErr = fmt.Errorf("connection refused")
+	seedbox.hashes["magnet:?xt=urn:btih:movie"] = "MOVIEHASH"
+
+	hash, err := c.GetHashByMagnet("magnet:?xt=urn:btih:movie")
+	require.NoError(t, err)
+	assert.Equal(t, "seedbox:MOVIEHASH", hash)
+}
+
+func TestGetHashByMagnetOn_SameTorrentOnTwoInstances(t *testing.T) {
+	c, nas, seedbox := newTestRouter(t)
+	nas.hashes["magnet:?xt=urn:btih:shared"] = "SHAREDHASH"
+	seedbox.hashes["magnet:?xt=urn:btih:shared"] = "SHAREDHASH"
+
+	hash, err := c.GetHashByMagnet("magnet:?xt=urn:btih:shared")
+	require.NoError(t, err)
+	assert.Equal(t, "nas:SHAREDHASH", hash, "an unscoped lookup returns the first match")
+
+	hash, err = c.GetHashByMagnetOn("seedbox", "magnet:?xt=urn:btih:shared")
+	require.NoError(t, err)
+	assert.Equal(t, "seedbox:SHAREDHASH", hash)
+
+	_, err = c.GetHashByMagnetOn("seedbox", "magnet:?xt=urn:btih:missing")
+	require.ErrorIs(t, err, types.ErrTorrentNotFound)
+
+	_, err = c.GetHashByMagnetOn("unknown", "magnet:?xt=urn:btih:shared")
+	require.ErrorIs(t, err, types.ErrUnknownInstance)
+}
+
+func TestSetLocation(t *testing.T) {
+	c, nas, seedbox := newTestRouter(t)
+
+	require.NoError(t, c.SetLocation("seedbox:MOVIEHASH", "/downloads/anime"))
+	assert.Equal(t, "/downloads/anime", seedbox.moved["MOVIEHASH"])
+	assert.Empty(t, nas.moved)
+
+	require.NoError(t, c.SetLocation("seedbox:MOVIEHASH", "/downloads/tv shows"))
+	assert.Equal(t, "/downloads/tv shows", seedbox.moved["MOVIEHASH"], "the task stays on the instance that owns it")
+	assert.Empty(t, nas.moved)
+
+	err := c.SetLocation("MOVIEHASH", "/downloads/anime")
+	require.Error(t, err)
+
+	err = c.SetLocation("unknown:MOVIEHASH", "/downloads/anime")
+	require.Error(t, err)
+}
+
+func TestRemoveTorrent_DelegatesToOwner(t *testing.T) {
+	c, nas, seedbox := newTestRouter(t)
+
+	require.NoError(t, c.RemoveTorrent("nas:TVHASH", true))
+	assert.Equal(t, map[string]bool{"TVHASH": true}, nas.removed)
+	assert.Empty(t, seedbox.removed)
+
+	require.Error(t, c.RemoveTorrent("TVHASH", true))
+}
+
+func TestGetLocations_MergesInstances(t *testing.T) {
+	c, _, _ := newTestRouter(t)
+
+	assert.Equal(t, []types.Location{
+		{ID: "/downloads/tv shows", Name: "TV Shows"},
+		{ID: "/downloads/movies", Name: "Movies"},
+		{ID: "/downloads/anime", Name: "Anime"},
+	}, c.GetLocations())
+	assert.Equal(t, "/downloads/tv shows", c.GetDefaultLocation())
+}
@@ -0,0 +1,348 @@
+package transmission
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+const (
+	sessionIDHeader = "X-Transmission-Session-Id"
+	defaultRPCPath  = "/transmission/rpc"
+)
+
+const (
+	statusStopped = iota
+	statusCheckWait
+	statusCheck
+	statusDownloadWait
+	statusDownload
+	statusSeedWait
+	statusSeed
+)
+
+type Client struct {
+	httpClient         *http.Client
+	rpcURL             string
+	username           string
+	password           string
+	defaultDestination string
+	locations          []types.Location
+
+	mu        sync.Mutex
+	sessionID string
+}
+
+func NewClient(config config.TransmissionConfig) *Client {
+	return &Client{
+		httpClient:         &http.Client{Timeout: 30 * time.Second},
+		rpcURL:             rpcURL(config.URL),
+		username:           config.Username,
+		password:           config.Password,
+		defaultDestination: config.Destination,
+		locations:          config.Locations,
+	}
+}
+
+func rpcURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = defaultRPCPath
+	}
+	return u.String()
+}
+
+func (c *Client) CreateDownloadTask(url string, opts types.DownloadOptions) error {
+	if err := c.addTorrent("filename", url, opts); err != nil {
+		return fmt.Errorf("add torrent: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) AddTorrentFile(data []byte, opts types.DownloadOptions) error {
+	if err := c.addTorrent("metainfo", base64.StdEncoding.EncodeToString(data), opts); err != nil {
+		return fmt.Errorf("add torrent file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) addTorrent(sourceKey, source string, opts types.DownloadOptions) error {
+	args := map[string]any{sourceKey: source}
+	if opts.Destination != "" {
+		args["download-dir"] = opts.Destination
+	}
+	if labels := downloadLabels(opts); len(labels) > 0 {
+		args["labels"] = labels
+	}
+
+	var resp torrentAddResponse
+	if err := c.call("torrent-add", args, &resp); err != nil {
+		return err
+	}
+
+	if resp.Added == nil && resp.Duplicate == nil {
+		return fmt.Errorf("empty response")
+	}
+
+	return nil
+}
+
+func (c *Client) GetHashByMagnet(magnet string) (string, error) {
+	var resp torrentGetResponse
+	if err := c.call("torrent-get", map[string]any{"fields": []string{"hashString", "magnetLink"}}, &resp); err != nil {
+		return "", fmt.Errorf("get torrents: %w", err)
+	}
+
+	wanted, err := utils.ParseMagnet(magnet)
+	if err != nil {
+		return "", types.ErrTorrentNotFound
+	}
+	for _, torrent := range resp.Torrents {
+		if torrent.HashString == "" {
+			continue
+		}
+		if strings.EqualFold(torrent.HashString, wanted.Hash()) {
+			return torrent.HashString, nil
+		}
+		if current, err := utils.ParseMagnet(torrent.MagnetLink); err == nil && current.SameTorrent(wanted) {
+			return torrent.HashString, nil
+		}
+	}
+
+	return "", types.ErrTorrentNotFound
+}
+
+func (c *Client) SetLocation(taskID, location string) error {
+	args := map[string]any{
+		"ids":      []string{taskID},
+		"location": location,
+		"move":     true,
+	}
+	if err := c.call("torrent-set-location", args, nil); err != nil {
+		return fmt.Errorf("set location: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) SetCategory(_, _ string) error {
+	return fmt.Errorf("set category: %w", errors.ErrUnsupported)
+}
+
+func (c *Client) RemoveTorrent(taskID string, deleteFiles bool) error {
+	args := map[string]any{
+		"ids":               []string{taskID},
+		"delete-local-data": deleteFiles,
+	}
+	if err := c.call("torrent-remove", args, nil); err != nil {
+		return fmt.Errorf("remove torrent: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) GetTorrentStatus(taskID string) (types.TorrentStatus, error) {
+	args := map[string]any{
+		"ids":    []string{taskID},
+		"fields": []string{"hashString", "status", "error", "percentDone", "metadataPercentComplete", "sizeWhenDone", "eta", "doneDate"},
+	}
+
+	var resp torrentGetResponse
+	if err := c.call("torrent-get", args, &resp); err != nil {
+		return types.TorrentStatus{}, fmt.Errorf("get torrents: %w", err)
+	}
+	if len(resp.Torrents) == 0 {
+		return types.TorrentStatus{}, types.ErrTorrentNotFound
+	}
+
+	torrent := resp.Torrents[0]
+	status := types.TorrentStatus{
+		State:    toTorrentState(torrent),
+		Progress: torrent.PercentDone,
+		Size:     torrent.SizeWhenDone,
+	}
+	if torrent.ETA > 0 {
+		status.ETA = torrent.ETA
+	}
+	if torrent.DoneDate > 0 {
+		status.CompletedAt = time.Unix(torrent.DoneDate, 0)
+	}
+
+	return status, nil
+}
+
+func (c *Client) GetLocations() []types.Location {
+	return types.LocationsOrDefault(c.locations)
+}
+
+func (c *Client) GetDefaultLocation() string {
+	return c.defaultDestination
+}
+
+func (c *Client) call(method string, arguments any, out any) error {
+	payload, err := json.Marshal(rpcRequest{Method: method, Arguments: arguments})
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	resp, err := c.post(payload)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode == http.StatusConflict {
+		c.setSessionID(resp.Header.Get(sessionIDHeader))
+		closeBody(resp)
+
+		resp, err = c.post(payload)
+		if err != nil {
+			return err
+		}
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var rpcResp rpcResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+
+	if rpcResp.Result != "success" {
+		return fmt.Errorf("rpc error: %s", rpcResp.Result)
+	}
+
+	if out == nil || len(rpcResp.Arguments) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(rpcResp.Arguments, out); err != nil {
+		return fmt.Errorf("decode arguments: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Client) post(payload []byte) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodPost, c.rpcURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	if sessionID := c.getSessionID(); sessionID != "" {
+		req.Header.Set(sessionIDHeader, sessionID)
+	}
+	if c.username != "" || c.password != "" {
+		req.SetBasicAuth(c.username, c.password)
+	}
+
+	return c.httpClient.Do(req)
+}
+
+func (c *Client) getSessionID() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sessionID
+}
+
+func (c *Client) setSessionID(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sessionID = sessionID
+}
+
+func downloadLabels(opts types.DownloadOptions) []string {
+	labels := make([]string, 0, len(opts.Tags)+1)
+	if opts.Category != "" {
+		labels = append(labels, opts.Category)
+	}
+	return append(labels, opts.Tags...)
+}
+
+func closeBody(resp *http.Response) {
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if err := resp.Body.Close(); err != nil {
+		slog.Error("error closing response body", "error", err)
+	}
+}
+
+type rpcRequest struct {
+	Method    string `json:"method"`
+	Arguments any    `json:"arguments,omitempty"`
+}
+
+type rpcResponse struct {
+	Result    string          `json:"result"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+type torrentAddResponse struct {
+	Added     *torrentInfo `json:"torrent-added"`
+	Duplicate *torrentInfo `json:"torrent-duplicate"`
+}
+
+type torrentGetResponse struct {
+	Torrents []torrentInfo `json:"torrents"`
+}
+
+type torrentInfo struct {
+	ID                      int     `json:"id"`
+	Name                    string  `json:"name"`
+	HashString              string  `json:"hashString"`
+	MagnetLink              string  `json:"magnetLink"`
+	Status                  int     `json:"status"`
+	Error                   int     `json:"error"`
+	PercentDone             float64 `json:"percentDone"`
+	MetadataPercentComplete float64 `json:"metadataPercentComplete"`
+	SizeWhenDone            int64   `json:"sizeWhenDone"`
+	ETA                     int64   `json:"eta"`
+	DoneDate                int64   `json:"doneDate"`
+}
+
+func toTorrentState(torrent torrentInfo) types.TorrentState {
+	if torrent.Error != 0 {
+		return types.TorrentStateError
+	}
+
+	switch torrent.Status {
+	case statusCheckWait, statusCheck:
+		return types.TorrentStateChecking
+	case statusDownloadWait:
+		return types.TorrentStateQueued
+	case statusDownload:
+		if torrent.MetadataPercentComplete < 1 {
+			return types.TorrentStateMetadata
+		}
+		return types.TorrentStateDownloading
+	case statusSeedWait, statusSeed:
+		return types.TorrentStateCompleted
+	case statusStopped:
+		if torrent.PercentDone >= 1 {
+			return types.TorrentStateCompleted
+		}
+		return types.TorrentStatePaused
+	default:
+		return types.TorrentStateUnknown
+	}
+}
@@ -0,0 +1,391 @@
+package transmission
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/types"
+)
+
+type rpcCall struct {
+	Method    string         `json:"method"`
+	Arguments map[string]any `json:"arguments"`
+}
+
+type fakeTransmission struct {
+	server    *httptest.Server
+	sessionID string
+	torrents  []map[string]any
+
+	calls        []rpcCall
+	handshakes   int
+	username     string
+	password     string
+	resultStatus string
+	addResponse  map[string]any
+}
+
+func newFakeTransmission(t *testing.T) *fakeTransmission {
+	t.Helper()
+
+	f := &fakeTransmission{
+		sessionID:    "session-1",
+		resultStatus: "success",
+		addResponse: map[string]any{
+			"torrent-added": map[string]any{"id": 1, "name": "test", "hashString": "abc123"},
+		},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/transmission/rpc", func(w http.ResponseWriter, r *http.Request) {
+		f.username, f.password, _ = r.BasicAuth()
+
+		if r.Header.Get(sessionIDHeader) != f.sessionID {
+			f.handshakes++
+			w.Header().Set(sessionIDHeader, f.sessionID)
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+
+		var call rpcCall
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&call))
+		f.calls = append(f.calls, call)
+
+		var arguments any
+		switch call.Method {
+		case "torrent-add":
+			arguments = f.addResponse
+		case "torrent-get":
+			arguments = map[string]any{"torrents": f.torrents}
+		default:
+			arguments = map[string]any{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"result": f.resultStatus, "arguments": arguments})
+	})
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func (f *fakeTransmission) client() *Client {
+	return NewClient(config.TransmissionConfig{
+		URL:         f.server.URL,
+		Username:    "admin",
+		Password:    "adminpass",
+		Destination: "/downloads/default",
+	})
+}
+
+func TestCreateDownloadTask(t *testing.T) {
+	tests := []struct {
+		name         string
+		resultStatus string
+		addResponse  map[string]any
+		wantErr      bool
+	}{
+		{
+			name:         "success on torrent-added",
+			resultStatus: "success",
+			addResponse:  map[string]any{"torrent-added": map[string]any{"id": 1, "hashString": "abc123"}},
+		},
+		{
+			name:         "success on torrent-duplicate",
+			resultStatus: "success",
+			addResponse:  map[string]any{"torrent-duplicate": map[string]any{"id": 1, "hashString": "abc123"}},
+		},
+		{
+			name:         "error on rpc failure",
+			resultStatus: "invalid or corrupt torrent file",
+			addResponse:  map[string]any{},
+			wantErr:      true,
+		},
+		{
+			name:         "error on empty arguments",
+			resultStatus: "success",
+			addResponse:  map[string]any{},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeTransmission(t)
+			fake.resultStatus = tt.resultStatus
+			fake.addResponse = tt.addResponse
+
+			err := fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads/movies"})
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, fake.calls, 1)
+			assert.Equal(t, "torrent-add", fake.calls[0].Method)
+			assert.Equal(t, "magnet:?xt=urn:btih:abc", fake.calls[0].Arguments["filename"])
+			assert.Equal(t, "/downloads/movies", fake.calls[0].Arguments["download-dir"])
+		})
+	}
+}
+
+func TestCreateDownloadTask_Labels(t *testing.T) {
+	fake := newFakeTransmission(t)
+
+	err := fake.client().CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{
+		Destination: "/downloads/tv shows",
+		Category:    "tv",
+		Tags:        []string{"magnet-feed-sync", "6810475"},
+	})
+
+	require.NoError(t, err)
+	require.Len(t, fake.calls, 1)
+	assert.Equal(t, []any{"tv", "magnet-feed-sync", "6810475"}, fake.calls[0].Arguments["labels"])
+}
+
+func TestCall_SessionHandshake(t *testing.T) {
+	fake := newFakeTransmission(t)
+	client := fake.client()
+
+	require.NoError(t, client.CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads/movies"}))
+	require.NoError(t, client.CreateDownloadTask("magnet:?xt=urn:btih:def", types.DownloadOptions{Destination: "/downloads/movies"}))
+
+	assert.Equal(t, 1, fake.handshakes, "session id should be reused after the first handshake")
+	assert.Len(t, fake.calls, 2)
+	assert.Equal(t, "admin", fake.username)
+	assert.Equal(t, "adminpass", fake.password)
+
+	fake.sessionID = "session-2"
+	require.NoError(t, client.CreateDownloadTask("magnet:?xt=urn:btih:ghi", types.DownloadOptions{Destination: "/downloads/movies"}))
+
+	assert.Equal(t, 2, fake.handshakes, "expired session id should trigger a new handshake")
+	assert.Len(t, fake.calls, 3)
+}
+
+func TestGetHashByMagnet(t *testing.T) {
+	tests := []struct {
+		name     string
+		torrents []map[string]any
+		magnet   string
+		wantHash string
+		wantErr  bool
+	}{
+		{
+			name: "matches by hash string ignoring case",
+			torrents: []map[string]any{
+				{"hashString": "2566e2b012ea1ef9087465bc97a7ac4449f4f0de", "magnetLink": ""},
+			},
+			magnet:   "magnet:?xt=urn:btih:2566E2B012EA1EF9087465BC97A7AC4449F4F0DE&dn=Some.Name",
+			wantHash: "2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+		},
+		{
+			name: "not found when no torrent matches",
+			torrents: []map[string]any{
+				{"hashString": "deadbeef", "magnetLink": "magnet:?xt=urn:btih:deadbeef"},
+			},
+			magnet:  "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+			wantErr: true,
+		},
+		{
+			name: "no false match when queried magnet has no btih hash",
+			torrents: []map[string]any{
+				{"hashString": "", "magnetLink": "magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a"},
+			},
+			magnet:  "magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a",
+			wantErr: true,
+		},
+		{
+			name: "matches base32 hash",
+			torrents: []map[string]any{
+				{"hashString": "2566e2b012ea1ef9087465bc97a7ac4449f4f0de", "magnetLink": ""},
+			},
+			magnet:   "magnet:?xt=urn:btih:EVTOFMAS5IPPSCDUMW6JPJ5MIRE7J4G6",
+			wantHash: "2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeTransmission(t)
+			fake.torrents = tt.torrents
+
+			hash, err := fake.client().GetHashByMagnet(tt.magnet)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantHash, hash)
+		})
+	}
+}
+
+func TestSetLocation(t *testing.T) {
+	fake := newFakeTransmission(t)
+
+	err := fake.client().SetLocation("HASH1", "/downloads/tv shows")
+
+	require.NoError(t, err)
+	require.Len(t, fake.calls, 1)
+	assert.Equal(t, "torrent-set-location", fake.calls[0].Method)
+	assert.Equal(t, []any{"HASH1"}, fake.calls[0].Arguments["ids"])
+	assert.Equal(t, "/downloads/tv shows", fake.calls[0].Arguments["location"])
+	assert.Equal(t, true, fake.calls[0].Arguments["move"])
+}
+
+func TestSetLocation_RPCError(t *testing.T) {
+	fake := newFakeTransmission(t)
+	fake.resultStatus = "no such torrent"
+
+	err := fake.client().SetLocation("HASH1", "/downloads/tv shows")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no such torrent")
+}
+
+func TestRemoveTorrent(t *testing.T) {
+	fake := newFakeTransmission(t)
+
+	err := fake.client().RemoveTorrent("HASH1", true)
+
+	require.NoError(t, err)
+	require.Len(t, fake.calls, 1)
+	assert.Equal(t, "torrent-remove", fake.calls[0].Method)
+	assert.Equal(t, []any{"HASH1"}, fake.calls[0].Arguments["ids"])
+	assert.Equal(t, true, fake.calls[0].Arguments["delete-local-data"])
+}
+
+func TestGetTorrentStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		torrent   map[string]any
+		wantState types.TorrentState
+	}{
+		{
+			name:      "fetching metadata",
+			torrent:   map[string]any{"status": statusDownload, "metadataPercentComplete": 0.5},
+			wantState: types.TorrentStateMetadata,
+		},
+		{
+			name:      "checking",
+			torrent:   map[string]any{"status": statusCheck, "metadataPercentComplete": 1},
+			wantState: types.TorrentStateChecking,
+		},
+		{
+			name:      "downloading",
+			torrent:   map[string]any{"status": statusDownload, "metadataPercentComplete": 1},
+			wantState: types.TorrentStateDownloading,
+		},
+		{
+			name:      "stopped after completion",
+			torrent:   map[string]any{"status": statusStopped, "percentDone": 1, "metadataPercentComplete": 1},
+			wantState: types.TorrentStateCompleted,
+		},
+		{
+			name:      "error",
+			torrent:   map[string]any{"status": statusDownload, "error": 3, "metadataPercentComplete": 1},
+			wantState: types.TorrentStateError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeTransmission(t)
+			tt.torrent["hashString"] = "HASH1"
+			fake.torrents = []map[string]any{tt.torrent}
+
+			status, err := fake.client().GetTorrentStatus("HASH1")
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantState, status.State)
+			assert.Equal(t, []any{"HASH1"}, fake.calls[0].Arguments["ids"])
+		})
+	}
+}
+
+func TestAddTorrentFile(t *testing.T) {
+	fake := newFakeTransmission(t)
+	data := []byte("d4:infod4:name4:testee")
+
+	err := fake.client().AddTorrentFile(data, types.DownloadOptions{Destination: "/downloads/movies"})
+
+	require.NoError(t, err)
+	require.Len(t, fake.calls, 1)
+	args := fake.calls[0].Arguments
+	assert.Equal(t, base64.StdEncoding.EncodeToString(data), args["metainfo"])
+	assert.Equal(t, "/downloads/movies", args["download-dir"])
+	assert.NotContains(t, args, "filename")
+}
+
+func TestGetTorrentStatus_Details(t *testing.T) {
+	fake := newFakeTransmission(t)
+	fake.torrents = []map[string]any{{
+		"hashString":              "HASH1",
+		"status":                  statusSeed,
+		"percentDone":             1,
+		"metadataPercentComplete": 1,
+		"sizeWhenDone":            4096,
+		"eta":                     -1,
+		"doneDate":                1760000000,
+	}}
+
+	status, err := fake.client().GetTorrentStatus("HASH1")
+
+	require.NoError(t, err)
+	assert.Equal(t, types.TorrentStatus{
+		State:       types.TorrentStateCompleted,
+		Progress:    1,
+		Size:        4096,
+		CompletedAt: time.Unix(1760000000, 0),
+	}, status)
+}
+
+func TestGetTorrentStatus_NotFound(t *testing.T) {
+	fake := newFakeTransmission(t)
+
+	_, err := fake.client().GetTorrentStatus("HASH1")
+
+	require.Error(t, err)
+}
+
+func TestCall_Unauthorized(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	client := NewClient(config.TransmissionConfig{URL: server.URL})
+
+	err := client.CreateDownloadTask("magnet:?xt=urn:btih:abc", types.DownloadOptions{Destination: "/downloads"})
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestRPCURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "bare host gets default rpc path", url: "http://nas:9091", want: "http://nas:9091/transmission/rpc"},
+		{name: "root path gets default rpc path", url: "http://nas:9091/", want: "http://nas:9091/transmission/rpc"},
+		{name: "custom rpc path is kept", url: "https://example.com/tr/rpc", want: "https://example.com/tr/rpc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rpcURL(tt.url))
+		})
+	}
+}
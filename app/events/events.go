@@ -2,14 +2,24 @@ package events
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"magnet-feed-sync/app/bot"
 	downloadTask "magnet-feed-sync/app/bot/download-tasks"
 	taskStore "magnet-feed-sync/app/task-store"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+	"net/http"
+	"path/filepath"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	tbapi "github.com/OvyFlash/telegram-bot-api"
 )
@@ -17,22 +27,24 @@ import (
 const (
 	PingCommand           = "ping"
 	GetActiveTasksCommand = "get_active_tasks"
+	SearchCommand         = "search"
 	RemoveTaskCallback    = "remove_task"
+	SearchTrackCallback   = "search_track"
+	SearchOnceCallback    = "search_once"
 )
 
-var folderCommands = map[string]string{
-	"movies":     "/downloads/movies",
-	"books":      "/downloads/books",
-	"music":      "/downloads/music",
-	"other":      "/downloads/other",
-	"comics":     "/downloads/comics",
-	"podcasts":   "/downloads/podcasts",
-	"audiobooks": "/downloads/audiobooks",
-	"anime":      "/downloads/anime",
-}
+const (
+	searchResultsLimit = 10
+	maxCachedSearches  = 20
+)
+
+var documentClient = &http.Client{Timeout: 30 * time.Second}
 
 type Bot interface {
 	OnMessage(ctx context.Context, msg bot.Message, location string) (bool, string, error)
+	DownloadTorrentFile(ctx context.Context, data []byte, location string) error
+	DownloadNow(ctx context.Context, source, location string) error
+	Search(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error)
 	RemoveTask(id string) error
 }
 
@@ -40,6 +52,7 @@ type TbAPI interface {
 	GetUpdatesChan(config tbapi.UpdateConfig) tbapi.UpdatesChannel
 	Send(c tbapi.Chattable) (tbapi.Message, error)
 	Request(c tbapi.Chattable) (*tbapi.APIResponse, error)
+	GetFileDirectURL(fileID string) (string, error)
 }
 
 type TelegramListener struct {
@@ -48,14 +61,43 @@ type TelegramListener struct {
 	Bot             Bot
 	Store           *taskStore.Repository
 	MessagesForSend chan string
+	Locations       []types.Location
+
+	searchMu    sync.Mutex
+	searches    map[string][]types.SearchResult
+	searchOrder []string
 }
 
-type RemoveTaskData struct {
-	TaskID string `json:"taskId"`
-	Type   string `json:"type"`
+type CallbackData struct {
+	Type     string `json:"type"`
+	TaskID   string `json:"taskId,omitempty"`
+	SearchID string `json:"searchId,omitempty"`
+	Index    int    `json:"index,omitempty"`
+}
+
+func ValidateLocationCommands(locations []types.Location) error {
+	seen := map[string]string{PingCommand: "", GetActiveTasksCommand: "", SearchCommand: ""}
+	for _, location := range locations {
+		if location.Command == "" {
+			continue
+		}
+		if owner, exists := seen[location.Command]; exists {
+			if owner == "" {
+				return fmt.Errorf("command %q of location %q is reserved", location.Command, location.ID)
+			}
+			return fmt.Errorf("command %q is used by locations %q and %q", location.Command, owner, location.ID)
+		}
+		seen[location.Command] = location.ID
+	}
+
+	return nil
 }
 
 func (tl *TelegramListener) Do() error {
+	if err := tl.registerCommands(); err != nil {
+		slog.Warn("failed to register bot commands", "error", err)
+	}
+
 	u := tbapi.NewUpdate(0)
 	u.Timeout = 60
 
@@ -109,13 +151,21 @@ func (tl *TelegramListener) processEvent(update tbapi.Update) error {
 	case GetActiveTasksCommand:
 		tl.handleGetActiveTasksCommand(update)
 		return nil
+
+	case SearchCommand:
+		tl.handleSearchCommand(update)
+		return nil
+	}
+
+	if isTorrentDocument(update.Message.Document) {
+		return tl.handleTorrentDocument(update.Message)
 	}
 
 	msg := tl.transform(update.Message)
 	location := ""
 
 	if cmd := update.Message.Command(); cmd != "" {
-		if loc, ok := folderCommands[cmd]; ok {
+		if loc, ok := tl.locationForCommand(cmd); ok {
 			location = loc
 			msg.Text = update.Message.CommandArguments()
 		}
@@ -152,9 +202,122 @@ func (tl *TelegramListener) processEvent(update tbapi.Update) error {
 	return nil
 }
 
+func (tl *TelegramListener) handleTorrentDocument(message *tbapi.Message) error {
+	data, err := tl.downloadDocument(message.Document)
+	if err == nil {
+		err = tl.Bot.DownloadTorrentFile(context.Background(), data, tl.captionLocation(message.Caption))
+	}
+	if err != nil {
+		errMsg := tbapi.NewMessage(message.Chat.ID, "💥 Error: "+err.Error())
+		_, err := tl.TbAPI.Send(errMsg)
+		if err != nil {
+			return fmt.Errorf("failed to send error message: %w", err)
+		}
+
+		return errors.New(errMsg.Text)
+	}
+
+	if err := tl.reactToMessage(message.Chat.ID, message.MessageID, tbapi.ReactionType{
+		Type:  "emoji",
+		Emoji: "👍",
+	}); err != nil {
+		return fmt.Errorf("failed to react to message: %w", err)
+	}
+
+	return nil
+}
+
+func (tl *TelegramListener) downloadDocument(document *tbapi.Document) ([]byte, error) {
+	if document.FileSize > utils.MaxTorrentFileSize {
+		return nil, fmt.Errorf("torrent file is too large")
+	}
+
+	fileURL, err := tl.TbAPI.GetFileDirectURL(document.FileID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get file url: %w", err)
+	}
+
+	resp, err := documentClient.Get(fileURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download file: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, utils.MaxTorrentFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	if len(data) > utils.MaxTorrentFileSize {
+		return nil, fmt.Errorf("torrent file is too large")
+	}
+	if !utils.IsTorrentFile(data) {
+		return nil, fmt.Errorf("file is not a valid torrent")
+	}
+
+	return data, nil
+}
+
+func isTorrentDocument(document *tbapi.Document) bool {
+	if document == nil {
+		return false
+	}
+	return strings.EqualFold(filepath.Ext(document.FileName), ".torrent") || document.MimeType == "application/x-bittorrent"
+}
+
+func (tl *TelegramListener) captionLocation(caption string) string {
+	fields := strings.Fields(caption)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return ""
+	}
+
+	cmd, _, _ := strings.Cut(strings.TrimPrefix(fields[0], "/"), "@")
+	location, _ := tl.locationForCommand(cmd)
+	return location
+}
+
+func (tl *TelegramListener) locationForCommand(cmd string) (string, bool) {
+	for _, location := range tl.Locations {
+		if location.Command != "" && location.Command == cmd {
+			return location.ID, true
+		}
+	}
+	return "", false
+}
+
+func (tl *TelegramListener) registerCommands() error {
+	commands := []tbapi.BotCommand{
+		{Command: GetActiveTasksCommand, Description: "Retrieve tasks for monitoring"},
+		{Command: PingCommand, Description: "Check if bot is running"},
+		{Command: SearchCommand, Description: "Search torrents via Jackett"},
+	}
+	for _, location := range tl.Locations {
+		if location.Command == "" {
+			continue
+		}
+		commands = append(commands, tbapi.BotCommand{
+			Command:     location.Command,
+			Description: "Download to " + location.Name,
+		})
+	}
+
+	if _, err := tl.TbAPI.Request(tbapi.NewSetMyCommands(commands...)); err != nil {
+		return fmt.Errorf("failed to set bot commands: %w", err)
+	}
+
+	return nil
+}
+
 func (tl *TelegramListener) processCallbackQuery(update tbapi.Update) error {
 	rawMsgData := update.CallbackQuery.Data
-	var data RemoveTaskData
+	var data CallbackData
 
 	if err := json.Unmarshal([]byte(rawMsgData), &data); err != nil {
 		return fmt.Errorf("failed to unmarshal callback data: %w", err)
@@ -178,11 +341,161 @@ func (tl *TelegramListener) processCallbackQuery(update tbapi.Update) error {
 			return fmt.Errorf("failed to delete message: %w", err)
 		}
 		slog.Debug("task removed", "taskId", data.TaskID)
+
+	case SearchTrackCallback, SearchOnceCallback:
+		if !tl.isSuperUser(update.CallbackQuery.From.ID) {
+			slog.Debug("user is not super user", "userId", update.CallbackQuery.From.ID)
+			tl.sendText(update.CallbackQuery.Message.Chat.ID, "I don't know you 🤷‍")
+			return nil
+		}
+		return tl.handleSearchCallback(update.CallbackQuery.Message.Chat.ID, data)
+	}
+
+	return nil
+}
+
+func (tl *TelegramListener) handleSearchCommand(update tbapi.Update) {
+	chatID := update.Message.Chat.ID
+	query := strings.TrimSpace(update.Message.CommandArguments())
+	if query == "" {
+		tl.sendText(chatID, "Usage: /search <query>")
+		return
+	}
+
+	results, err := tl.Bot.Search(context.Background(), types.SearchQuery{Query: query, Limit: searchResultsLimit})
+	if err != nil {
+		tl.sendText(chatID, "💥 Error: "+err.Error())
+		return
+	}
+
+	if len(results) == 0 {
+		tl.sendText(chatID, "🔎 Nothing found for "+query)
+		return
+	}
+
+	searchID, err := tl.saveSearch(results)
+	if err != nil {
+		tl.sendText(chatID, "💥 Error: "+err.Error())
+		return
+	}
+
+	var text strings.Builder
+	rows := make([][]ReplyMarkupButton, 0, len(results))
+	fmt.Fprintf(&text, "🔎 Results for %s:\n", query)
+	for i, result := range results {
+		fmt.Fprintf(&text, "\n%d. %s\n%s\n", i+1, result.Title, searchResultDetails(result))
+
+		var row []ReplyMarkupButton
+		if result.Trackable {
+			row = append(row, ReplyMarkupButton{
+				Text: fmt.Sprintf("📌 Track %d", i+1),
+				Data: map[string]any{"type": SearchTrackCallback, "searchId": searchID, "index": i},
+			})
+		}
+		row = append(row, ReplyMarkupButton{
+			Text: fmt.Sprintf("⬇️ Once %d", i+1),
+			Data: map[string]any{"type": SearchOnceCallback, "searchId": searchID, "index": i},
+		})
+		rows = append(rows, row)
+	}
+
+	replyMarkup, err := buildReplyMarkupRows(rows)
+	if err != nil {
+		slog.Error("failed to build reply markup", "error", err)
+		return
+	}
+
+	msg := tbapi.NewMessage(chatID, text.String())
+	msg.ReplyMarkup = replyMarkup
+	if _, err := tl.TbAPI.Send(msg); err != nil {
+		slog.Error("failed to send message", "error", err)
+	}
+}
+
+func (tl *TelegramListener) handleSearchCallback(chatID int64, data CallbackData) error {
+	result, ok := tl.searchResult(data.SearchID, data.Index)
+	if !ok {
+		tl.sendText(chatID, "⌛ Search results expired, run /search again")
+		return nil
+	}
+
+	ctx := context.Background()
+
+	if data.Type == SearchTrackCallback {
+		_, replyMsg, err := tl.Bot.OnMessage(ctx, bot.Message{ChatID: chatID, Text: result.TrackerURL}, "")
+		if err != nil {
+			tl.sendText(chatID, "💥 Error: "+err.Error())
+			return fmt.Errorf("failed to track search result: %w", err)
+		}
+
+		if _, err := tl.TbAPI.Send(NewMarkdownMessage(chatID, replyMsg, nil)); err != nil {
+			return fmt.Errorf("failed to reply send message: %w", err)
+		}
+		return nil
 	}
 
+	if err := tl.Bot.DownloadNow(ctx, result.Source(), ""); err != nil {
+		tl.sendText(chatID, "💥 Error: "+err.Error())
+		return fmt.Errorf("failed to download search result: %w", err)
+	}
+
+	tl.sendText(chatID, "⬇️ Download started: "+result.Title)
 	return nil
 }
 
+func (tl *TelegramListener) saveSearch(results []types.SearchResult) (string, error) {
+	buf := make([]byte, 4)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate search id: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	tl.searchMu.Lock()
+	defer tl.searchMu.Unlock()
+
+	if tl.searches == nil {
+		tl.searches = make(map[string][]types.SearchResult)
+	}
+	tl.searches[id] = results
+	tl.searchOrder = append(tl.searchOrder, id)
+
+	if len(tl.searchOrder) > maxCachedSearches {
+		delete(tl.searches, tl.searchOrder[0])
+		tl.searchOrder = tl.searchOrder[1:]
+	}
+
+	return id, nil
+}
+
+func (tl *TelegramListener) searchResult(id string, index int) (types.SearchResult, bool) {
+	tl.searchMu.Lock()
+	defer tl.searchMu.Unlock()
+
+	results, ok := tl.searches[id]
+	if !ok || index < 0 || index >= len(results) {
+		return types.SearchResult{}, false
+	}
+	return results[index], true
+}
+
+func searchResultDetails(result types.SearchResult) string {
+	var details []string
+	if result.Indexer != "" {
+		details = append(details, result.Indexer)
+	}
+	if result.Torrent.Size > 0 {
+		details = append(details, utils.FormatSize(result.Torrent.Size))
+	}
+	details = append(details, fmt.Sprintf("%d↑ %d↓", result.Torrent.Seeders, result.Torrent.Leechers))
+	return strings.Join(details, " · ")
+}
+
+func (tl *TelegramListener) sendText(chatID int64, text string) {
+	if _, err := tl.TbAPI.Send(tbapi.NewMessage(chatID, text)); err != nil {
+		slog.Error("failed to send message", "error", err)
+	}
+}
+
 func (tl *TelegramListener) transform(message *tbapi.Message) bot.Message {
 	msg := bot.Message{
 		ID:     message.MessageID,
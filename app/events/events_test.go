@@ -2,20 +2,27 @@ package events
 
 import (
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	tbapi "github.com/OvyFlash/telegram-bot-api"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"magnet-feed-sync/app/bot"
+	"magnet-feed-sync/app/types"
 )
 
 type mockBot struct {
-	lastMessage  bot.Message
-	lastLocation string
-	returnSaved  bool
-	returnReply  string
-	returnError  error
+	lastMessage   bot.Message
+	lastLocation  string
+	lastTorrent   []byte
+	lastSource    string
+	lastSearch    types.SearchQuery
+	searchResults []types.SearchResult
+	returnSaved   bool
+	returnReply   string
+	returnError   error
 }
 
 func (m *mockBot) OnMessage(_ context.Context, msg bot.Message, location string) (bool, string, error) {
@@ -24,10 +31,33 @@ func (m *mockBot) OnMessage(_ context.Context, msg bot.Message, location string)
 	return m.returnSaved, m.returnReply, m.returnError
 }
 
+func (m *mockBot) DownloadTorrentFile(_ context.Context, data []byte, location string) error {
+	m.lastTorrent = data
+	m.lastLocation = location
+	return m.returnError
+}
+
+func (m *mockBot) DownloadNow(_ context.Context, source, location string) error {
+	m.lastSource = source
+	m.lastLocation = location
+	return m.returnError
+}
+
+func (m *mockBot) Search(_ context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	m.lastSearch = query
+	return m.searchResults, m.returnError
+}
+
 func (m *mockBot) RemoveTask(id string) error { return nil }
 
 type mockTbAPI struct {
 	sentMessages []tbapi.Chattable
+	requests     []tbapi.Chattable
+	fileURL      string
+}
+
+func (m *mockTbAPI) GetFileDirectURL(fileID string) (string, error) {
+	return m.fileURL + "/" + fileID, nil
 }
 
 func (m *mockTbAPI) GetUpdatesChan(config tbapi.UpdateConfig) tbapi.UpdatesChannel {
@@ -40,6 +70,7 @@ func (m *mockTbAPI) Send(c tbapi.Chattable) (tbapi.Message, error) {
 }
 
 func (m *mockTbAPI) Request(c tbapi.Chattable) (*tbapi.APIResponse, error) {
+	m.requests = append(m.requests, c)
 	return &tbapi.APIResponse{Ok: true}, nil
 }
 
@@ -91,15 +122,67 @@ func TestFolderCommands(t *testing.T) {
 		},
 	}
 
+	tl := &TelegramListener{Locations: types.DefaultLocations()}
+
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			location, ok := folderCommands[tt.command]
-			require.True(t, ok, "command %s should exist in folderCommands", tt.command)
+			location, ok := tl.locationForCommand(tt.command)
+			require.True(t, ok, "command %s should exist in locations", tt.command)
 			assert.Equal(t, tt.expectedLocation, location)
 		})
 	}
 }
 
+func TestFolderCommands_FromConfiguredLocations(t *testing.T) {
+	tl := &TelegramListener{Locations: []types.Location{
+		{ID: "/data/series", Name: "Series", Command: "series"},
+		{ID: "/data/misc", Name: "Misc"},
+	}}
+
+	location, ok := tl.locationForCommand("series")
+	require.True(t, ok)
+	assert.Equal(t, "/data/series", location)
+
+	_, ok = tl.locationForCommand("movies")
+	assert.False(t, ok, "default commands should not exist when locations are configured")
+}
+
+func TestValidateLocationCommands(t *testing.T) {
+	require.NoError(t, ValidateLocationCommands(types.DefaultLocations()))
+
+	err := ValidateLocationCommands([]types.Location{{ID: "/data/ping", Command: PingCommand}})
+	require.Error(t, err, "built-in commands cannot be reused")
+
+	err = ValidateLocationCommands([]types.Location{
+		{ID: "/data/a", Command: "media"},
+		{ID: "/data/b", Command: "media"},
+	})
+	require.Error(t, err, "commands must be unique")
+}
+
+func TestRegisterCommands(t *testing.T) {
+	mockAPI := &mockTbAPI{}
+	tl := &TelegramListener{
+		TbAPI: mockAPI,
+		Locations: []types.Location{
+			{ID: "/data/series", Name: "Series", Command: "series"},
+			{ID: "/data/misc", Name: "Misc"},
+		},
+	}
+
+	require.NoError(t, tl.registerCommands())
+
+	require.Len(t, mockAPI.requests, 1)
+	cfg, ok := mockAPI.requests[0].(tbapi.SetMyCommandsConfig)
+	require.True(t, ok)
+	assert.Equal(t, []tbapi.BotCommand{
+		{Command: GetActiveTasksCommand, Description: "Retrieve tasks for monitoring"},
+		{Command: PingCommand, Description: "Check if bot is running"},
+		{Command: SearchCommand, Description: "Search torrents via Jackett"},
+		{Command: "series", Description: "Download to Series"},
+	}, cfg.Commands)
+}
+
 func TestProcessEvent_FolderCommands(t *testing.T) {
 	tests := []struct {
 		name             string
@@ -147,6 +230,7 @@ func TestProcessEvent_FolderCommands(t *testing.T) {
 				SuperUsers: []int64{123},
 				TbAPI:      mockAPI,
 				Bot:        mockB,
+				Locations:  types.DefaultLocations(),
 			}
 
 			var entities []tbapi.MessageEntity
@@ -201,3 +285,198 @@ func TestProcessEvent_NonSuperUser(t *testing.T) {
 	assert.Empty(t, mockB.lastMessage.Text, "bot should not receive message from non-super user")
 	assert.Len(t, mockAPI.sentMessages, 1, "should send rejection message")
 }
+
+func TestProcessEvent_TorrentDocument(t *testing.T) {
+	torrent := []byte("d8:announce3:url4:infod4:name4:testee")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/file-1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(torrent)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name             string
+		caption          string
+		expectedLocation string
+	}{
+		{name: "without caption", caption: "", expectedLocation: ""},
+		{name: "folder command caption", caption: "/movies", expectedLocation: "/downloads/movies"},
+		{name: "folder command with bot name", caption: "/anime@magnet_bot", expectedLocation: "/downloads/anime"},
+		{name: "unknown command caption", caption: "/unknown", expectedLocation: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockB := &mockBot{}
+			tl := &TelegramListener{
+				SuperUsers: []int64{123},
+				TbAPI:      &mockTbAPI{fileURL: server.URL},
+				Bot:        mockB,
+				Locations:  types.DefaultLocations(),
+			}
+
+			update := tbapi.Update{
+				Message: &tbapi.Message{
+					Caption:  tt.caption,
+					Chat:     tbapi.Chat{ID: 1},
+					From:     &tbapi.User{ID: 123},
+					Document: &tbapi.Document{FileID: "file-1", FileName: "Episode.S01E01.torrent", FileSize: int64(len(torrent))},
+				},
+			}
+
+			require.NoError(t, tl.processEvent(update))
+			assert.Equal(t, torrent, mockB.lastTorrent)
+			assert.Equal(t, tt.expectedLocation, mockB.lastLocation)
+			assert.Empty(t, mockB.lastMessage.Text, "torrent documents should not be parsed as tracker links")
+		})
+	}
+}
+
+func TestProcessEvent_TorrentDocumentInvalid(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>not a torrent</html>"))
+	}))
+	defer server.Close()
+
+	mockB := &mockBot{}
+	mockAPI := &mockTbAPI{fileURL: server.URL}
+	tl := &TelegramListener{
+		SuperUsers: []int64{123},
+		TbAPI:      mockAPI,
+		Bot:        mockB,
+	}
+
+	update := tbapi.Update{
+		Message: &tbapi.Message{
+			Chat:     tbapi.Chat{ID: 1},
+			From:     &tbapi.User{ID: 123},
+			Document: &tbapi.Document{FileID: "file-1", FileName: "fake.torrent"},
+		},
+	}
+
+	require.Error(t, tl.processEvent(update))
+	assert.Nil(t, mockB.lastTorrent)
+	require.Len(t, mockAPI.sentMessages, 1)
+}
+
+func TestProcessEvent_SearchCommand(t *testing.T) {
+	mockB := &mockBot{searchResults: []types.SearchResult{
+		{
+			Title:      "Severance S02 2160p",
+			Indexer:    "RuTracker",
+			TrackerURL: "https://rutracker.org/forum/viewtopic.php?t=6810475",
+			Magnet:     "magnet:?xt=urn:btih:abc123",
+			Torrent:    types.TorrentInfo{Size: 50 << 30, Seeders: 150, Leechers: 30},
+			Trackable:  true,
+		},
+		{Title: "Severance S02 720p", Indexer: "Rutor", Link: "https://jackett.example.com/dl/rutor/?path=x"},
+	}}
+	mockAPI := &mockTbAPI{}
+	tl := &TelegramListener{SuperUsers: []int64{123}, TbAPI: mockAPI, Bot: mockB}
+
+	update := tbapi.Update{
+		Message: &tbapi.Message{
+			Text:     "/search severance s02",
+			Chat:     tbapi.Chat{ID: 1},
+			From:     &tbapi.User{ID: 123},
+			Entities: []tbapi.MessageEntity{{Type: "bot_command", Offset: 0, Length: 7}},
+		},
+	}
+
+	require.NoError(t, tl.processEvent(update))
+	assert.Equal(t, types.SearchQuery{Query: "severance s02", Limit: searchResultsLimit}, mockB.lastSearch)
+
+	require.Len(t, mockAPI.sentMessages, 1)
+	msg, ok := mockAPI.sentMessages[0].(tbapi.MessageConfig)
+	require.True(t, ok)
+	assert.Contains(t, msg.Text, "1. Severance S02 2160p\nRuTracker · 50.0 GiB · 150↑ 30↓")
+	assert.Contains(t, msg.Text, "2. Severance S02 720p\nRutor · 0↑ 0↓")
+
+	markup, ok := msg.ReplyMarkup.(tbapi.InlineKeyboardMarkup)
+	require.True(t, ok)
+	require.Len(t, markup.InlineKeyboard, 2)
+	require.Len(t, markup.InlineKeyboard[0], 2)
+	require.Len(t, markup.InlineKeyboard[1], 1, "untrackable results only offer a one-shot download")
+	assert.Equal(t, "📌 Track 1", markup.InlineKeyboard[0][0].Text)
+	assert.Equal(t, "⬇️ Once 2", markup.InlineKeyboard[1][0].Text)
+	for _, row := range markup.InlineKeyboard {
+		for _, button := range row {
+			assert.LessOrEqual(t, len(*button.CallbackData), 64)
+		}
+	}
+
+	callback := func(button tbapi.InlineKeyboardButton) tbapi.Update {
+		return tbapi.Update{CallbackQuery: &tbapi.CallbackQuery{
+			Data:    *button.CallbackData,
+			From:    &tbapi.User{ID: 123},
+			Message: &tbapi.Message{Chat: tbapi.Chat{ID: 1}},
+		}}
+	}
+
+	mockB.returnReply = "✅ Download task created"
+	require.NoError(t, tl.processCallbackQuery(callback(markup.InlineKeyboard[0][0])))
+	assert.Equal(t, "https://rutracker.org/forum/viewtopic.php?t=6810475", mockB.lastMessage.Text)
+
+	require.NoError(t, tl.processCallbackQuery(callback(markup.InlineKeyboard[1][0])))
+	assert.Equal(t, "https://jackett.example.com/dl/rutor/?path=x", mockB.lastSource)
+	assert.Empty(t, mockB.lastLocation)
+}
+
+func TestProcessCallbackQuery_SearchExpired(t *testing.T) {
+	mockB := &mockBot{}
+	mockAPI := &mockTbAPI{}
+	tl := &TelegramListener{SuperUsers: []int64{123}, TbAPI: mockAPI, Bot: mockB}
+
+	update := tbapi.Update{CallbackQuery: &tbapi.CallbackQuery{
+		Data:    `{"type":"search_once","searchId":"deadbeef","index":0}`,
+		From:    &tbapi.User{ID: 123},
+		Message: &tbapi.Message{Chat: tbapi.Chat{ID: 1}},
+	}}
+
+	require.NoError(t, tl.processCallbackQuery(update))
+	assert.Empty(t, mockB.lastSource)
+	require.Len(t, mockAPI.sentMessages, 1)
+	assert.Contains(t, mockAPI.sentMessages[0].(tbapi.MessageConfig).Text, "expired")
+}
+
+func TestProcessCallbackQuery_SearchNonSuperUser(t *testing.T) {
+	mockB := &mockBot{}
+	mockAPI := &mockTbAPI{}
+	tl := &TelegramListener{SuperUsers: []int64{123}, TbAPI: mockAPI, Bot: mockB}
+
+	searchID, err := tl.saveSearch([]types.SearchResult{
+		{Title: "Severance S02 2160p", TrackerURL: "https://rutracker.org/forum/viewtopic.php?t=6810475", Trackable: true},
+	})
+	require.NoError(t, err)
+
+	for _, callbackType := range []string{SearchTrackCallback, SearchOnceCallback} {
+		update := tbapi.Update{CallbackQuery: &tbapi.CallbackQuery{
+			Data:    `{"type":"` + callbackType + `","searchId":"` + searchID + `","index":0}`,
+			From:    &tbapi.User{ID: 456},
+			Message: &tbapi.Message{Chat: tbapi.Chat{ID: 1}},
+		}}
+		require.NoError(t, tl.processCallbackQuery(update))
+	}
+
+	assert.Empty(t, mockB.lastMessage.Text, "non-super user should not track search results")
+	assert.Empty(t, mockB.lastSource, "non-super user should not start downloads")
+	assert.Len(t, mockAPI.sentMessages, 2, "should send rejection messages")
+}
+
+func TestSaveSearch_EvictsOldest(t *testing.T) {
+	tl := &TelegramListener{}
+
+	first, err := tl.saveSearch([]types.SearchResult{{Title: "first"}})
+	require.NoError(t, err)
+	for range maxCachedSearches {
+		_, err := tl.saveSearch([]types.SearchResult{{Title: "next"}})
+		require.NoError(t, err)
+	}
+
+	_, ok := tl.searchResult(first, 0)
+	assert.False(t, ok)
+	assert.Len(t, tl.searches, maxCachedSearches)
+}
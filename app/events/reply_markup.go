@@ -11,20 +11,27 @@ type ReplyMarkupButton struct {
 }
 
 func buildReplyMarkup(buttons []ReplyMarkupButton) (tbapi.InlineKeyboardMarkup, error) {
+	return buildReplyMarkupRows([][]ReplyMarkupButton{buttons})
+}
+
+func buildReplyMarkupRows(rows [][]ReplyMarkupButton) (tbapi.InlineKeyboardMarkup, error) {
 	markup := tbapi.NewInlineKeyboardMarkup()
-	baseRow := tbapi.NewInlineKeyboardRow()
 
-	for _, button := range buttons {
-		jsonData, err := packButtonData(button.Data)
-		if err != nil {
-			return markup, err
+	for _, buttons := range rows {
+		row := tbapi.NewInlineKeyboardRow()
+
+		for _, button := range buttons {
+			jsonData, err := packButtonData(button.Data)
+			if err != nil {
+				return markup, err
+			}
+
+			row = append(row, tbapi.NewInlineKeyboardButtonData(button.Text, jsonData))
 		}
 
-		baseRow = append(baseRow, tbapi.NewInlineKeyboardButtonData(button.Text, jsonData))
+		markup.InlineKeyboard = append(markup.InlineKeyboard, row)
 	}
 
-	markup.InlineKeyboard = append(markup.InlineKeyboard, baseRow)
-
 	return markup, nil
 }
 
@@ -0,0 +1,173 @@
+package feed_store
+
+import (
+	"database/sql"
+	"log/slog"
+	"magnet-feed-sync/app/database"
+	"magnet-feed-sync/app/types"
+	"time"
+)
+
+type Repository struct {
+	db *database.Client
+}
+
+func NewRepository(db *database.Client) (*Repository, error) {
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS feeds (
+    		id INTEGER PRIMARY KEY AUTOINCREMENT,
+    		url TEXT NOT NULL UNIQUE,
+    		name TEXT NOT NULL DEFAULT '',
+    		include_filter TEXT NOT NULL DEFAULT '',
+    		exclude_filter TEXT NOT NULL DEFAULT '',
+    		accept_rules TEXT NOT NULL DEFAULT '',
+    		reject_rules TEXT NOT NULL DEFAULT '',
+    		location TEXT NOT NULL DEFAULT '',
+    		mode TEXT NOT NULL DEFAULT 'once',
+    		last_checked_at TIMESTAMP DEFAULT NULL,
+    		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS feed_items (
+    		feed_id INTEGER NOT NULL,
+    		guid TEXT NOT NULL,
+    		title TEXT NOT NULL DEFAULT '',
+    		seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    		PRIMARY KEY (feed_id, guid)
+	)`)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Repository{db: db}, nil
+}
+
+func (r *Repository) Create(feed *types.Feed) error {
+	result, err := r.db.Exec(`INSERT INTO feeds (
+				url,
+				name,
+				include_filter,
+				exclude_filter,
+				accept_rules,
+				reject_rules,
+				location,
+				mode
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (url) DO NOTHING`,
+		feed.URL,
+		feed.Name,
+		feed.Include,
+		feed.Exclude,
+		feed.Accept,
+		feed.Reject,
+		feed.Location,
+		feed.Mode,
+	)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return types.ErrFeedExists
+	}
+
+	feed.ID, err = result.LastInsertId()
+	return err
+}
+
+func (r *Repository) GetAll() ([]*types.Feed, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			id,
+			url,
+			name,
+			include_filter,
+			exclude_filter,
+			accept_rules,
+			reject_rules,
+			location,
+			mode,
+			last_checked_at,
+			created_at
+		FROM
+			feeds
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			slog.Error("failed to close rows", "error", err)
+		}
+	}()
+
+	var feeds []*types.Feed
+	for rows.Next() {
+		var (
+			f             types.Feed
+			lastCheckedAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&f.ID,
+			&f.URL,
+			&f.Name,
+			&f.Include,
+			&f.Exclude,
+			&f.Accept,
+			&f.Reject,
+			&f.Location,
+			&f.Mode,
+			&lastCheckedAt,
+			&f.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		f.LastCheckedAt = lastCheckedAt.Time
+
+		feeds = append(feeds, &f)
+	}
+
+	return feeds, rows.Err()
+}
+
+func (r *Repository) Remove(id int64) error {
+	result, err := r.db.Exec(`DELETE FROM feeds WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return types.ErrFeedNotFound
+	}
+
+	_, err = r.db.Exec(`DELETE FROM feed_items WHERE feed_id = ?`, id)
+	return err
+}
+
+func (r *Repository) HasItem(feedID int64, guid string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM feed_items WHERE feed_id = ? AND guid = ?)`, feedID, guid).Scan(&exists)
+	return exists, err
+}
+
+func (r *Repository) AddItem(feedID int64, guid, title string) error {
+	_, err := r.db.Exec(`INSERT OR IGNORE INTO feed_items (feed_id, guid, title) VALUES (?, ?, ?)`, feedID, guid, title)
+	return err
+}
+
+func (r *Repository) UpdateLastChecked(id int64, checkedAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE feeds SET last_checked_at = ? WHERE id = ?`, checkedAt, id)
+	return err
+}
@@ -2,29 +2,40 @@ package http
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/rs/cors"
 	"go.opentelemetry.io/otel"
 	"magnet-feed-sync/app/config"
+	"magnet-feed-sync/app/release"
 	"magnet-feed-sync/app/tracker"
 	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
 )
 
+const maxMultipartOverhead = 1024 * 1024
+
 type TaskCreator interface {
-	CreateFromURL(ctx context.Context, url, location string) (*tracker.FileMetadata, error)
+	CreateFromURLOnInstance(ctx context.Context, url, location, instance string) (*tracker.FileMetadata, error)
 	DownloadNow(ctx context.Context, source, location string) error
+	DownloadTorrentFile(ctx context.Context, data []byte, location string) error
 	RemoveTask(id string) error
 	UpdateTaskLocation(id, location string) error
+	UpdateTaskPolicy(id string, policy types.UpdatePolicy) error
+	UpdateTaskCategory(id, category string) error
 	CheckFileForUpdates(ctx context.Context, fileId string)
 	CheckForUpdates(ctx context.Context)
+	Search(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error)
 }
 
 type FileStore interface {
@@ -32,6 +43,13 @@ type FileStore interface {
 	GetById(id string) (*tracker.FileMetadata, error)
 }
 
+type FeedManager interface {
+	Subscribe(feed *types.Feed) error
+	Feeds() ([]*types.Feed, error)
+	Unsubscribe(id int64) error
+	CheckFeeds(ctx context.Context)
+}
+
 type DownloadClient interface {
 	SetLocation(taskID, location string) error
 	GetLocations() []types.Location
@@ -39,11 +57,16 @@ type DownloadClient interface {
 	GetDefaultLocation() string
 }
 
+type instanceLookup interface {
+	GetHashByMagnetOn(instance, magnet string) (string, error)
+}
+
 type Client struct {
 	config         config.HttpConfig
 	store          FileStore
 	taskCreator    TaskCreator
 	downloadClient DownloadClient
+	feeds          FeedManager
 }
 
 func NewClient(
@@ -51,10 +74,12 @@ func NewClient(
 	store FileStore,
 	taskCreator TaskCreator,
 	downloadClient DownloadClient,
+	feeds FeedManager,
 ) *Client {
 	return &Client{
 		taskCreator:    taskCreator,
 		downloadClient: downloadClient,
+		feeds:          feeds,
 		config:         cfg,
 		store:          store,
 	}
@@ -65,9 +90,15 @@ func (c *Client) Start(ctx context.Context, done chan struct{}) {
 	mux.HandleFunc("GET /api/files", c.handleFiles)
 	mux.HandleFunc("POST /api/files", c.handleCreateFile)
 	mux.HandleFunc("POST /api/downloads", c.handleCreateDownload)
+	mux.HandleFunc("PATCH /api/files/{fileId}", c.handleUpdateFile)
 	mux.HandleFunc("PATCH /api/files/{fileId}/refresh", c.handleRefreshFile)
 	mux.HandleFunc("PATCH /api/files/refresh", c.handleRefreshAllFiles)
 	mux.HandleFunc("DELETE /api/files/{fileId}", c.handleRemoveFiles)
+	mux.HandleFunc("GET /api/search", c.handleSearch)
+	mux.HandleFunc("GET /api/feeds", c.handleFeeds)
+	mux.HandleFunc("POST /api/feeds", c.handleCreateFeed)
+	mux.HandleFunc("PATCH /api/feeds/refresh", c.handleRefreshFeeds)
+	mux.HandleFunc("DELETE /api/feeds/{feedId}", c.handleRemoveFeed)
 	mux.HandleFunc("GET /api/file-locations", c.handleGetFileLocations)
 	mux.HandleFunc("POST /api/file-locations", c.handleSetFileLocation)
 	mux.HandleFunc("GET /api/health", c.healthHandler)
@@ -103,14 +134,52 @@ func (c *Client) Start(ctx context.Context, done chan struct{}) {
 }
 
 type FileMetadataResponse struct {
-	ID               string    `json:"id"`
-	OriginalUrl      string    `json:"originalUrl"`
-	Name             string    `json:"name"`
-	LastComment      string    `json:"lastComment"`
-	LastSyncAt       time.Time `json:"lastSyncAt"`
-	Magnet           string    `json:"magnet"`
-	TorrentUpdatedAt time.Time `json:"torrentUpdatedAt"`
-	Location         string    `json:"location"`
+	ID               string                 `json:"id"`
+	OriginalUrl      string                 `json:"originalUrl"`
+	Name             string                 `json:"name"`
+	LastComment      string                 `json:"lastComment"`
+	LastSyncAt       time.Time              `json:"lastSyncAt"`
+	Magnet           string                 `json:"magnet"`
+	MagnetInfo       *MagnetResponse        `json:"magnetInfo,omitempty"`
+	TorrentUpdatedAt time.Time              `json:"torrentUpdatedAt"`
+	Location         string                 `json:"location"`
+	Category         string                 `json:"category"`
+	UpdatePolicy     string                 `json:"updatePolicy"`
+	Instance         string                 `json:"instance,omitempty"`
+	Torrent          TorrentInfoResponse    `json:"torrent"`
+	Episodes         EpisodesResponse       `json:"episodes"`
+	Download         DownloadStatusResponse `json:"download"`
+	CompletedAt      *time.Time             `json:"completedAt,omitempty"`
+}
+
+type MagnetResponse struct {
+	InfoHash   string   `json:"infoHash,omitempty"`
+	InfoHashV2 string   `json:"infoHashV2,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	Trackers   []string `json:"trackers,omitempty"`
+	Size       int64    `json:"size,omitempty"`
+	WebSeeds   []string `json:"webSeeds,omitempty"`
+}
+
+type EpisodesResponse struct {
+	Current int `json:"current"`
+	Total   int `json:"total"`
+}
+
+type TorrentInfoResponse struct {
+	Size      int64  `json:"size"`
+	Seeders   int    `json:"seeders"`
+	Leechers  int    `json:"leechers"`
+	Category  string `json:"category"`
+	FileCount int    `json:"fileCount"`
+}
+
+type DownloadStatusResponse struct {
+	State       string     `json:"state"`
+	Progress    float64    `json:"progress"`
+	Size        int64      `json:"size"`
+	ETA         int64      `json:"eta"`
+	CompletedAt *time.Time `json:"completedAt,omitempty"`
 }
 
 func (c *Client) handleFiles(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +209,7 @@ func (c *Client) handleFiles(w http.ResponseWriter, r *http.Request) {
 }
 
 func toResponse(f *tracker.FileMetadata) FileMetadataResponse {
-	return FileMetadataResponse{
+	resp := FileMetadataResponse{
 		ID:               f.ID,
 		Name:             f.Name,
 		Magnet:           f.Magnet,
@@ -149,12 +218,46 @@ func toResponse(f *tracker.FileMetadata) FileMetadataResponse {
 		OriginalUrl:      f.OriginalUrl,
 		LastComment:      f.LastComment,
 		TorrentUpdatedAt: f.TorrentUpdatedAt,
+		Category:         f.Category,
+		UpdatePolicy:     string(f.UpdatePolicy),
+		Instance:         f.Instance,
+		Torrent:          TorrentInfoResponse(f.Torrent),
+		Episodes:         EpisodesResponse(f.Episodes),
+		Download:         toDownloadStatusResponse(f.Download),
 	}
+	if !f.CompletedAt.IsZero() {
+		resp.CompletedAt = &f.CompletedAt
+	}
+	if magnet, err := utils.ParseMagnet(f.Magnet); err == nil {
+		resp.MagnetInfo = &MagnetResponse{
+			InfoHash:   magnet.InfoHash,
+			InfoHashV2: magnet.InfoHashV2,
+			Name:       magnet.Name,
+			Trackers:   magnet.Trackers,
+			Size:       magnet.Size,
+			WebSeeds:   magnet.WebSeeds,
+		}
+	}
+	return resp
+}
+
+func toDownloadStatusResponse(status types.TorrentStatus) DownloadStatusResponse {
+	resp := DownloadStatusResponse{
+		State:    string(status.State),
+		Progress: status.Progress,
+		Size:     status.Size,
+		ETA:      status.ETA,
+	}
+	if !status.CompletedAt.IsZero() {
+		resp.CompletedAt = &status.CompletedAt
+	}
+	return resp
 }
 
 type CreateFileRequest struct {
 	URL      string `json:"url"`
 	Location string `json:"location"`
+	Instance string `json:"instance"`
 }
 
 func (c *Client) handleCreateFile(w http.ResponseWriter, r *http.Request) {
@@ -172,13 +275,17 @@ func (c *Client) handleCreateFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	metadata, err := c.taskCreator.CreateFromURL(ctx, req.URL, req.Location)
+	metadata, err := c.taskCreator.CreateFromURLOnInstance(ctx, req.URL, req.Location, req.Instance)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create file from URL", "error", err)
 		if errors.Is(err, tracker.ErrProviderNotFound) {
 			http.Error(w, "unsupported URL", http.StatusBadRequest)
 			return
 		}
+		if errors.Is(err, types.ErrUnknownInstance) {
+			http.Error(w, "unknown download client instance", http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "failed to create file from URL", http.StatusInternalServerError)
 		return
 	}
@@ -199,6 +306,11 @@ func (c *Client) handleCreateDownload(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("http").Start(r.Context(), "POST /api/downloads")
 	defer span.End()
 
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		c.handleUploadTorrent(ctx, w, r)
+		return
+	}
+
 	var req CreateDownloadRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "invalid request body", http.StatusBadRequest)
@@ -221,6 +333,61 @@ func (c *Client) handleCreateDownload(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	writeDownloadCreated(ctx, w)
+}
+
+func (c *Client) handleUploadTorrent(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, utils.MaxTorrentFileSize+maxMultipartOverhead)
+	if err := r.ParseMultipartForm(utils.MaxTorrentFileSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			http.Error(w, "torrent file is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "invalid multipart body", http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("torrent")
+	if err != nil {
+		http.Error(w, "torrent file is required", http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			slog.ErrorContext(ctx, "failed to close uploaded file", "error", err)
+		}
+	}()
+
+	data, err := io.ReadAll(io.LimitReader(file, utils.MaxTorrentFileSize+1))
+	if err != nil {
+		http.Error(w, "failed to read torrent file", http.StatusBadRequest)
+		return
+	}
+	if len(data) > utils.MaxTorrentFileSize {
+		http.Error(w, "torrent file is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if !utils.IsTorrentFile(data) {
+		http.Error(w, "file is not a valid torrent", http.StatusBadRequest)
+		return
+	}
+
+	location := r.FormValue("location")
+	if location == "" {
+		location = c.downloadClient.GetDefaultLocation()
+	}
+
+	if err := c.taskCreator.DownloadTorrentFile(ctx, data, location); err != nil {
+		slog.ErrorContext(ctx, "failed to add torrent file", "error", err)
+		http.Error(w, "failed to create download", http.StatusInternalServerError)
+		return
+	}
+
+	writeDownloadCreated(ctx, w)
+}
+
+func writeDownloadCreated(ctx context.Context, w http.ResponseWriter) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(map[string]string{"status": "ok"}); err != nil {
@@ -252,6 +419,78 @@ func (c *Client) handleRemoveFiles(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+type UpdateFileRequest struct {
+	UpdatePolicy *string `json:"updatePolicy"`
+	Category     *string `json:"category"`
+}
+
+func (c *Client) handleUpdateFile(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http").Start(r.Context(), "PATCH /api/files/{fileId}")
+	defer span.End()
+
+	fileId := r.PathValue("fileId")
+
+	var req UpdateFileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var policy types.UpdatePolicy
+	if req.UpdatePolicy != nil {
+		var err error
+		if policy, err = types.ParseUpdatePolicy(*req.UpdatePolicy); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if req.UpdatePolicy != nil {
+		if err := c.taskCreator.UpdateTaskPolicy(fileId, policy); err != nil {
+			slog.ErrorContext(ctx, "failed to update file policy", "error", err)
+			writeUpdateError(w, err)
+			return
+		}
+	}
+
+	if req.Category != nil {
+		if err := c.taskCreator.UpdateTaskCategory(fileId, strings.TrimSpace(*req.Category)); err != nil {
+			slog.ErrorContext(ctx, "failed to update file category", "error", err)
+			writeUpdateError(w, err)
+			return
+		}
+	}
+
+	file, err := c.store.GetById(fileId)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get file by id", "error", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "file not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to get file", http.StatusInternalServerError)
+		return
+	}
+
+	if file == nil {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(toResponse(file)); err != nil {
+		slog.ErrorContext(ctx, "failed to encode response", "error", err)
+	}
+}
+
+func writeUpdateError(w http.ResponseWriter, err error) {
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "file not found", http.StatusNotFound)
+		return
+	}
+	http.Error(w, "failed to update file", http.StatusInternalServerError)
+}
+
 func (c *Client) handleRefreshFile(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("http").Start(r.Context(), "PATCH /api/files/{fileId}/refresh")
 	defer span.End()
@@ -275,6 +514,229 @@ func (c *Client) handleRefreshAllFiles(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+type FeedResponse struct {
+	ID            int64      `json:"id"`
+	URL           string     `json:"url"`
+	Name          string     `json:"name"`
+	Include       string     `json:"include"`
+	Exclude       string     `json:"exclude"`
+	Accept        string     `json:"accept"`
+	Reject        string     `json:"reject"`
+	Location      string     `json:"location"`
+	Mode          string     `json:"mode"`
+	LastCheckedAt *time.Time `json:"lastCheckedAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+}
+
+func toFeedResponse(f *types.Feed) FeedResponse {
+	resp := FeedResponse{
+		ID:        f.ID,
+		URL:       utils.RedactURL(f.URL),
+		Name:      f.Name,
+		Include:   f.Include,
+		Exclude:   f.Exclude,
+		Accept:    f.Accept,
+		Reject:    f.Reject,
+		Location:  f.Location,
+		Mode:      string(f.Mode),
+		CreatedAt: f.CreatedAt,
+	}
+	if !f.LastCheckedAt.IsZero() {
+		resp.LastCheckedAt = &f.LastCheckedAt
+	}
+	return resp
+}
+
+func (c *Client) handleFeeds(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http").Start(r.Context(), "GET /api/feeds")
+	defer span.End()
+
+	feeds, err := c.feeds.Feeds()
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get feeds", "error", err)
+		http.Error(w, "failed to get feeds", http.StatusInternalServerError)
+		return
+	}
+
+	resp := make([]FeedResponse, 0, len(feeds))
+	for _, feed := range feeds {
+		resp = append(resp, toFeedResponse(feed))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(ctx, "failed to encode feeds", "error", err)
+	}
+}
+
+type CreateFeedRequest struct {
+	URL      string `json:"url"`
+	Name     string `json:"name"`
+	Include  string `json:"include"`
+	Exclude  string `json:"exclude"`
+	Accept   string `json:"accept"`
+	Reject   string `json:"reject"`
+	Location string `json:"location"`
+	Mode     string `json:"mode"`
+}
+
+func (c *Client) handleCreateFeed(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http").Start(r.Context(), "POST /api/feeds")
+	defer span.End()
+
+	var req CreateFeedRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	feed := &types.Feed{
+		URL:      strings.TrimSpace(req.URL),
+		Name:     req.Name,
+		Include:  req.Include,
+		Exclude:  req.Exclude,
+		Accept:   req.Accept,
+		Reject:   req.Reject,
+		Location: req.Location,
+		Mode:     types.FeedMode(req.Mode),
+	}
+	if feed.Location == "" {
+		feed.Location = c.downloadClient.GetDefaultLocation()
+	}
+	if feed.Mode == "" {
+		feed.Mode = types.FeedModeOnce
+	}
+
+	if err := c.feeds.Subscribe(feed); err != nil {
+		slog.ErrorContext(ctx, "failed to subscribe to feed", "error", err)
+		switch {
+		case errors.Is(err, types.ErrInvalidFeed):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, types.ErrFeedExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "failed to subscribe to feed", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if err := json.NewEncoder(w).Encode(toFeedResponse(feed)); err != nil {
+		slog.ErrorContext(ctx, "failed to encode response", "error", err)
+	}
+}
+
+func (c *Client) handleRefreshFeeds(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http").Start(r.Context(), "PATCH /api/feeds/refresh")
+	defer span.End()
+
+	c.feeds.CheckFeeds(context.WithoutCancel(ctx))
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (c *Client) handleRemoveFeed(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http").Start(r.Context(), "DELETE /api/feeds/{feedId}")
+	defer span.End()
+
+	feedID, err := strconv.ParseInt(r.PathValue("feedId"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid feed id", http.StatusBadRequest)
+		return
+	}
+
+	if err := c.feeds.Unsubscribe(feedID); err != nil {
+		slog.ErrorContext(ctx, "failed to remove feed", "error", err)
+		if errors.Is(err, types.ErrFeedNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "failed to remove feed", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+type SearchResultResponse struct {
+	Title       string              `json:"title"`
+	Indexer     string              `json:"indexer"`
+	TrackerURL  string              `json:"trackerUrl,omitempty"`
+	Magnet      string              `json:"magnet,omitempty"`
+	Link        string              `json:"link,omitempty"`
+	Torrent     TorrentInfoResponse `json:"torrent"`
+	PublishedAt *time.Time          `json:"publishedAt,omitempty"`
+	Trackable   bool                `json:"trackable"`
+}
+
+func (c *Client) handleSearch(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("http").Start(r.Context(), "GET /api/search")
+	defer span.End()
+
+	params := r.URL.Query()
+	query := types.SearchQuery{
+		Query:    strings.TrimSpace(params.Get("q")),
+		Indexer:  params.Get("indexer"),
+		Category: params.Get("cat"),
+		Accept:   params.Get("accept"),
+		Reject:   params.Get("reject"),
+	}
+	if query.Query == "" {
+		http.Error(w, "q is required", http.StatusBadRequest)
+		return
+	}
+	if _, err := release.ParseRules(query.Accept, query.Reject); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if rawLimit := params.Get("limit"); rawLimit != "" {
+		limit, err := strconv.Atoi(rawLimit)
+		if err != nil || limit <= 0 {
+			http.Error(w, "limit must be a positive number", http.StatusBadRequest)
+			return
+		}
+		query.Limit = limit
+	}
+
+	results, err := c.taskCreator.Search(ctx, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to search", "error", err)
+		if errors.Is(err, types.ErrSearchNotConfigured) {
+			http.Error(w, err.Error(), http.StatusNotImplemented)
+			return
+		}
+		http.Error(w, "failed to search", http.StatusBadGateway)
+		return
+	}
+
+	resp := make([]SearchResultResponse, 0, len(results))
+	for _, result := range results {
+		resp = append(resp, toSearchResultResponse(result))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		slog.ErrorContext(ctx, "failed to encode search results", "error", err)
+	}
+}
+
+func toSearchResultResponse(result types.SearchResult) SearchResultResponse {
+	resp := SearchResultResponse{
+		Title:      result.Title,
+		Indexer:    result.Indexer,
+		TrackerURL: result.TrackerURL,
+		Magnet:     result.Magnet,
+		Link:       result.Link,
+		Torrent:    TorrentInfoResponse(result.Torrent),
+		Trackable:  result.Trackable,
+	}
+	if !result.PublishedAt.IsZero() {
+		resp.PublishedAt = &result.PublishedAt
+	}
+	return resp
+}
+
 func (c *Client) handleGetFileLocations(w http.ResponseWriter, r *http.Request) {
 	ctx, span := otel.Tracer("http").Start(r.Context(), "GET /api/file-locations")
 	defer span.End()
@@ -289,6 +751,13 @@ func (c *Client) handleGetFileLocations(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+func (c *Client) findTorrent(file *tracker.FileMetadata) (string, error) {
+	if lookup, ok := c.downloadClient.(instanceLookup); ok && file.Instance != "" {
+		return lookup.GetHashByMagnetOn(file.Instance, file.Magnet)
+	}
+	return c.downloadClient.GetHashByMagnet(file.Magnet)
+}
+
 type SetFileLocationRequest struct {
 	FileId   string `json:"fileId"`
 	Location string `json:"location"`
@@ -320,7 +789,7 @@ func (c *Client) handleSetFileLocation(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hash, err := c.downloadClient.GetHashByMagnet(file.Magnet)
+	hash, err := c.findTorrent(file)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to get hash by magnet", "error", err)
 		http.Error(w, err.Error(), http.StatusInternalServerError)
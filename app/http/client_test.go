@@ -3,8 +3,11 @@ package http
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -26,14 +29,25 @@ type mockTaskCreator struct {
 	lastDownloadSource   string
 	lastDownloadLocation string
 	downloadCalls        int
+	lastInstance         string
 	returnMeta           *tracker.FileMetadata
 	returnErr            error
 	downloadErr          error
+	lastPolicyID         string
+	lastPolicy           types.UpdatePolicy
+	policyErr            error
+	lastCategoryID       string
+	lastCategory         string
+	lastTorrentFile      []byte
+	lastSearch           types.SearchQuery
+	searchResults        []types.SearchResult
+	searchErr            error
 }
 
-func (m *mockTaskCreator) CreateFromURL(_ context.Context, url, location string) (*tracker.FileMetadata, error) {
+func (m *mockTaskCreator) CreateFromURLOnInstance(_ context.Context, url, location, instance string) (*tracker.FileMetadata, error) {
 	m.lastURL = url
 	m.lastLocation = location
+	m.lastInstance = instance
 	return m.returnMeta, m.returnErr
 }
 
@@ -44,11 +58,35 @@ func (m *mockTaskCreator) DownloadNow(_ context.Context, source, location string
 	return m.downloadErr
 }
 
+func (m *mockTaskCreator) DownloadTorrentFile(_ context.Context, data []byte, location string) error {
+	m.downloadCalls++
+	m.lastTorrentFile = data
+	m.lastDownloadLocation = location
+	return m.downloadErr
+}
+
 func (m *mockTaskCreator) RemoveTask(id string) error                      { return nil }
 func (m *mockTaskCreator) UpdateTaskLocation(id, location string) error    { return nil }
 func (m *mockTaskCreator) CheckFileForUpdates(_ context.Context, _ string) {}
 func (m *mockTaskCreator) CheckForUpdates(_ context.Context)               {}
 
+func (m *mockTaskCreator) Search(_ context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	m.lastSearch = query
+	return m.searchResults, m.searchErr
+}
+
+func (m *mockTaskCreator) UpdateTaskCategory(id, category string) error {
+	m.lastCategoryID = id
+	m.lastCategory = category
+	return nil
+}
+
+func (m *mockTaskCreator) UpdateTaskPolicy(id string, policy types.UpdatePolicy) error {
+	m.lastPolicyID = id
+	m.lastPolicy = policy
+	return m.policyErr
+}
+
 type mockFileStore struct {
 	existingFile *tracker.FileMetadata
 	getByIdErr   error
@@ -59,6 +97,33 @@ func (m *mockFileStore) GetById(id string) (*tracker.FileMetadata, error) {
 	return m.existingFile, m.getByIdErr
 }
 
+type mockFeedManager struct {
+	feeds        []*types.Feed
+	subscribed   *types.Feed
+	subscribeErr error
+	removedID    int64
+	removeErr    error
+	checked      bool
+}
+
+func (m *mockFeedManager) Subscribe(feed *types.Feed) error {
+	m.subscribed = feed
+	if m.subscribeErr != nil {
+		return m.subscribeErr
+	}
+	feed.ID = 1
+	return nil
+}
+
+func (m *mockFeedManager) Feeds() ([]*types.Feed, error) { return m.feeds, nil }
+
+func (m *mockFeedManager) Unsubscribe(id int64) error {
+	m.removedID = id
+	return m.removeErr
+}
+
+func (m *mockFeedManager) CheckFeeds(_ context.Context) { m.checked = true }
+
 type mockDownloadClient struct {
 	defaultLocation string
 }
@@ -83,12 +148,15 @@ func TestHandleCreateFile_WithURL(t *testing.T) {
 			Location:         "/downloads/tv shows",
 			LastSyncAt:       now,
 			TorrentUpdatedAt: now,
+			Torrent:          types.TorrentInfo{Size: 52428800000, Seeders: 150, Leechers: 30, Category: "TV/UHD", FileCount: 8},
+			Episodes:         types.EpisodeProgress{Current: 10, Total: 10},
+			CompletedAt:      now,
 		},
 	}
 	store := &mockFileStore{}
 	dlClient := &mockDownloadClient{defaultLocation: "/downloads/tv shows"}
 
-	c := NewClient(config.HttpConfig{}, store, creator, dlClient)
+	c := NewClient(config.HttpConfig{}, store, creator, dlClient, nil)
 
 	body := `{"url":"https://rutracker.org/forum/viewtopic.php?t=6810475","location":"/downloads/tv shows"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/files", bytes.NewBufferString(body))
@@ -107,10 +175,15 @@ func TestHandleCreateFile_WithURL(t *testing.T) {
 	assert.Equal(t, "Severance S02 2160p", resp.Name)
 	assert.Equal(t, "magnet:?xt=urn:btih:abc123", resp.Magnet)
 	assert.Equal(t, "/downloads/tv shows", resp.Location)
+	assert.Equal(t, TorrentInfoResponse{Size: 52428800000, Seeders: 150, Leechers: 30, Category: "TV/UHD", FileCount: 8}, resp.Torrent)
+	assert.Equal(t, EpisodesResponse{Current: 10, Total: 10}, resp.Episodes)
+	require.NotNil(t, resp.MagnetInfo)
+	assert.Equal(t, "abc123", resp.MagnetInfo.InfoHash)
+	require.NotNil(t, resp.CompletedAt)
 }
 
 func TestHandleCreateFile_MissingURL(t *testing.T) {
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{})
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, nil)
 
 	body := `{"location":"/downloads/movies"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/files", bytes.NewBufferString(body))
@@ -123,7 +196,7 @@ func TestHandleCreateFile_MissingURL(t *testing.T) {
 }
 
 func TestHandleCreateFile_InvalidBody(t *testing.T) {
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{})
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/files", bytes.NewBufferString("not json"))
 	req.Header.Set("Content-Type", "application/json")
@@ -138,7 +211,7 @@ func TestHandleCreateFile_URLProviderNotFound(t *testing.T) {
 	creator := &mockTaskCreator{
 		returnErr: fmt.Errorf("%w for url: https://unknown.com", tracker.ErrProviderNotFound),
 	}
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{})
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
 
 	body := `{"url":"https://unknown.com"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/files", bytes.NewBufferString(body))
@@ -150,11 +223,44 @@ func TestHandleCreateFile_URLProviderNotFound(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestHandleCreateFile_PinnedInstance(t *testing.T) {
+	creator := &mockTaskCreator{returnMeta: &tracker.FileMetadata{ID: "123", Instance: "seedbox"}}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
+
+	body := `{"url":"https://rutracker.org/forum/viewtopic.php?t=123","instance":"seedbox"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/files", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c.handleCreateFile(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "seedbox", creator.lastInstance)
+
+	var resp FileMetadataResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "seedbox", resp.Instance)
+}
+
+func TestHandleCreateFile_UnknownInstance(t *testing.T) {
+	creator := &mockTaskCreator{returnErr: fmt.Errorf("%w: %q", types.ErrUnknownInstance, "nope")}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
+
+	body := `{"url":"https://rutracker.org/forum/viewtopic.php?t=123","instance":"nope"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/files", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	c.handleCreateFile(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestHandleCreateFile_URLServerError(t *testing.T) {
 	creator := &mockTaskCreator{
 		returnErr: fmt.Errorf("network timeout"),
 	}
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{})
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
 
 	body := `{"url":"https://rutracker.org/forum/viewtopic.php?t=123"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/files", bytes.NewBufferString(body))
@@ -170,7 +276,7 @@ func TestHandleCreateDownload_Magnet(t *testing.T) {
 	creator := &mockTaskCreator{}
 	dlClient := &mockDownloadClient{defaultLocation: "/downloads/default"}
 
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient)
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient, nil)
 
 	body := `{"source":"magnet:?xt=urn:btih:abc123","location":"/downloads/movies"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/downloads", bytes.NewBufferString(body))
@@ -189,11 +295,90 @@ func TestHandleCreateDownload_Magnet(t *testing.T) {
 	assert.Equal(t, "ok", resp["status"])
 }
 
+func newTorrentUploadRequest(t *testing.T, data []byte, location string) *http.Request {
+	t.Helper()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("torrent", "episode.torrent")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	if location != "" {
+		require.NoError(t, writer.WriteField("location", location))
+	}
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/downloads", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestHandleCreateDownload_TorrentUpload(t *testing.T) {
+	creator := &mockTaskCreator{}
+	dlClient := &mockDownloadClient{defaultLocation: "/downloads/default"}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient, nil)
+
+	data := []byte("d8:announce3:url4:infod4:name4:testee")
+	w := httptest.NewRecorder()
+
+	c.handleCreateDownload(w, newTorrentUploadRequest(t, data, "/downloads/movies"))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, 1, creator.downloadCalls)
+	assert.Equal(t, data, creator.lastTorrentFile)
+	assert.Equal(t, "/downloads/movies", creator.lastDownloadLocation)
+}
+
+func TestHandleCreateDownload_TorrentUploadDefaultLocation(t *testing.T) {
+	creator := &mockTaskCreator{}
+	dlClient := &mockDownloadClient{defaultLocation: "/downloads/default"}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient, nil)
+
+	w := httptest.NewRecorder()
+
+	c.handleCreateDownload(w, newTorrentUploadRequest(t, []byte("d4:infod4:name4:testee"), ""))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "/downloads/default", creator.lastDownloadLocation)
+}
+
+func TestHandleCreateDownload_TorrentUploadInvalidFile(t *testing.T) {
+	creator := &mockTaskCreator{}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
+
+	w := httptest.NewRecorder()
+
+	c.handleCreateDownload(w, newTorrentUploadRequest(t, []byte("<html>login required</html>"), ""))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, creator.downloadCalls)
+}
+
+func TestHandleCreateDownload_TorrentUploadMissingFile(t *testing.T) {
+	creator := &mockTaskCreator{}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.NoError(t, writer.WriteField("location", "/downloads/movies"))
+	require.NoError(t, writer.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/downloads", &body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	w := httptest.NewRecorder()
+
+	c.handleCreateDownload(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, 0, creator.downloadCalls)
+}
+
 func TestHandleCreateDownload_HTTPSource(t *testing.T) {
 	creator := &mockTaskCreator{}
 	dlClient := &mockDownloadClient{defaultLocation: "/downloads/default"}
 
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient)
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient, nil)
 
 	body := `{"source":"https://jackett.example.com/dl/tpb?apikey=secret&file=x.torrent"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/downloads", bytes.NewBufferString(body))
@@ -212,7 +397,7 @@ func TestHandleCreateDownload_PlainHTTPSource(t *testing.T) {
 	creator := &mockTaskCreator{}
 	dlClient := &mockDownloadClient{defaultLocation: "/downloads/default"}
 
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient)
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient, nil)
 
 	body := `{"source":"http://tracker.local/dl/x.torrent"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/downloads", bytes.NewBufferString(body))
@@ -229,7 +414,7 @@ func TestHandleCreateDownload_PlainHTTPSource(t *testing.T) {
 
 func TestHandleCreateDownload_EmptySource(t *testing.T) {
 	creator := &mockTaskCreator{}
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{})
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
 
 	body := `{"location":"/downloads/movies"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/downloads", bytes.NewBufferString(body))
@@ -244,7 +429,7 @@ func TestHandleCreateDownload_EmptySource(t *testing.T) {
 
 func TestHandleCreateDownload_GarbageSource(t *testing.T) {
 	creator := &mockTaskCreator{}
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{})
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
 
 	body := `{"source":"ftp://not-supported/file.torrent"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/downloads", bytes.NewBufferString(body))
@@ -258,7 +443,7 @@ func TestHandleCreateDownload_GarbageSource(t *testing.T) {
 }
 
 func TestHandleCreateDownload_InvalidBody(t *testing.T) {
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{})
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/api/downloads", bytes.NewBufferString("not json"))
 	req.Header.Set("Content-Type", "application/json")
@@ -273,7 +458,7 @@ func TestHandleCreateDownload_DownloadError(t *testing.T) {
 	creator := &mockTaskCreator{downloadErr: fmt.Errorf("qbittorrent unreachable")}
 	dlClient := &mockDownloadClient{defaultLocation: "/downloads/default"}
 
-	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient)
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, dlClient, nil)
 
 	body := `{"source":"magnet:?xt=urn:btih:abc123"}`
 	req := httptest.NewRequest(http.MethodPost, "/api/downloads", bytes.NewBufferString(body))
@@ -287,6 +472,89 @@ func TestHandleCreateDownload_DownloadError(t *testing.T) {
 	assert.Equal(t, "/downloads/default", creator.lastDownloadLocation)
 }
 
+func TestHandleUpdateFile_UpdatePolicy(t *testing.T) {
+	creator := &mockTaskCreator{}
+	store := &mockFileStore{existingFile: &tracker.FileMetadata{
+		ID:           "6810475",
+		Name:         "Severance S02 2160p",
+		UpdatePolicy: types.UpdatePolicyRemove,
+	}}
+	c := NewClient(config.HttpConfig{}, store, creator, &mockDownloadClient{}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/files/6810475", bytes.NewBufferString(`{"updatePolicy":"remove"}`))
+	req.SetPathValue("fileId", "6810475")
+	w := httptest.NewRecorder()
+
+	c.handleUpdateFile(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "6810475", creator.lastPolicyID)
+	assert.Equal(t, types.UpdatePolicyRemove, creator.lastPolicy)
+
+	var resp FileMetadataResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "remove", resp.UpdatePolicy)
+}
+
+func TestToDownloadStatusResponse(t *testing.T) {
+	completedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+
+	resp := toDownloadStatusResponse(types.TorrentStatus{State: types.TorrentStateDownloading, Progress: 0.5, Size: 1024, ETA: 30})
+	assert.Equal(t, DownloadStatusResponse{State: "downloading", Progress: 0.5, Size: 1024, ETA: 30}, resp)
+
+	resp = toDownloadStatusResponse(types.TorrentStatus{State: types.TorrentStateCompleted, Progress: 1, Size: 1024, CompletedAt: completedAt})
+	require.NotNil(t, resp.CompletedAt)
+	assert.Equal(t, completedAt, *resp.CompletedAt)
+}
+
+func TestHandleUpdateFile_Category(t *testing.T) {
+	creator := &mockTaskCreator{}
+	store := &mockFileStore{existingFile: &tracker.FileMetadata{ID: "6810475", Category: "tv"}}
+	c := NewClient(config.HttpConfig{}, store, creator, &mockDownloadClient{}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/files/6810475", bytes.NewBufferString(`{"category":" tv "}`))
+	req.SetPathValue("fileId", "6810475")
+	w := httptest.NewRecorder()
+
+	c.handleUpdateFile(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "6810475", creator.lastCategoryID)
+	assert.Equal(t, "tv", creator.lastCategory)
+	assert.Empty(t, creator.lastPolicyID, "policy should not change when omitted")
+
+	var resp FileMetadataResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, "tv", resp.Category)
+}
+
+func TestHandleUpdateFile_InvalidPolicy(t *testing.T) {
+	creator := &mockTaskCreator{}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/files/6810475", bytes.NewBufferString(`{"updatePolicy":"delete"}`))
+	req.SetPathValue("fileId", "6810475")
+	w := httptest.NewRecorder()
+
+	c.handleUpdateFile(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Empty(t, creator.lastPolicyID)
+}
+
+func TestHandleUpdateFile_NotFound(t *testing.T) {
+	creator := &mockTaskCreator{policyErr: fmt.Errorf("get task: %w", sql.ErrNoRows)}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
+
+	req := httptest.NewRequest(http.MethodPatch, "/api/files/missing", bytes.NewBufferString(`{"updatePolicy":"keep"}`))
+	req.SetPathValue("fileId", "missing")
+	w := httptest.NewRecorder()
+
+	c.handleUpdateFile(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
 	t.Helper()
 	orig := otel.GetTracerProvider()
@@ -313,6 +581,7 @@ func TestHTTPHandlers_CreateTracingSpans(t *testing.T) {
 		{"handleCreateDownload", http.MethodPost, "/api/downloads", func(c *Client) http.HandlerFunc { return c.handleCreateDownload }, "POST /api/downloads"},
 		{"handleGetFileLocations", http.MethodGet, "/api/file-locations", func(c *Client) http.HandlerFunc { return c.handleGetFileLocations }, "GET /api/file-locations"},
 		{"healthHandler", http.MethodGet, "/api/health", func(c *Client) http.HandlerFunc { return c.healthHandler }, "GET /api/health"},
+		{"handleUpdateFile", http.MethodPatch, "/api/files/6810475", func(c *Client) http.HandlerFunc { return c.handleUpdateFile }, "PATCH /api/files/{fileId}"},
 		{"handleRefreshAllFiles", http.MethodPatch, "/api/files/refresh", func(c *Client) http.HandlerFunc { return c.handleRefreshAllFiles }, "PATCH /api/files/refresh"},
 	}
 
@@ -323,7 +592,7 @@ func TestHTTPHandlers_CreateTracingSpans(t *testing.T) {
 			store := &mockFileStore{}
 			creator := &mockTaskCreator{}
 			dlClient := &mockDownloadClient{}
-			c := NewClient(config.HttpConfig{}, store, creator, dlClient)
+			c := NewClient(config.HttpConfig{}, store, creator, dlClient, nil)
 
 			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString("{}"))
 			req.Header.Set("Content-Type", "application/json")
@@ -349,10 +618,198 @@ func TestHTTPHandlers_NoopTracingNoCrash(t *testing.T) {
 	store := &mockFileStore{}
 	creator := &mockTaskCreator{}
 	dlClient := &mockDownloadClient{}
-	c := NewClient(config.HttpConfig{}, store, creator, dlClient)
+	c := NewClient(config.HttpConfig{}, store, creator, dlClient, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/api/files", nil)
 	w := httptest.NewRecorder()
 
 	c.handleFiles(w, req)
 }
+
+func TestHandleSearch(t *testing.T) {
+	publishedAt := time.Date(2025, 3, 10, 14, 0, 0, 0, time.UTC)
+	creator := &mockTaskCreator{
+		searchResults: []types.SearchResult{
+			{
+				Title:       "Severance S02 2160p",
+				Indexer:     "RuTracker",
+				TrackerURL:  "https://rutracker.org/forum/viewtopic.php?t=6810475",
+				Magnet:      "magnet:?xt=urn:btih:abc123",
+				Torrent:     types.TorrentInfo{Size: 52428800000, Seeders: 150, Leechers: 30, Category: "TV/UHD"},
+				PublishedAt: publishedAt,
+				Trackable:   true,
+			},
+			{Title: "Severance S02 720p", Indexer: "Rutor", Link: "https://jackett.example.com/dl/rutor/?path=x"},
+		},
+	}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, creator, &mockDownloadClient{}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/search?q=severance+s02&indexer=rutracker&cat=5000&limit=5", nil)
+	w := httptest.NewRecorder()
+
+	c.handleSearch(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, types.SearchQuery{Query: "severance s02", Indexer: "rutracker", Category: "5000", Limit: 5}, creator.lastSearch)
+
+	var resp []SearchResultResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 2)
+	assert.Equal(t, "RuTracker", resp[0].Indexer)
+	assert.Equal(t, "https://rutracker.org/forum/viewtopic.php?t=6810475", resp[0].TrackerURL)
+	assert.Equal(t, TorrentInfoResponse{Size: 52428800000, Seeders: 150, Leechers: 30, Category: "TV/UHD"}, resp[0].Torrent)
+	require.NotNil(t, resp[0].PublishedAt)
+	assert.True(t, publishedAt.Equal(*resp[0].PublishedAt))
+	assert.True(t, resp[0].Trackable)
+	assert.Equal(t, "https://jackett.example.com/dl/rutor/?path=x", resp[1].Link)
+	assert.Nil(t, resp[1].PublishedAt)
+	assert.False(t, resp[1].Trackable)
+}
+
+func TestHandleSearch_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		searchErr  error
+		wantStatus int
+	}{
+		{name: "missing query", target: "/api/search?indexer=rutracker", wantStatus: http.StatusBadRequest},
+		{name: "invalid limit", target: "/api/search?q=severance&limit=abc", wantStatus: http.StatusBadRequest},
+		{name: "invalid accept rules", target: "/api/search?q=severance&accept=quality:hd", wantStatus: http.StatusBadRequest},
+		{name: "invalid reject rules", target: "/api/search?q=severance&reject=episode:x", wantStatus: http.StatusBadRequest},
+		{name: "not configured", target: "/api/search?q=severance", searchErr: types.ErrSearchNotConfigured, wantStatus: http.StatusNotImplemented},
+		{name: "jackett error", target: "/api/search?q=severance", searchErr: errors.New("bad status: 500"), wantStatus: http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{searchErr: tt.searchErr}, &mockDownloadClient{}, nil)
+
+			w := httptest.NewRecorder()
+			c.handleSearch(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestHandleCreateFeed(t *testing.T) {
+	feeds := &mockFeedManager{}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{defaultLocation: "/downloads/tv shows"}, feeds)
+
+	body := `{"url":"https://rutracker.org/forum/feed.php?f=2366","name":"Series","include":"2160p","exclude":"cam","accept":"season:2","reject":"audio:avo","mode":"track"}`
+	w := httptest.NewRecorder()
+	c.handleCreateFeed(w, httptest.NewRequest(http.MethodPost, "/api/feeds", bytes.NewBufferString(body)))
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, &types.Feed{
+		ID:       1,
+		URL:      "https://rutracker.org/forum/feed.php?f=2366",
+		Name:     "Series",
+		Include:  "2160p",
+		Exclude:  "cam",
+		Accept:   "season:2",
+		Reject:   "audio:avo",
+		Location: "/downloads/tv shows",
+		Mode:     types.FeedModeTrack,
+	}, feeds.subscribed)
+
+	var resp FeedResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal(t, int64(1), resp.ID)
+	assert.Equal(t, "track", resp.Mode)
+	assert.Nil(t, resp.LastCheckedAt)
+}
+
+func TestHandleCreateFeed_DefaultsToOnce(t *testing.T) {
+	feeds := &mockFeedManager{}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, feeds)
+
+	w := httptest.NewRecorder()
+	c.handleCreateFeed(w, httptest.NewRequest(http.MethodPost, "/api/feeds", bytes.NewBufferString(`{"url":"https://example.com/rss","location":"/downloads/movies"}`)))
+
+	require.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, types.FeedModeOnce, feeds.subscribed.Mode)
+	assert.Equal(t, "/downloads/movies", feeds.subscribed.Location)
+}
+
+func TestHandleCreateFeed_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		err        error
+		wantStatus int
+	}{
+		{name: "invalid body", body: "{", wantStatus: http.StatusBadRequest},
+		{name: "invalid feed", body: `{"url":"https://example.com/rss","include":"("}`, err: fmt.Errorf("%w: invalid include filter", types.ErrInvalidFeed), wantStatus: http.StatusBadRequest},
+		{name: "duplicate", body: `{"url":"https://example.com/rss"}`, err: types.ErrFeedExists, wantStatus: http.StatusConflict},
+		{name: "store error", body: `{"url":"https://example.com/rss"}`, err: errors.New("disk full"), wantStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, &mockFeedManager{subscribeErr: tt.err})
+
+			w := httptest.NewRecorder()
+			c.handleCreateFeed(w, httptest.NewRequest(http.MethodPost, "/api/feeds", bytes.NewBufferString(tt.body)))
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestHandleFeeds(t *testing.T) {
+	checkedAt := time.Date(2026, 3, 12, 10, 0, 0, 0, time.UTC)
+	feeds := &mockFeedManager{feeds: []*types.Feed{
+		{ID: 1, URL: "https://example.com/rss", Mode: types.FeedModeOnce, LastCheckedAt: checkedAt},
+	}}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, feeds)
+
+	w := httptest.NewRecorder()
+	c.handleFeeds(w, httptest.NewRequest(http.MethodGet, "/api/feeds", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var resp []FeedResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Len(t, resp, 1)
+	assert.Equal(t, "https://example.com/rss", resp[0].URL)
+	require.NotNil(t, resp[0].LastCheckedAt)
+	assert.True(t, checkedAt.Equal(*resp[0].LastCheckedAt))
+}
+
+func TestHandleFeeds_RedactsAPIKey(t *testing.T) {
+	feeds := &mockFeedManager{feeds: []*types.Feed{
+		{ID: 1, URL: "https://jackett.example.com/api/v2.0/indexers/all/results/torznab/api?apikey=secret&t=search", Mode: types.FeedModeOnce},
+	}}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, feeds)
+
+	w := httptest.NewRecorder()
+	c.handleFeeds(w, httptest.NewRequest(http.MethodGet, "/api/feeds", nil))
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "secret")
+}
+
+func TestHandleRemoveFeed(t *testing.T) {
+	feeds := &mockFeedManager{}
+	c := NewClient(config.HttpConfig{}, &mockFileStore{}, &mockTaskCreator{}, &mockDownloadClient{}, feeds)
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/feeds/7", nil)
+	req.SetPathValue("feedId", "7")
+	w := httptest.NewRecorder()
+	c.handleRemoveFeed(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(7), feeds.removedID)
+
+	feeds.removeErr = types.ErrFeedNotFound
+	w = httptest.NewRecorder()
+	c.handleRemoveFeed(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/feeds/abc", nil)
+	req.SetPathValue("feedId", "abc")
+	w = httptest.NewRecorder()
+	c.handleRemoveFeed(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
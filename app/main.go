@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
-	"net/url"
 	"os"
 	"os/signal"
 	"strings"
@@ -13,18 +12,38 @@ import (
 
 	tbapi "github.com/OvyFlash/telegram-bot-api"
 	downloadTasks "magnet-feed-sync/app/bot/download-tasks"
+	feedSubscriptions "magnet-feed-sync/app/bot/feed-subscriptions"
 	"magnet-feed-sync/app/config"
 	"magnet-feed-sync/app/database"
+	"magnet-feed-sync/app/download-client/blackhole"
+	"magnet-feed-sync/app/download-client/deluge"
 	"magnet-feed-sync/app/download-client/qbittorrent"
+	"magnet-feed-sync/app/download-client/router"
+	"magnet-feed-sync/app/download-client/transmission"
 	"magnet-feed-sync/app/events"
+	feedStore "magnet-feed-sync/app/feed-store"
 	"magnet-feed-sync/app/http"
 	"magnet-feed-sync/app/observability"
 	"magnet-feed-sync/app/schedular"
 	taskStore "magnet-feed-sync/app/task-store"
 	"magnet-feed-sync/app/tracker"
 	"magnet-feed-sync/app/tracker/providers"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
 )
 
+type downloadClient interface {
+	CreateDownloadTask(url string, opts types.DownloadOptions) error
+	AddTorrentFile(data []byte, opts types.DownloadOptions) error
+	GetHashByMagnet(magnet string) (string, error)
+	SetLocation(taskID, location string) error
+	SetCategory(taskID, category string) error
+	RemoveTorrent(taskID string, deleteFiles bool) error
+	GetTorrentStatus(taskID string) (types.TorrentStatus, error)
+	GetLocations() []types.Location
+	GetDefaultLocation() string
+}
+
 func main() {
 	cfg, err := config.Init()
 	if err != nil {
@@ -65,19 +84,32 @@ func run(cfg *config.Config) error {
 
 	done := make(chan struct{})
 
-	dClient := qbittorrent.NewClient(cfg.QBittorrent)
+	dClient, err := newDownloadClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create download client: %w", err)
+	}
+	if err := events.ValidateLocationCommands(dClient.GetLocations()); err != nil {
+		return fmt.Errorf("invalid locations: %w", err)
+	}
 
-	providerList := []providers.Provider{
-		&providers.RutrackerProvider{},
-		&providers.NnmProvider{},
+	pageCache, err := newPageCache(cfg.Tracker)
+	if err != nil {
+		return fmt.Errorf("failed to create tracker page cache: %w", err)
 	}
-	if cfg.Jackett.URL != "" {
-		redacted := redactURL(cfg.Jackett.URL)
-		slog.Info("jackett provider enabled", "url", redacted)
-		providerList = append(providerList, providers.NewJackettProvider(cfg.Jackett.URL))
+
+	providerList, err := newProviders(cfg, pageCache)
+	if err != nil {
+		return fmt.Errorf("failed to create tracker providers: %w", err)
 	}
 	t := tracker.NewParser(dClient, providerList...)
 
+	var searcher downloadTasks.Searcher
+	for _, provider := range providerList {
+		if jackett, ok := provider.(*providers.JackettProvider); ok {
+			searcher = jackett
+		}
+	}
+
 	db, err := database.NewClient("tasks.db")
 	if err != nil {
 		return fmt.Errorf("failed to create database client: %w", err)
@@ -86,25 +118,58 @@ func run(cfg *config.Config) error {
 	if err != nil {
 		return fmt.Errorf("failed to create task store: %w", err)
 	}
+	feeds, err := feedStore.NewRepository(db)
+	if err != nil {
+		return fmt.Errorf("failed to create feed store: %w", err)
+	}
+	feedFetcher, err := newTrackerFetcher(cfg.Tracker, "feeds", "", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create feed fetcher: %w", err)
+	}
 
 	messagesForSend := make(chan string)
 
 	downloadTasksClient := downloadTasks.NewClient(&downloadTasks.ClientCtx{
 		Tracker:         t,
+		Searcher:        searcher,
 		DClient:         dClient,
 		Store:           store,
+		PageCache:       pageCache,
 		DryMode:         cfg.DryMode,
+		UpdatePolicy:    cfg.UpdatePolicy,
 		MessagesForSend: messagesForSend,
 	})
 
+	feedSubscriptionsClient := feedSubscriptions.NewClient(&feedSubscriptions.ClientCtx{
+		MessagesForSend: messagesForSend,
+		Store:           feeds,
+		Tasks:           downloadTasksClient,
+		Fetcher:         feedFetcher,
+	})
+
 	s, err := schedular.NewService(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create scheduler: %w", err)
 	}
 
+	if cfg.StatusSyncInterval > 0 {
+		if err := s.Every(cfg.StatusSyncInterval, func() { downloadTasksClient.SyncDownloadStatus(context.Background()) }); err != nil {
+			return fmt.Errorf("failed to schedule download status sync: %w", err)
+		}
+	}
+
+	if cfg.FeedPollInterval > 0 {
+		if err := s.Every(cfg.FeedPollInterval, func() { feedSubscriptionsClient.CheckFeeds(context.Background()) }); err != nil {
+			return fmt.Errorf("failed to schedule feed polling: %w", err)
+		}
+	}
+
 	schedulerErr := make(chan error, 1)
 	go func() {
-		if err := s.Start(func() { downloadTasksClient.CheckForUpdates(context.Background()) }); err != nil {
+		if err := s.Start(func() {
+			downloadTasksClient.CheckForUpdates(context.Background())
+			pageCache.LogStats()
+		}); err != nil {
 			schedulerErr <- err
 		}
 	}()
@@ -120,10 +185,11 @@ func run(cfg *config.Config) error {
 		Bot:             downloadTasksClient,
 		Store:           store,
 		MessagesForSend: messagesForSend,
+		Locations:       dClient.GetLocations(),
 	}
 
 	go tgListener.SendMessagesForAdmins(ctx)
-	go http.NewClient(cfg.Http, store, downloadTasksClient, dClient).Start(ctx, done)
+	go http.NewClient(cfg.Http, store, downloadTasksClient, dClient, feedSubscriptionsClient).Start(ctx, done)
 
 	go func() {
 		if err := tgListener.Do(); err != nil {
@@ -154,17 +220,148 @@ func run(cfg *config.Config) error {
 	return runErr
 }
 
-func redactURL(rawURL string) string {
-	u, err := url.Parse(rawURL)
+func newPageCache(cfg config.TrackerConfig) (*providers.PageCache, error) {
+	if !cfg.Cache {
+		return nil, nil
+	}
+	slog.Info("tracker page cache enabled", "dir", cfg.CacheDir, "max_age", cfg.CacheMaxAge, "max_entries", cfg.CacheMaxEntries)
+	return providers.NewPageCache(providers.PageCacheConfig{
+		Dir:        cfg.CacheDir,
+		MaxAge:     cfg.CacheMaxAge,
+		MaxEntries: cfg.CacheMaxEntries,
+	})
+}
+
+func newProviders(cfg *config.Config, cache *providers.PageCache) ([]providers.Provider, error) {
+	rutrackerFetcher, err := newTrackerFetcher(cfg.Tracker, "rutracker", cfg.Rutracker.Proxy, cache)
+	if err != nil {
+		return nil, err
+	}
+	nnmFetcher, err := newTrackerFetcher(cfg.Tracker, "nnm", cfg.Nnm.Proxy, cache)
+	if err != nil {
+		return nil, err
+	}
+	kinozalFetcher, err := newTrackerFetcher(cfg.Tracker, "kinozal", cfg.Kinozal.Proxy, cache)
+	if err != nil {
+		return nil, err
+	}
+	rutorFetcher, err := newTrackerFetcher(cfg.Tracker, "rutor", cfg.Rutor.Proxy, cache)
 	if err != nil {
-		return "<invalid url>"
+		return nil, err
+	}
+
+	if cfg.Rutracker.Username != "" {
+		slog.Info("rutracker login enabled", "username", cfg.Rutracker.Username)
+	}
+	providerList := []providers.Provider{
+		providers.NewRutrackerProvider(cfg.Rutracker.Username, cfg.Rutracker.Password, cfg.Rutracker.Mirror, rutrackerFetcher),
+		providers.NewNnmProvider(cfg.Nnm.Mirror, nnmFetcher),
+		providers.NewKinozalProvider(kinozalFetcher),
+		providers.NewRutorProvider(rutorFetcher),
+	}
+
+	if cfg.Jackett.URL != "" {
+		jackettFetcher, err := newIndexerFetcher(cfg.Tracker, "jackett")
+		if err != nil {
+			return nil, fmt.Errorf("invalid jackett fetcher config: %w", err)
+		}
+
+		redacted := utils.RedactURL(cfg.Jackett.URL)
+		slog.Info("jackett provider enabled", "url", redacted)
+		providerList = append(providerList, providers.NewJackettProvider(cfg.Jackett.URL, cfg.Jackett.APIKey, jackettFetcher))
 	}
-	q := u.Query()
-	for key := range q {
-		if strings.Contains(strings.ToLower(key), "apikey") || strings.Contains(strings.ToLower(key), "api_key") {
-			q.Set(key, "***")
+
+	if cfg.Prowlarr.URL != "" {
+		prowlarrFetcher, err := newIndexerFetcher(cfg.Tracker, "prowlarr")
+		if err != nil {
+			return nil, fmt.Errorf("invalid prowlarr fetcher config: %w", err)
 		}
+
+		slog.Info("prowlarr provider enabled", "url", utils.RedactURL(cfg.Prowlarr.URL))
+		providerList = append(providerList, providers.NewProwlarrProvider(cfg.Prowlarr.URL, cfg.Prowlarr.APIKey, prowlarrFetcher))
+	}
+
+	return providerList, nil
+}
+
+func newIndexerFetcher(cfg config.TrackerConfig, indexer string) (*providers.Fetcher, error) {
+	return providers.NewFetcher(providers.FetcherConfig{
+		UserAgent: cfg.UserAgent,
+		Timeout:   cfg.Timeout,
+		Retries:   cfg.Retries,
+		RateLimit: providers.RateLimit(cfg.RateLimitFor(indexer)),
+	})
+}
+
+func newTrackerFetcher(cfg config.TrackerConfig, tracker, proxy string, cache *providers.PageCache) (*providers.Fetcher, error) {
+	if proxy == "" {
+		proxy = cfg.Proxy
+	}
+	if proxy != "" {
+		slog.Info("using tracker proxy", "tracker", tracker, "proxy", utils.RedactURL(proxy))
+	}
+
+	fetcher, err := providers.NewFetcher(providers.FetcherConfig{
+		Proxy:     proxy,
+		UserAgent: cfg.UserAgent,
+		Timeout:   cfg.Timeout,
+		Retries:   cfg.Retries,
+		RateLimit: providers.RateLimit(cfg.RateLimitFor(tracker)),
+		Cache:     cache,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s fetcher config: %w", tracker, err)
+	}
+	return fetcher, nil
+}
+
+func newDownloadClient(cfg *config.Config) (downloadClient, error) {
+	cfg.QBittorrent.Locations = cfg.Locations
+	cfg.Transmission.Locations = cfg.Locations
+	cfg.Deluge.Locations = cfg.Locations
+	cfg.Blackhole.Locations = cfg.Locations
+
+	switch strings.ToLower(cfg.DownloadClient) {
+	case "", "qbittorrent":
+		return newQBittorrentClient(cfg.QBittorrent)
+	case "transmission":
+		slog.Info("using transmission download client", "url", utils.RedactURL(cfg.Transmission.URL))
+		return transmission.NewClient(cfg.Transmission), nil
+	case "deluge":
+		slog.Info("using deluge download client", "url", utils.RedactURL(cfg.Deluge.URL))
+		return deluge.NewClient(cfg.Deluge), nil
+	case "blackhole":
+		slog.Info("using blackhole download client", "dir", cfg.Blackhole.Dir)
+		return blackhole.NewClient(cfg.Blackhole), nil
+	default:
+		return nil, fmt.Errorf("unknown download client: %s", cfg.DownloadClient)
+	}
+}
+
+func newQBittorrentClient(cfg config.QBittorrentConfig) (downloadClient, error) {
+	if len(cfg.Instances) == 0 {
+		slog.Info("using qbittorrent download client", "url", utils.RedactURL(cfg.URL))
+		return qbittorrent.NewClient(cfg), nil
 	}
-	u.RawQuery = q.Encode()
-	return u.String()
+
+	instances := make([]router.Instance, 0, len(cfg.Instances)+1)
+	if cfg.URL != "" {
+		instances = append(instances, router.Instance{Name: "default", Client: qbittorrent.NewClient(cfg)})
+	}
+	for _, instance := range cfg.Instances {
+		slog.Info("using qbittorrent instance", "name", instance.Name, "url", utils.RedactURL(instance.URL))
+		instances = append(instances, router.Instance{
+			Name: instance.Name,
+			Client: qbittorrent.NewClient(config.QBittorrentConfig{
+				URL:         instance.URL,
+				Username:    instance.Username,
+				Password:    instance.Password,
+				Destination: cfg.Destination,
+				Categories:  cfg.Categories,
+				Locations:   cfg.Locations,
+			}),
+		})
+	}
+
+	return router.NewClient(instances, cfg.Routes, cfg.DefaultInstance)
 }
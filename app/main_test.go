@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/config"
+)
+
+func TestNewIndexerFetcher_Throttled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<rss></rss>")
+	}))
+	defer server.Close()
+
+	var limits config.RateLimits
+	require.NoError(t, limits.SetValue("jackett=0/200ms/1"))
+	fetcher, err := newIndexerFetcher(config.TrackerConfig{RateLimits: limits}, "jackett")
+	require.NoError(t, err)
+
+	start := time.Now()
+	for range 3 {
+		_, err := fetcher.Fetch(context.Background(), server.URL)
+		require.NoError(t, err)
+	}
+
+	assert.GreaterOrEqual(t, time.Since(start), 400*time.Millisecond, "requests to the indexer should be spaced by the min interval")
+}
@@ -0,0 +1,203 @@
+package release
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type Rules struct {
+	accept []rule
+	reject []rule
+}
+
+type rule []condition
+
+type condition struct {
+	field   string
+	values  []string
+	from    int
+	to      int
+	atLeast bool
+}
+
+var codecAliases = map[string]string{
+	"hevc": "hevc", "x265": "hevc", "h265": "hevc", "h.265": "hevc",
+	"avc": "avc", "x264": "avc", "h264": "avc", "h.264": "avc",
+	"av1": "av1", "xvid": "xvid",
+}
+
+var audioNames = []string{AudioDub, AudioMVO, AudioDVO, AudioAVO, AudioVO, AudioOriginal}
+
+func ParseRules(accept, reject string) (Rules, error) {
+	var rules Rules
+	var err error
+	if rules.accept, err = parseRuleList(accept); err != nil {
+		return Rules{}, fmt.Errorf("invalid accept rules: %w", err)
+	}
+	if rules.reject, err = parseRuleList(reject); err != nil {
+		return Rules{}, fmt.Errorf("invalid reject rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (r Rules) Empty() bool {
+	return len(r.accept) == 0 && len(r.reject) == 0
+}
+
+func (r Rules) Match(info Info) bool {
+	if len(r.accept) > 0 && !slices.ContainsFunc(r.accept, func(rl rule) bool { return rl.match(info) }) {
+		return false
+	}
+	return !slices.ContainsFunc(r.reject, func(rl rule) bool { return rl.match(info) })
+}
+
+func (r Rules) MatchTitle(title string) bool {
+	if r.Empty() {
+		return true
+	}
+	return r.Match(Parse(title))
+}
+
+func parseRuleList(value string) ([]rule, error) {
+	var rules []rule
+	for _, raw := range strings.FieldsFunc(value, func(r rune) bool { return r == ';' || r == '\n' }) {
+		fields := strings.Fields(raw)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var rl rule
+		for _, field := range fields {
+			cond, err := parseCondition(field)
+			if err != nil {
+				return nil, err
+			}
+			rl = append(rl, cond)
+		}
+		rules = append(rules, rl)
+	}
+	return rules, nil
+}
+
+func parseCondition(value string) (condition, error) {
+	field, arg, ok := strings.Cut(value, ":")
+	if !ok || arg == "" {
+		return condition{}, fmt.Errorf("condition %q must look like field:value", value)
+	}
+	cond := condition{field: strings.ToLower(field)}
+
+	switch cond.field {
+	case "season", "episode":
+		from, to, err := parseRange(arg)
+		if err != nil {
+			return condition{}, fmt.Errorf("condition %q: %w", value, err)
+		}
+		cond.from, cond.to = from, to
+	case "resolution":
+		if strings.HasSuffix(arg, "+") {
+			cond.atLeast = true
+			arg = strings.TrimSuffix(arg, "+")
+		}
+		for _, res := range strings.Split(strings.ToLower(arg), ",") {
+			height, err := resolutionHeight(res)
+			if err != nil {
+				return condition{}, fmt.Errorf("condition %q: %w", value, err)
+			}
+			cond.values = append(cond.values, strconv.Itoa(height)+"p")
+			cond.from = height
+		}
+		if cond.atLeast && len(cond.values) > 1 {
+			return condition{}, fmt.Errorf("condition %q: a minimum resolution takes a single value", value)
+		}
+	case "codec":
+		for _, codec := range strings.Split(strings.ToLower(arg), ",") {
+			name, ok := codecAliases[codec]
+			if !ok {
+				return condition{}, fmt.Errorf("condition %q: unknown codec %q", value, codec)
+			}
+			cond.values = append(cond.values, name)
+		}
+	case "audio":
+		for _, audio := range strings.Split(strings.ToLower(arg), ",") {
+			if !slices.Contains(audioNames, audio) {
+				return condition{}, fmt.Errorf("condition %q: unknown audio %q, expected one of: %s", value, audio, strings.Join(audioNames, ", "))
+			}
+			cond.values = append(cond.values, audio)
+		}
+	case "group":
+		for _, group := range strings.Split(arg, ",") {
+			cond.values = append(cond.values, strings.ToLower(strings.ReplaceAll(group, "_", " ")))
+		}
+	default:
+		return condition{}, fmt.Errorf("condition %q: unknown field %q, expected one of: season, episode, resolution, codec, audio, group", value, field)
+	}
+
+	return cond, nil
+}
+
+func parseRange(value string) (int, int, error) {
+	fromRaw, toRaw, isRange := strings.Cut(value, "-")
+	from, to := 1, 0
+	var err error
+	if fromRaw != "" {
+		if from, err = strconv.Atoi(fromRaw); err != nil || from < 1 {
+			return 0, 0, fmt.Errorf("invalid number %q", fromRaw)
+		}
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	if toRaw != "" {
+		if to, err = strconv.Atoi(toRaw); err != nil || to < from {
+			return 0, 0, fmt.Errorf("invalid range %q", value)
+		}
+	}
+	return from, to, nil
+}
+
+func resolutionHeight(value string) (int, error) {
+	if value == "4k" || value == "uhd" {
+		return 2160, nil
+	}
+	height, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSuffix(value, "p"), "i"))
+	if err != nil || height <= 0 {
+		return 0, fmt.Errorf("invalid resolution %q", value)
+	}
+	return height, nil
+}
+
+func (rl rule) match(info Info) bool {
+	for _, cond := range rl {
+		if !cond.match(info) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c condition) match(info Info) bool {
+	switch c.field {
+	case "season":
+		return info.Seasons.Overlaps(c.from, c.to)
+	case "episode":
+		return info.Episodes.Overlaps(c.from, c.to)
+	case "resolution":
+		if info.Resolution == "" {
+			return false
+		}
+		if c.atLeast {
+			height, _ := resolutionHeight(info.Resolution)
+			return height >= c.from
+		}
+		return slices.Contains(c.values, info.Resolution)
+	case "codec":
+		return info.Codec != "" && slices.Contains(c.values, info.Codec)
+	case "audio":
+		return slices.ContainsFunc(info.Audio, func(audio string) bool { return slices.Contains(c.values, audio) })
+	case "group":
+		return slices.ContainsFunc(info.Groups, func(group string) bool { return slices.Contains(c.values, strings.ToLower(group)) })
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRules_MatchTitle(t *testing.T) {
+	const (
+		rutracker = "Разделение / Severance / Сезон: 2 / Серии: 1-8 из 10 (Бен Стиллер) [2025, США, триллер, HEVC, WEB-DL 2160p] MVO (HDrezka Studio) + Original + Sub (Rus, Eng)"
+		kinozal   = "Одни из нас (1 сезон: 1-9 серии из 9) / The Last of Us / 2023 / ПМ (LostFilm) / WEB-DLRip"
+		rutor     = "Разделение / Severance [S02E09-10 из 10] (2025) WEB-DL 1080p | LostFilm"
+		scene     = "Severance.S02E03.1080p.ATVP.WEB-DL.DDP5.1.H.264-NTb"
+	)
+
+	tests := []struct {
+		name   string
+		accept string
+		reject string
+		title  string
+		want   bool
+	}{
+		{name: "no rules", title: kinozal, want: true},
+		{name: "resolution", accept: "resolution:2160p", title: rutracker, want: true},
+		{name: "resolution list", accept: "resolution:2160p,1080p", title: rutor, want: true},
+		{name: "minimum resolution", accept: "resolution:1080p+", title: rutracker, want: true},
+		{name: "minimum resolution not met", accept: "resolution:2160p+", title: rutor, want: false},
+		{name: "unknown resolution", accept: "resolution:720p+", title: kinozal, want: false},
+		{name: "codec alias", accept: "codec:x265", title: rutracker, want: true},
+		{name: "codec mismatch", accept: "codec:hevc", title: scene, want: false},
+		{name: "episode range overlaps", accept: "season:2 episode:9-", title: rutor, want: true},
+		{name: "episode range outside", accept: "season:2 episode:9-", title: rutracker, want: false},
+		{name: "season range", accept: "season:1-3", title: kinozal, want: true},
+		{name: "season mismatch", accept: "season:2", title: kinozal, want: false},
+		{name: "audio", accept: "audio:mvo,dub", title: kinozal, want: true},
+		{name: "group with spaces", accept: "group:hdrezka_studio", title: rutracker, want: true},
+		{name: "group case insensitive", accept: "group:lostfilm", title: rutor, want: true},
+		{name: "any accept rule", accept: "group:lostfilm; resolution:2160p", title: rutracker, want: true},
+		{name: "all conditions of a rule", accept: "group:lostfilm resolution:2160p", title: rutor, want: false},
+		{name: "reject", reject: "group:lostfilm", title: kinozal, want: false},
+		{name: "reject wins over accept", accept: "season:2", reject: "episode:9-", title: rutor, want: false},
+		{name: "reject unknown field value", reject: "audio:dub", title: scene, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := ParseRules(tt.accept, tt.reject)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, rules.MatchTitle(tt.title))
+		})
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	tests := []string{
+		"resolution",
+		"quality:1080p",
+		"resolution:hd",
+		"resolution:1080p,720p+",
+		"codec:divx",
+		"audio:stereo",
+		"season:two",
+		"episode:8-3",
+		"episode:0",
+	}
+
+	for _, value := range tests {
+		t.Run(value, func(t *testing.T) {
+			_, err := ParseRules(value, "")
+			assert.Error(t, err)
+
+			_, err = ParseRules("", value)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package release
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type Range struct {
+	From int
+	To   int
+}
+
+func (r Range) IsZero() bool {
+	return r.From == 0
+}
+
+func (r Range) Overlaps(from, to int) bool {
+	if r.IsZero() {
+		return false
+	}
+	if to > 0 && r.From > to {
+		return false
+	}
+	return r.To >= from
+}
+
+type Info struct {
+	Seasons       Range
+	Episodes      Range
+	EpisodesTotal int
+	Resolution    string
+	Codec         string
+	Audio         []string
+	Groups        []string
+}
+
+const (
+	AudioDub      = "dub"
+	AudioMVO      = "mvo"
+	AudioDVO      = "dvo"
+	AudioAVO      = "avo"
+	AudioVO       = "vo"
+	AudioOriginal = "original"
+)
+
+func word(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(?:` + pattern + `)(?:[^\p{L}\p{N}]|$)`)
+}
+
+var (
+	sceneEpisodePattern   = regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])S(\d{1,2})(?:-S?(\d{1,2}))?(?:\s?E(\d{1,3})(?:\s?-\s?E?(\d{1,3}))?)?(?:[\s._-]*(?:из|of)[\s._-]*(\d+))?(?:[^\p{L}\p{N}]|$)`)
+	seasonPattern         = regexp.MustCompile(`(?i)(?:сезон[ыа]?|season)\s*:?\s*(\d+)(?:\s*-\s*(\d+))?`)
+	seasonSuffixPattern   = regexp.MustCompile(`(?i)(\d+)(?:\s*-\s*(\d+))?\s*сезон`)
+	episodesPattern       = regexp.MustCompile(`(?i)(?:сери[яий]|episodes?)\s*:?\s*(\d+)(?:\s*-\s*(\d+))?(?:\s*(?:из|of)\s*(\d+))?`)
+	episodesSuffixPattern = regexp.MustCompile(`(?i)(\d+)(?:\s*-\s*(\d+))?\s*сери[яий](?:\s*(?:из|of)\s*(\d+))?`)
+	resolutionPattern     = regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}])(2160|1440|1080|720|576|480)[pi](?:[^\p{L}\p{N}]|$)`)
+	uhdPattern            = word(`4k|uhd`)
+	groupSuffixPattern    = regexp.MustCompile(`[\p{L}\p{N}.]-([A-Za-z0-9]+)$`)
+	groupPipePattern      = regexp.MustCompile(`\|\s*([^|\[\]()]+?)\s*$`)
+	groupStudioPattern    = regexp.MustCompile(`(?i)(?:MVO|DVO|AVO|VO|Dub|ПМ|ПД|ДО|АП|ЛО)\s*\(([^)]+)\)`)
+)
+
+var codecPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{name: "hevc", pattern: word(`hevc|[hx]\.?265`)},
+	{name: "avc", pattern: word(`avc|[hx]\.?264`)},
+	{name: "av1", pattern: word(`av1`)},
+	{name: "xvid", pattern: word(`xvid`)},
+}
+
+var audioPatterns = []struct {
+	name    string
+	pattern *regexp.Regexp
+}{
+	{name: AudioDub, pattern: word(`dub|дубляж|дублированный|(?-i:ПД)`)},
+	{name: AudioMVO, pattern: word(`mvo|многоголосый|(?-i:ПМ)`)},
+	{name: AudioDVO, pattern: word(`dvo|двухголосый|(?-i:ДО)`)},
+	{name: AudioAVO, pattern: word(`avo|авторский|(?-i:АП)`)},
+	{name: AudioVO, pattern: word(`vo|одноголосый|(?-i:ЛО)`)},
+	{name: AudioOriginal, pattern: word(`original|оригинал|оригинальная`)},
+}
+
+var notGroups = map[string]bool{"dl": true, "dlrip": true, "rip": true, "hd": true, "avc": true, "hevc": true, "x264": true, "x265": true}
+
+func Parse(title string) Info {
+	var info Info
+
+	if m := sceneEpisodePattern.FindStringSubmatch(title); m != nil {
+		info.Seasons = newRange(m[1], m[2])
+		info.Episodes = newRange(m[3], m[4])
+		info.EpisodesTotal = atoi(m[5])
+	}
+	if info.Seasons.IsZero() {
+		if m := seasonSuffixPattern.FindStringSubmatch(title); m != nil {
+			info.Seasons = newRange(m[1], m[2])
+		} else if m := seasonPattern.FindStringSubmatch(title); m != nil {
+			info.Seasons = newRange(m[1], m[2])
+		}
+	}
+	if info.Episodes.IsZero() {
+		if m := episodesPattern.FindStringSubmatch(title); m != nil {
+			info.Episodes = newRange(m[1], m[2])
+			info.EpisodesTotal = atoi(m[3])
+		} else if m := episodesSuffixPattern.FindStringSubmatch(title); m != nil {
+			info.Episodes = newRange(m[1], m[2])
+			info.EpisodesTotal = atoi(m[3])
+		}
+	}
+
+	if m := resolutionPattern.FindStringSubmatch(title); m != nil {
+		info.Resolution = m[1] + "p"
+	} else if uhdPattern.MatchString(title) {
+		info.Resolution = "2160p"
+	}
+
+	for _, codec := range codecPatterns {
+		if codec.pattern.MatchString(title) {
+			info.Codec = codec.name
+			break
+		}
+	}
+
+	for _, audio := range audioPatterns {
+		if audio.pattern.MatchString(title) {
+			info.Audio = append(info.Audio, audio.name)
+		}
+	}
+
+	info.Groups = parseGroups(strings.TrimSpace(title))
+
+	return info
+}
+
+func parseGroups(title string) []string {
+	if m := groupSuffixPattern.FindStringSubmatch(title); m != nil && !notGroups[strings.ToLower(m[1])] {
+		return []string{m[1]}
+	}
+	if m := groupPipePattern.FindStringSubmatch(title); m != nil {
+		return []string{m[1]}
+	}
+	if m := groupStudioPattern.FindStringSubmatch(title); m != nil {
+		var groups []string
+		for _, group := range strings.Split(m[1], ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+func newRange(from, to string) Range {
+	r := Range{From: atoi(from), To: atoi(to)}
+	if r.To < r.From {
+		r.To = r.From
+	}
+	return r
+}
+
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
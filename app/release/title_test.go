@@ -0,0 +1,171 @@
+package release
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  Info
+	}{
+		{
+			name:  "rutracker series",
+			title: "Терапия / Shrinking / Сезон: 3 / Серии: 1 из 12 (Рэндолл Кинан Уинстон, Джеймс Понсольдт, Зак Брафф) [2026, США, драма, комедия, HEVC, HDR10+, Dolby Vision, WEB-DL 2160p, 4K] 4 x MVO (HDrezka Studio, TVShows, WStudio, LE-Production) + Original (Eng) + Sub (Rus, Eng)",
+			want: Info{
+				Seasons:       Range{From: 3, To: 3},
+				Episodes:      Range{From: 1, To: 1},
+				EpisodesTotal: 12,
+				Resolution:    "2160p",
+				Codec:         "hevc",
+				Audio:         []string{AudioMVO, AudioOriginal},
+				Groups:        []string{"HDrezka Studio", "TVShows", "WStudio", "LE-Production"},
+			},
+		},
+		{
+			name:  "rutracker episode range",
+			title: "Разделение / Severance / Сезон: 2 / Серии: 1-8 из 10 (Бен Стиллер, Эйфер Макардл) [2025, США, триллер, WEB-DL 1080p] Dub (Red Head Sound) + Original + Sub (Rus, Eng)",
+			want: Info{
+				Seasons:       Range{From: 2, To: 2},
+				Episodes:      Range{From: 1, To: 8},
+				EpisodesTotal: 10,
+				Resolution:    "1080p",
+				Audio:         []string{AudioDub, AudioOriginal},
+				Groups:        []string{"Red Head Sound"},
+			},
+		},
+		{
+			name:  "kinozal series",
+			title: "Одни из нас (1 сезон: 1-9 серии из 9) / The Last of Us / 2023 / ПМ (LostFilm) / WEB-DLRip",
+			want: Info{
+				Seasons:       Range{From: 1, To: 1},
+				Episodes:      Range{From: 1, To: 9},
+				EpisodesTotal: 9,
+				Audio:         []string{AudioMVO},
+				Groups:        []string{"LostFilm"},
+			},
+		},
+		{
+			name:  "kinozal season pack with resolution",
+			title: "Разделение (1-2 сезоны: 1-19 серии из 19) / Severance / 2022-2025 / ПД, ЛО (Гоблин) / WEB-DL (1080p)",
+			want: Info{
+				Seasons:       Range{From: 1, To: 2},
+				Episodes:      Range{From: 1, To: 19},
+				EpisodesTotal: 19,
+				Resolution:    "1080p",
+				Audio:         []string{AudioDub, AudioVO},
+				Groups:        []string{"Гоблин"},
+			},
+		},
+		{
+			name:  "rutor series",
+			title: "Одни из нас / The Last of Us [S01E01-05] (2023) WEB-DLRip | LostFilm",
+			want: Info{
+				Seasons:  Range{From: 1, To: 1},
+				Episodes: Range{From: 1, To: 5},
+				Groups:   []string{"LostFilm"},
+			},
+		},
+		{
+			name:  "rutor series with total",
+			title: "Разделение / Severance [S02E01-08 из 10] (2025) WEB-DL 2160p | HDR | LostFilm",
+			want: Info{
+				Seasons:       Range{From: 2, To: 2},
+				Episodes:      Range{From: 1, To: 8},
+				EpisodesTotal: 10,
+				Resolution:    "2160p",
+				Groups:        []string{"LostFilm"},
+			},
+		},
+		{
+			name:  "nnm season",
+			title: "Разделение / Severance [S02] (2025) WEB-DL 1080p | TVShows",
+			want: Info{
+				Seasons:    Range{From: 2, To: 2},
+				Resolution: "1080p",
+				Groups:     []string{"TVShows"},
+			},
+		},
+		{
+			name:  "jackett scene release",
+			title: "Severance S02 2160p WEB-DL DDP5.1 HDR DoVi Hybrid HEVC-FLUX",
+			want: Info{
+				Seasons:    Range{From: 2, To: 2},
+				Resolution: "2160p",
+				Codec:      "hevc",
+				Groups:     []string{"FLUX"},
+			},
+		},
+		{
+			name:  "scene episode",
+			title: "Severance.S02E03.Who.Is.Alive.1080p.ATVP.WEB-DL.DDP5.1.H.264-NTb",
+			want: Info{
+				Seasons:    Range{From: 2, To: 2},
+				Episodes:   Range{From: 3, To: 3},
+				Resolution: "1080p",
+				Codec:      "avc",
+				Groups:     []string{"NTb"},
+			},
+		},
+		{
+			name:  "nnm episode progress",
+			title: "Северная полоса / The Northern Line [Серии 1-6 из 10] (2026) WEB-DL 1080p | NewStudio",
+			want: Info{
+				Episodes:      Range{From: 1, To: 6},
+				EpisodesTotal: 10,
+				Resolution:    "1080p",
+				Groups:        []string{"NewStudio"},
+			},
+		},
+		{
+			name:  "english episode progress",
+			title: "The Northern Line (S01E01-05 of 10) 2160p WEB-DL",
+			want: Info{
+				Seasons:       Range{From: 1, To: 1},
+				Episodes:      Range{From: 1, To: 5},
+				EpisodesTotal: 10,
+				Resolution:    "2160p",
+			},
+		},
+		{
+			name:  "dot separated episode progress",
+			title: "Show.S02E01-05.of.10.1080p.WEB-DL.x264",
+			want: Info{
+				Seasons:       Range{From: 2, To: 2},
+				Episodes:      Range{From: 1, To: 5},
+				EpisodesTotal: 10,
+				Resolution:    "1080p",
+				Codec:         "avc",
+			},
+		},
+		{
+			name:  "movie",
+			title: "Some Movie 2024 1080p BluRay",
+			want:  Info{Resolution: "1080p"},
+		},
+		{
+			name:  "uhd without resolution",
+			title: "Documentary 2025 4K UHD",
+			want:  Info{Resolution: "2160p"},
+		},
+		{
+			name:  "web-dl is not a group",
+			title: "Разделение / Severance (2025) WEB-DL",
+			want:  Info{},
+		},
+		{
+			name:  "magazine",
+			title: "[Журнал] Хакер + Хакер.Спец (393 номеров) [1999-2025, PDF, RUS] Обновлено 17.03.2026",
+			want:  Info{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.title))
+		})
+	}
+}
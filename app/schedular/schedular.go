@@ -4,6 +4,7 @@ import (
 	"github.com/go-co-op/gocron/v2"
 	"log/slog"
 	"magnet-feed-sync/app/config"
+	"time"
 )
 
 type Service struct {
@@ -23,6 +24,21 @@ func NewService(cfg *config.Config) (*Service, error) {
 	}, nil
 }
 
+func (s *Service) Every(interval time.Duration, cb func()) error {
+	j, err := s.scheduler.NewJob(
+		gocron.DurationJob(interval),
+		gocron.NewTask(cb),
+		gocron.WithSingletonMode(gocron.LimitModeReschedule),
+	)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("interval job created", "job_id", j.ID(), "interval", interval)
+
+	return nil
+}
+
 func (s *Service) Start(cb func()) error {
 	j, err := s.scheduler.NewJob(
 		gocron.CronJob(s.cfg.Cron, false),
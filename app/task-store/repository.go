@@ -1,9 +1,12 @@
 package task_store
 
 import (
+	"database/sql"
 	"log/slog"
 	"magnet-feed-sync/app/database"
 	"magnet-feed-sync/app/tracker"
+	"magnet-feed-sync/app/types"
+	"time"
 )
 
 type Repository struct {
@@ -19,6 +22,23 @@ func NewRepository(db *database.Client) (*Repository, error) {
     		last_sync_at TIMESTAMP,
     		last_comment TEXT NOT NULL DEFAULT '',
     		location TEXT NOT NULL DEFAULT '/downloads/tv shows',
+    		category TEXT NOT NULL DEFAULT '',
+    		update_policy TEXT NOT NULL DEFAULT 'keep',
+    		download_instance TEXT NOT NULL DEFAULT '',
+    		torrent_size INTEGER NOT NULL DEFAULT 0,
+    		torrent_seeders INTEGER NOT NULL DEFAULT 0,
+    		torrent_leechers INTEGER NOT NULL DEFAULT 0,
+    		torrent_category TEXT NOT NULL DEFAULT '',
+    		torrent_file_count INTEGER NOT NULL DEFAULT 0,
+    		episodes_current INTEGER NOT NULL DEFAULT 0,
+    		episodes_total INTEGER NOT NULL DEFAULT 0,
+    		download_state TEXT NOT NULL DEFAULT '',
+    		download_progress REAL NOT NULL DEFAULT 0,
+    		download_size INTEGER NOT NULL DEFAULT 0,
+    		download_eta INTEGER NOT NULL DEFAULT 0,
+    		download_completed_at TIMESTAMP DEFAULT NULL,
+    		redownload_started_at TIMESTAMP DEFAULT NULL,
+    		completed_at TIMESTAMP DEFAULT NULL,
     		torrent_updated_at TIMESTAMP,
     		created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
             delete_at TIMESTAMP DEFAULT NULL
@@ -40,8 +60,25 @@ func (r *Repository) CreateOrReplace(metadata *tracker.FileMetadata) error {
 				last_comment,
 				torrent_updated_at,
 				location,
+				category,
+				update_policy,
+				download_instance,
+				torrent_size,
+				torrent_seeders,
+				torrent_leechers,
+				torrent_category,
+				torrent_file_count,
+				episodes_current,
+				episodes_total,
+				download_state,
+				download_progress,
+				download_size,
+				download_eta,
+				download_completed_at,
+				redownload_started_at,
+				completed_at,
 				delete_at
-			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NULL)`,
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL)`,
 		metadata.ID,
 		metadata.OriginalUrl,
 		metadata.Magnet,
@@ -50,6 +87,23 @@ func (r *Repository) CreateOrReplace(metadata *tracker.FileMetadata) error {
 		metadata.LastComment,
 		metadata.TorrentUpdatedAt,
 		metadata.Location,
+		metadata.Category,
+		metadata.UpdatePolicy,
+		metadata.Instance,
+		metadata.Torrent.Size,
+		metadata.Torrent.Seeders,
+		metadata.Torrent.Leechers,
+		metadata.Torrent.Category,
+		metadata.Torrent.FileCount,
+		metadata.Episodes.Current,
+		metadata.Episodes.Total,
+		metadata.Download.State,
+		metadata.Download.Progress,
+		metadata.Download.Size,
+		metadata.Download.ETA,
+		nullTime(metadata.Download.CompletedAt),
+		nullTime(metadata.RedownloadStartedAt),
+		nullTime(metadata.CompletedAt),
 	)
 
 	return err
@@ -66,6 +120,23 @@ func (r *Repository) GetAll() ([]*tracker.FileMetadata, error) {
 			last_sync_at,
 			torrent_updated_at,
 			location,
+			category,
+			update_policy,
+			download_instance,
+			torrent_size,
+			torrent_seeders,
+			torrent_leechers,
+			torrent_category,
+			torrent_file_count,
+			episodes_current,
+			episodes_total,
+			download_state,
+			download_progress,
+			download_size,
+			download_eta,
+			download_completed_at,
+			redownload_started_at,
+			completed_at,
 			created_at,
 			delete_at
 		FROM
@@ -86,7 +157,12 @@ func (r *Repository) GetAll() ([]*tracker.FileMetadata, error) {
 
 	var metadata []*tracker.FileMetadata
 	for rows.Next() {
-		var m tracker.FileMetadata
+		var (
+			m                   tracker.FileMetadata
+			completedAt         sql.NullTime
+			redownloadStartedAt sql.NullTime
+			taskCompletedAt     sql.NullTime
+		)
 		if err := rows.Scan(
 			&m.ID,
 			&m.OriginalUrl,
@@ -96,11 +172,31 @@ func (r *Repository) GetAll() ([]*tracker.FileMetadata, error) {
 			&m.LastSyncAt,
 			&m.TorrentUpdatedAt,
 			&m.Location,
+			&m.Category,
+			&m.UpdatePolicy,
+			&m.Instance,
+			&m.Torrent.Size,
+			&m.Torrent.Seeders,
+			&m.Torrent.Leechers,
+			&m.Torrent.Category,
+			&m.Torrent.FileCount,
+			&m.Episodes.Current,
+			&m.Episodes.Total,
+			&m.Download.State,
+			&m.Download.Progress,
+			&m.Download.Size,
+			&m.Download.ETA,
+			&completedAt,
+			&redownloadStartedAt,
+			&taskCompletedAt,
 			&m.CreatedAt,
 			&m.DeleteAt,
 		); err != nil {
 			return nil, err
 		}
+		m.Download.CompletedAt = completedAt.Time
+		m.RedownloadStartedAt = redownloadStartedAt.Time
+		m.CompletedAt = taskCompletedAt.Time
 
 		metadata = append(metadata, &m)
 	}
@@ -109,7 +205,12 @@ func (r *Repository) GetAll() ([]*tracker.FileMetadata, error) {
 }
 
 func (r *Repository) GetById(id string) (*tracker.FileMetadata, error) {
-	var m tracker.FileMetadata
+	var (
+		m                   tracker.FileMetadata
+		completedAt         sql.NullTime
+		redownloadStartedAt sql.NullTime
+		taskCompletedAt     sql.NullTime
+	)
 	err := r.db.QueryRow(`
 		SELECT
 			id,
@@ -120,6 +221,23 @@ func (r *Repository) GetById(id string) (*tracker.FileMetadata, error) {
 			last_sync_at,
 			torrent_updated_at,
 			location,
+			category,
+			update_policy,
+			download_instance,
+			torrent_size,
+			torrent_seeders,
+			torrent_leechers,
+			torrent_category,
+			torrent_file_count,
+			episodes_current,
+			episodes_total,
+			download_state,
+			download_progress,
+			download_size,
+			download_eta,
+			download_completed_at,
+			redownload_started_at,
+			completed_at,
 			created_at,
 			delete_at
 		FROM
@@ -135,17 +253,66 @@ func (r *Repository) GetById(id string) (*tracker.FileMetadata, error) {
 		&m.LastSyncAt,
 		&m.TorrentUpdatedAt,
 		&m.Location,
+		&m.Category,
+		&m.UpdatePolicy,
+		&m.Instance,
+		&m.Torrent.Size,
+		&m.Torrent.Seeders,
+		&m.Torrent.Leechers,
+		&m.Torrent.Category,
+		&m.Torrent.FileCount,
+		&m.Episodes.Current,
+		&m.Episodes.Total,
+		&m.Download.State,
+		&m.Download.Progress,
+		&m.Download.Size,
+		&m.Download.ETA,
+		&completedAt,
+		&redownloadStartedAt,
+		&taskCompletedAt,
 		&m.CreatedAt,
 		&m.DeleteAt,
 	)
 	if err != nil {
 		return nil, err
 	}
+	m.Download.CompletedAt = completedAt.Time
+	m.RedownloadStartedAt = redownloadStartedAt.Time
+	m.CompletedAt = taskCompletedAt.Time
 
 	return &m, nil
 }
 
+func (r *Repository) UpdateDownloadStatus(id, magnet string, status types.TorrentStatus) error {
+	_, err := r.db.Exec(`UPDATE files SET
+				download_state = ?,
+				download_progress = ?,
+				download_size = ?,
+				download_eta = ?,
+				download_completed_at = ?
+			WHERE id = ? AND magnet = ? AND delete_at IS NULL`,
+		status.State,
+		status.Progress,
+		status.Size,
+		status.ETA,
+		nullTime(status.CompletedAt),
+		id,
+		magnet,
+	)
+
+	return err
+}
+
+func (r *Repository) ClearRedownloadStartedAt(id, magnet string) error {
+	_, err := r.db.Exec(`UPDATE files SET redownload_started_at = NULL WHERE id = ? AND magnet = ?`, id, magnet)
+	return err
+}
+
 func (r *Repository) Remove(id string) error {
 	_, err := r.db.Exec(`UPDATE files SET delete_at = CURRENT_TIMESTAMP WHERE id = ?`, id)
 	return err
 }
+
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
@@ -9,28 +9,53 @@ import (
 	"strings"
 	"time"
 
+	"magnet-feed-sync/app/release"
 	"magnet-feed-sync/app/tracker/providers"
+	"magnet-feed-sync/app/types"
 )
 
 type FileMetadata struct {
-	ID               string       `json:"id"`
-	OriginalUrl      string       `json:"original_url"`
-	Magnet           string       `json:"magnet"`
-	Name             string       `json:"name"`
-	LastComment      string       `json:"last_comment"`
-	LastSyncAt       time.Time    `json:"last_sync_at"`
-	TorrentUpdatedAt time.Time    `json:"torrent_updated_at"`
-	Location         string       `json:"location"`
-	CreatedAt        time.Time    `json:"-"`
-	DeleteAt         sql.NullTime `json:"-"`
+	ID                  string                `json:"id"`
+	OriginalUrl         string                `json:"original_url"`
+	Magnet              string                `json:"magnet"`
+	TorrentFile         []byte                `json:"-"`
+	Name                string                `json:"name"`
+	LastComment         string                `json:"last_comment"`
+	LastSyncAt          time.Time             `json:"last_sync_at"`
+	TorrentUpdatedAt    time.Time             `json:"torrent_updated_at"`
+	Location            string                `json:"location"`
+	Category            string                `json:"category"`
+	UpdatePolicy        types.UpdatePolicy    `json:"update_policy"`
+	Instance            string                `json:"instance,omitempty"`
+	Torrent             types.TorrentInfo     `json:"torrent"`
+	Episodes            types.EpisodeProgress `json:"episodes"`
+	Download            types.TorrentStatus   `json:"download"`
+	RedownloadStartedAt time.Time             `json:"redownload_started_at,omitzero"`
+	CompletedAt         time.Time             `json:"completed_at,omitzero"`
+	CreatedAt           time.Time             `json:"-"`
+	DeleteAt            sql.NullTime          `json:"-"`
 }
 
 var ErrProviderNotFound = errors.New("provider not found")
 
+var ErrNotModified = providers.ErrNotModified
+
+func SkipUnchanged(ctx context.Context) context.Context {
+	return providers.SkipUnchanged(ctx)
+}
+
+func DeferPageCache(ctx context.Context) (context.Context, func()) {
+	return providers.DeferPageCache(ctx)
+}
+
 type DownloadClient interface {
 	GetDefaultLocation() string
 }
 
+type apiKeyRestorer interface {
+	RestoresAPIKey() bool
+}
+
 type Parser struct {
 	downloadClient DownloadClient
 	providers      []providers.Provider
@@ -49,7 +74,7 @@ func (p *Parser) Parse(ctx context.Context, url string, location string) (*FileM
 		return nil, fmt.Errorf("%w for url: %s", ErrProviderNotFound, url)
 	}
 
-	result, err := provider.Parse(ctx, url)
+	result, err := provider.Parse(providers.WithCacheSource(ctx, url), url)
 	if err != nil {
 		return nil, err
 	}
@@ -63,16 +88,24 @@ func (p *Parser) Parse(ctx context.Context, url string, location string) (*FileM
 		originalURL = result.TrackerURL
 	} else if stripped := stripAPIKey(url); stripped != url {
 		originalURL = ""
+		if restorer, ok := provider.(apiKeyRestorer); ok && restorer.RestoresAPIKey() {
+			originalURL = stripped
+		}
 	}
 
+	info := release.Parse(result.Title)
+
 	return &FileMetadata{
 		ID:               result.ID,
 		OriginalUrl:      originalURL,
 		Magnet:           result.Magnet,
+		TorrentFile:      result.TorrentFile,
 		Name:             result.Title,
 		LastComment:      result.Comment,
 		LastSyncAt:       time.Now(),
 		TorrentUpdatedAt: result.UpdatedAt,
+		Torrent:          result.Torrent,
+		Episodes:         types.EpisodeProgress{Current: info.Episodes.To, Total: info.EpisodesTotal},
 		Location:         location,
 	}, nil
 }
@@ -97,6 +130,10 @@ func stripAPIKey(rawURL string) string {
 	return u.String()
 }
 
+func (p *Parser) CanHandle(url string) bool {
+	return p.getProvider(url) != nil
+}
+
 func (p *Parser) getProvider(url string) providers.Provider {
 	for _, provider := range p.providers {
 		if provider.CanHandle(url) {
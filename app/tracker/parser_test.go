@@ -50,6 +50,14 @@ func (m *mockProvider) Parse(ctx context.Context, url string) (*providers.Result
 	return m.result, m.err
 }
 
+type apiKeyProvider struct {
+	mockProvider
+}
+
+func (m *apiKeyProvider) RestoresAPIKey() bool {
+	return true
+}
+
 func TestParser_Parse(t *testing.T) {
 	mockResult := &providers.Result{
 		ID:        "123",
@@ -57,6 +65,7 @@ func TestParser_Parse(t *testing.T) {
 		Magnet:    "magnet:?xt=urn:btih:abc",
 		UpdatedAt: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
 		Comment:   "test comment",
+		Torrent:   types.TorrentInfo{Size: 1024, Seeders: 5, Leechers: 1, Category: "TV", FileCount: 3},
 	}
 
 	t.Run("successful parse with explicit location", func(t *testing.T) {
@@ -74,6 +83,21 @@ func TestParser_Parse(t *testing.T) {
 		assert.Equal(t, "https://example.com/test", metadata.OriginalUrl)
 		assert.Equal(t, "test comment", metadata.LastComment)
 		assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), metadata.TorrentUpdatedAt)
+		assert.Equal(t, mockResult.Torrent, metadata.Torrent)
+	})
+
+	t.Run("episode progress is read from the title", func(t *testing.T) {
+		result := *mockResult
+		result.Title = "Разделение / Severance / Сезон: 2 / Серии: 1-6 из 10 [2025, WEB-DL 2160p] MVO"
+		p := NewParser(
+			&mockDownloadClient{defaultLocation: "/default"},
+			&mockProvider{canHandleResult: true, result: &result},
+		)
+
+		metadata, err := p.Parse(context.Background(), "https://example.com/test", "")
+		require.NoError(t, err)
+		assert.Equal(t, types.EpisodeProgress{Current: 6, Total: 10}, metadata.Episodes)
+		assert.False(t, metadata.Episodes.Complete())
 	})
 
 	t.Run("empty location falls back to default", func(t *testing.T) {
@@ -224,6 +248,26 @@ func TestParser_TrackerURLSwap(t *testing.T) {
 		assert.Equal(t, "", metadata.OriginalUrl)
 	})
 
+	t.Run("empty TrackerURL keeps URL without apikey when provider restores it", func(t *testing.T) {
+		inputURL := "http://prowlarr:9696/12/api?apikey=SECRET123&q=severance&t=search"
+
+		p := NewParser(
+			&mockDownloadClient{defaultLocation: "/default"},
+			&apiKeyProvider{mockProvider: mockProvider{
+				canHandleResult: true,
+				result: &providers.Result{
+					ID:     "789",
+					Title:  "Indexer Torrent",
+					Magnet: "magnet:?xt=urn:btih:ghi",
+				},
+			}},
+		)
+
+		metadata, err := p.Parse(context.Background(), inputURL, "")
+		require.NoError(t, err)
+		assert.Equal(t, "http://prowlarr:9696/12/api?q=severance&t=search", metadata.OriginalUrl)
+	})
+
 	t.Run("non-Jackett provider without TrackerURL keeps original URL", func(t *testing.T) {
 		rutrackerURL := "https://rutracker.org/forum/viewtopic.php?t=789"
 
@@ -2,13 +2,11 @@ package providers
 
 import (
 	"context"
-	"fmt"
-	"io"
-	"log/slog"
-	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
-	"golang.org/x/net/html/charset"
+	"magnet-feed-sync/app/types"
 )
 
 type Provider interface {
@@ -17,44 +15,20 @@ type Provider interface {
 }
 
 type Result struct {
-	ID         string
-	Title      string
-	Magnet     string
-	UpdatedAt  time.Time
-	Comment    string
-	TrackerURL string
+	ID          string
+	Title       string
+	Magnet      string
+	TorrentFile []byte
+	UpdatedAt   time.Time
+	Comment     string
+	TrackerURL  string
+	Torrent     types.TorrentInfo
 }
 
-func fetchPage(ctx context.Context, pageURL string) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+func parseCount(s string) int {
+	n, err := strconv.Atoi(strings.Join(strings.Fields(s), ""))
 	if err != nil {
-		return nil, err
+		return 0
 	}
-
-	resp, err := http.DefaultClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			slog.Error("error closing response body", "error", err)
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bad status: %s", resp.Status)
-	}
-
-	utf8Reader, err := charset.NewReader(resp.Body, resp.Header.Get("Content-Type"))
-	if err != nil {
-		return nil, err
-	}
-
-	const maxResponseSize = 10 * 1024 * 1024
-	body, err := io.ReadAll(io.LimitReader(utf8Reader, maxResponseSize))
-	if err != nil {
-		return nil, err
-	}
-
-	return body, nil
+	return n
 }
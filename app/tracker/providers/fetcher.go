@@ -0,0 +1,281 @@
+package providers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	DefaultUserAgent  = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+	defaultTimeout    = 30 * time.Second
+	defaultRetryDelay = time.Second
+	maxResponseSize   = 10 * 1024 * 1024
+)
+
+type FetcherConfig struct {
+	Proxy      string
+	UserAgent  string
+	Timeout    time.Duration
+	Retries    int
+	RetryDelay time.Duration
+	RateLimit  RateLimit
+	Cache      *PageCache
+}
+
+type Fetcher struct {
+	client     *http.Client
+	userAgent  string
+	retries    int
+	retryDelay time.Duration
+	limiter    *rateLimiter
+	cache      *PageCache
+}
+
+var defaultFetcher = &Fetcher{
+	client:     &http.Client{Timeout: defaultTimeout},
+	userAgent:  DefaultUserAgent,
+	retryDelay: defaultRetryDelay,
+}
+
+func NewFetcher(cfg FetcherConfig) (*Fetcher, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("unsupported proxy scheme: %q", proxyURL.Scheme)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = DefaultUserAgent
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = defaultRetryDelay
+	}
+
+	return &Fetcher{
+		client:     &http.Client{Transport: transport, Timeout: cfg.Timeout},
+		userAgent:  cfg.UserAgent,
+		retries:    max(cfg.Retries, 0),
+		retryDelay: cfg.RetryDelay,
+		limiter:    newRateLimiter(cfg.RateLimit),
+		cache:      cfg.Cache,
+	}, nil
+}
+
+func fetcherOrDefault(f *Fetcher) *Fetcher {
+	if f == nil {
+		return defaultFetcher
+	}
+	return f
+}
+
+func (f *Fetcher) withCookieJar(jar http.CookieJar) *Fetcher {
+	client := *f.client
+	client.Jar = jar
+	withJar := *f
+	withJar.client = &client
+	return &withJar
+}
+
+func (f *Fetcher) Do(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", f.userAgent)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(req.Context())
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+		}
+
+		release, err := f.limiter.wait(req.Context(), req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := f.client.Do(attemptReq)
+		if err != nil {
+			release()
+		} else {
+			resp.Body = releaseOnClose{ReadCloser: resp.Body, release: release}
+		}
+
+		if attempt >= f.retries || !f.shouldRetry(req.Context(), resp, err) {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		}
+
+		delay := f.retryDelay << attempt
+		slog.Warn("retrying tracker request", "url", req.URL.Redacted(), "attempt", attempt+1, "delay", delay, "error", err)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+func (f *Fetcher) shouldRetry(ctx context.Context, resp *http.Response, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+func (f *Fetcher) fetch(ctx context.Context, pageURL string, cache *PageCache) ([]byte, string, bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	cached := cache.load(pageURL)
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := f.Do(req)
+	if err != nil {
+		return nil, "", false, err
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		cache.notModified.Add(1)
+		cache.touch(pageURL)
+		slog.DebugContext(ctx, "tracker page not modified", "url", req.URL.Redacted())
+		return cached.Body, cached.ContentType, true, nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", false, fmt.Errorf("bad status: %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
+	if err != nil {
+		return nil, "", false, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+
+	if cache == nil {
+		return body, contentType, false, nil
+	}
+
+	hash := bodyHash(body)
+	unchanged := cached != nil && cached.BodyHash == hash
+	if unchanged {
+		cache.unchanged.Add(1)
+		slog.DebugContext(ctx, "tracker page unchanged", "url", req.URL.Redacted())
+	} else {
+		cache.misses.Add(1)
+	}
+
+	cache.storeFor(ctx, pageURL, &cacheEntry{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		ContentType:  contentType,
+		Source:       cacheSource(ctx),
+		BodyHash:     hash,
+		Body:         body,
+		UpdatedAt:    time.Now(),
+	})
+
+	return body, contentType, unchanged, nil
+}
+
+func (f *Fetcher) FetchRaw(ctx context.Context, pageURL string) ([]byte, string, error) {
+	body, contentType, _, err := f.fetch(ctx, pageURL, nil)
+	return body, contentType, err
+}
+
+func (f *Fetcher) Fetch(ctx context.Context, pageURL string) ([]byte, error) {
+	body, contentType, _, err := f.fetch(ctx, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeBody(body, contentType)
+}
+
+func (f *Fetcher) FetchIfChanged(ctx context.Context, pageURL string) ([]byte, error) {
+	body, unchanged, err := f.fetchChanged(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	if unchanged && skipUnchanged(ctx) {
+		return nil, ErrNotModified
+	}
+	return body, nil
+}
+
+func (f *Fetcher) fetchChanged(ctx context.Context, pageURL string) ([]byte, bool, error) {
+	body, contentType, unchanged, err := f.fetch(ctx, pageURL, f.cache)
+	if err != nil {
+		return nil, false, err
+	}
+	body, err = decodeBody(body, contentType)
+	return body, unchanged, err
+}
+
+func decodeBody(body []byte, contentType string) ([]byte, error) {
+	utf8Reader, err := charset.NewReader(bytes.NewReader(body), contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	return io.ReadAll(utf8Reader)
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
@@ -3,24 +3,39 @@ package providers
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
 	"net/url"
+	"sort"
 	"strings"
-	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
-	"magnet-feed-sync/app/utils"
+	"magnet-feed-sync/app/types"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
 )
 
 type JackettProvider struct {
+	torznabParser
 	baseURL string
+	apiKey  string
+	fetcher *Fetcher
 }
 
-func NewJackettProvider(baseURL string) *JackettProvider {
+func NewJackettProvider(baseURL, apiKey string, fetcher *Fetcher) *JackettProvider {
 	u, err := url.Parse(baseURL)
 	if err != nil || u.Host == "" {
-		return &JackettProvider{baseURL: strings.TrimRight(baseURL, "/")}
+		return &JackettProvider{torznabParser: torznabParser{source: "jackett"}, baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, fetcher: fetcher}
+	}
+	if apiKey == "" {
+		apiKey = u.Query().Get("apikey")
 	}
 	u.User = nil
 	u.RawQuery = ""
@@ -29,7 +44,7 @@ func NewJackettProvider(baseURL string) *JackettProvider {
 		u.Path = u.Path[:idx]
 	}
 	u.Path = strings.TrimRight(u.Path, "/")
-	return &JackettProvider{baseURL: u.String()}
+	return &JackettProvider{torznabParser: torznabParser{source: "jackett"}, baseURL: u.String(), apiKey: apiKey, fetcher: fetcher}
 }
 
 func (p *JackettProvider) CanHandle(u string) bool {
@@ -64,7 +79,10 @@ func (p *JackettProvider) Parse(ctx context.Context, pageURL string) (*Result, e
 	ctx, span := otel.Tracer("tracker").Start(ctx, "JackettProvider.Parse")
 	defer span.End()
 
-	body, err := fetchPage(ctx, pageURL)
+	body, err := fetcherOrDefault(p.fetcher).FetchIfChanged(ctx, pageURL)
+	if errors.Is(err, ErrNotModified) {
+		return nil, err
+	}
 	if err != nil {
 		err = fmt.Errorf("failed to fetch jackett page: %w", err)
 		span.RecordError(err)
@@ -81,103 +99,114 @@ func (p *JackettProvider) Parse(ctx context.Context, pageURL string) (*Result, e
 	return result, nil
 }
 
-func (p *JackettProvider) parseXML(data []byte, originalURL string) (*Result, error) {
-	var rss torznabRSS
-	if err := xml.Unmarshal(data, &rss); err != nil {
-		return nil, fmt.Errorf("failed to parse jackett XML: %w", err)
-	}
+func (p *JackettProvider) Search(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	ctx, span := otel.Tracer("tracker").Start(ctx, "JackettProvider.Search")
+	defer span.End()
 
-	if len(rss.Channel.Items) == 0 {
-		return nil, fmt.Errorf("no items found in jackett response")
+	results, err := p.search(ctx, query)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
 	}
+	return results, nil
+}
 
-	item := rss.Channel.Items[0]
+func (p *JackettProvider) search(ctx context.Context, query types.SearchQuery) ([]types.SearchResult, error) {
+	if p.apiKey == "" {
+		return nil, fmt.Errorf("jackett api key is not configured")
+	}
+	if strings.TrimSpace(query.Query) == "" {
+		return nil, fmt.Errorf("empty search query")
+	}
 
-	magnet := p.extractMagnet(item)
-	if magnet == "" {
-		return nil, fmt.Errorf("no magnet link found in jackett response")
+	data, err := p.fetchSearch(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jackett: %w", err)
 	}
 
-	trackerURL := p.extractTrackerURL(item)
-	id := p.extractID(trackerURL, originalURL)
-	if id == "" {
-		id = utils.ExtractBtihHash(magnet)
+	var torznabErr torznabError
+	if xml.Unmarshal(data, &torznabErr) == nil {
+		return nil, fmt.Errorf("jackett error %s: %s", torznabErr.Code, torznabErr.Description)
 	}
 
-	var updatedAt time.Time
-	if item.PubDate != "" {
-		parsed, err := time.Parse(time.RFC1123Z, item.PubDate)
-		if err != nil {
-			parsed, _ = time.Parse(time.RFC1123, item.PubDate)
-		}
-		updatedAt = parsed
+	var rss torznabRSS
+	if err := xml.Unmarshal(data, &rss); err != nil {
+		return nil, fmt.Errorf("failed to parse jackett XML: %w", err)
 	}
 
-	return &Result{
-		ID:         id,
-		Title:      item.Title,
-		Magnet:     magnet,
-		UpdatedAt:  updatedAt,
-		TrackerURL: trackerURL,
-	}, nil
-}
+	results := make([]types.SearchResult, 0, len(rss.Channel.Items))
+	for _, item := range rss.Channel.Items {
+		magnet, link := p.extractMagnet(item), p.extractLink(item)
+		if magnet == "" && link == "" {
+			continue
+		}
+		results = append(results, types.SearchResult{
+			Title:       item.Title,
+			Indexer:     p.indexerName(item),
+			TrackerURL:  p.extractTrackerURL(item),
+			Magnet:      magnet,
+			Link:        link,
+			Torrent:     p.extractTorrentInfo(item),
+			PublishedAt: p.parsePubDate(item.PubDate),
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Torrent.Seeders != results[j].Torrent.Seeders {
+			return results[i].Torrent.Seeders > results[j].Torrent.Seeders
+		}
+		return results[i].PublishedAt.After(results[j].PublishedAt)
+	})
 
-func (p *JackettProvider) extractMagnet(item torznabItem) string {
-	if strings.HasPrefix(item.Link, "magnet:") {
-		return item.Link
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultSearchLimit
 	}
-	if item.Enclosure.URL != "" && strings.HasPrefix(item.Enclosure.URL, "magnet:") {
-		return item.Enclosure.URL
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
 	}
-	return ""
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	return results, nil
 }
 
-func (p *JackettProvider) extractTrackerURL(item torznabItem) string {
-	if item.Comments != "" && strings.HasPrefix(item.Comments, "http") {
-		return item.Comments
+func (p *JackettProvider) fetchSearch(ctx context.Context, query types.SearchQuery) ([]byte, error) {
+	indexer := query.Indexer
+	if indexer == "" {
+		indexer = "all"
 	}
-	if item.GUID != "" && strings.HasPrefix(item.GUID, "http") {
-		return item.GUID
+	endpoint := fmt.Sprintf("%s/api/v2.0/indexers/%s/results/torznab/api", p.baseURL, url.PathEscape(indexer))
+
+	params := url.Values{"apikey": {p.apiKey}, "t": {"search"}, "q": {query.Query}}
+	if query.Category != "" {
+		params.Set("cat", query.Category)
 	}
-	return ""
-}
 
-func (p *JackettProvider) extractID(trackerURL, originalURL string) string {
-	if trackerURL != "" {
-		if u, err := url.Parse(trackerURL); err == nil {
-			if t := u.Query().Get("t"); t != "" {
-				return t
-			}
-		}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
 	}
 
-	if u, err := url.Parse(originalURL); err == nil {
-		if id := u.Query().Get("id"); id != "" {
-			return id
+	resp, err := fetcherOrDefault(p.fetcher).Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = endpoint
 		}
+		return nil, err
 	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("error closing response body", "error", err)
+		}
+	}()
 
-	return ""
-}
-
-type torznabRSS struct {
-	XMLName xml.Name        `xml:"rss"`
-	Channel torznabChannel  `xml:"channel"`
-}
-
-type torznabChannel struct {
-	Items []torznabItem `xml:"item"`
-}
-
-type torznabItem struct {
-	Title     string           `xml:"title"`
-	GUID      string           `xml:"guid"`
-	Comments  string           `xml:"comments"`
-	Link      string           `xml:"link"`
-	PubDate   string           `xml:"pubDate"`
-	Enclosure torznabEnclosure `xml:"enclosure"`
-}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad status: %s", resp.Status)
+	}
 
-type torznabEnclosure struct {
-	URL string `xml:"url,attr"`
+	return io.ReadAll(io.LimitReader(resp.Body, maxResponseSize))
 }
@@ -2,13 +2,16 @@ package providers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/types"
 )
 
 func TestJackettProvider_CanHandle(t *testing.T) {
@@ -94,7 +97,7 @@ func TestJackettProvider_CanHandle(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			provider := NewJackettProvider(tt.baseURL)
+			provider := NewJackettProvider(tt.baseURL, "", nil)
 			assert.Equal(t, tt.want, provider.CanHandle(tt.url))
 		})
 	}
@@ -110,7 +113,7 @@ func TestJackettProvider_Parse_ValidResponse(t *testing.T) {
 	}))
 	defer server.Close()
 
-	provider := NewJackettProvider(server.URL)
+	provider := NewJackettProvider(server.URL, "", nil)
 
 	result, err := provider.Parse(context.Background(), server.URL+"/api/v2.0/indexers/rutracker/results/torznab?apikey=KEY&t=details&id=6810475")
 	require.NoError(t, err)
@@ -122,6 +125,51 @@ func TestJackettProvider_Parse_ValidResponse(t *testing.T) {
 	assert.False(t, result.UpdatedAt.IsZero())
 }
 
+func TestJackettProvider_Parse_TorznabAttributes(t *testing.T) {
+	fixtureData, err := os.ReadFile("testdata/jackett_rutracker_attrs.xml")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		_, _ = w.Write(fixtureData)
+	}))
+	defer server.Close()
+
+	provider := NewJackettProvider(server.URL, "", nil)
+
+	result, err := provider.Parse(context.Background(), server.URL+"/api/v2.0/indexers/rutracker/results/torznab?apikey=KEY&t=details&id=6810475")
+	require.NoError(t, err)
+
+	assert.Equal(t, types.TorrentInfo{
+		Size:      52428800000,
+		Seeders:   150,
+		Leechers:  30,
+		Category:  "TV/UHD",
+		FileCount: 8,
+	}, result.Torrent)
+}
+
+func TestJackettProvider_CategoryName(t *testing.T) {
+	tests := []struct {
+		name       string
+		categories []string
+		want       string
+	}{
+		{name: "most specific standard category", categories: []string{"5000", "5040", "100509"}, want: "TV/HD"},
+		{name: "unknown subcategory falls back to parent", categories: []string{"2090"}, want: "Movies"},
+		{name: "unknown category", categories: []string{"9000"}, want: "9000"},
+		{name: "only indexer categories", categories: []string{"100509"}, want: ""},
+		{name: "none", want: ""},
+	}
+
+	provider := &JackettProvider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, provider.categoryName(tt.categories))
+		})
+	}
+}
+
 func TestJackettProvider_Parse_EmptyResponse(t *testing.T) {
 	fixtureData, err := os.ReadFile("testdata/jackett_empty.xml")
 	require.NoError(t, err)
@@ -132,7 +180,7 @@ func TestJackettProvider_Parse_EmptyResponse(t *testing.T) {
 	}))
 	defer server.Close()
 
-	provider := NewJackettProvider(server.URL)
+	provider := NewJackettProvider(server.URL, "", nil)
 
 	_, err = provider.Parse(context.Background(), server.URL+"/api/v2.0/indexers/rutracker/results/torznab")
 	assert.Error(t, err)
@@ -149,7 +197,7 @@ func TestJackettProvider_Parse_NoTrackerURL(t *testing.T) {
 	}))
 	defer server.Close()
 
-	provider := NewJackettProvider(server.URL)
+	provider := NewJackettProvider(server.URL, "", nil)
 
 	result, err := provider.Parse(context.Background(), server.URL+"/api/v2.0/indexers/test/results/torznab?id=12345")
 	require.NoError(t, err)
@@ -170,7 +218,7 @@ func TestJackettProvider_Parse_EnclosureMagnet(t *testing.T) {
 	}))
 	defer server.Close()
 
-	provider := NewJackettProvider(server.URL)
+	provider := NewJackettProvider(server.URL, "", nil)
 
 	result, err := provider.Parse(context.Background(), server.URL+"/api/v2.0/indexers/nnm/results/torznab")
 	require.NoError(t, err)
@@ -214,7 +262,7 @@ func TestJackettProvider_TrackerURL_Extraction(t *testing.T) {
 		},
 	}
 
-	provider := NewJackettProvider("http://localhost:9117")
+	provider := NewJackettProvider("http://localhost:9117", "", nil)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			item := torznabItem{
@@ -258,7 +306,7 @@ func TestJackettProvider_ExtractID(t *testing.T) {
 		},
 	}
 
-	provider := NewJackettProvider("http://nas:9117")
+	provider := NewJackettProvider("http://nas:9117", "", nil)
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			got := provider.extractID(tt.trackerURL, tt.originalURL)
@@ -274,9 +322,94 @@ func TestJackettProvider_Parse_InvalidXML(t *testing.T) {
 	}))
 	defer server.Close()
 
-	provider := NewJackettProvider(server.URL)
+	provider := NewJackettProvider(server.URL, "", nil)
 
 	_, err := provider.Parse(context.Background(), server.URL+"/api/v2.0/indexers/test/results/torznab")
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "failed to parse jackett XML")
 }
+
+func newJackettSearchServer(t *testing.T, fixture string, gotQuery *url.Values, gotPath *string) *httptest.Server {
+	t.Helper()
+
+	fixtureData, err := os.ReadFile(fixture)
+	require.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*gotPath = r.URL.Path
+		*gotQuery = r.URL.Query()
+		w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		_, _ = w.Write(fixtureData)
+	}))
+}
+
+func TestJackettProvider_Search(t *testing.T) {
+	var gotQuery url.Values
+	var gotPath string
+	server := newJackettSearchServer(t, "testdata/jackett_search.xml", &gotQuery, &gotPath)
+	defer server.Close()
+
+	provider := NewJackettProvider(server.URL, "KEY", nil)
+
+	results, err := provider.Search(context.Background(), types.SearchQuery{Query: "severance s02", Category: "5000"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/v2.0/indexers/all/results/torznab/api", gotPath)
+	assert.Equal(t, "KEY", gotQuery.Get("apikey"))
+	assert.Equal(t, "search", gotQuery.Get("t"))
+	assert.Equal(t, "severance s02", gotQuery.Get("q"))
+	assert.Equal(t, "5000", gotQuery.Get("cat"))
+
+	require.Len(t, results, 3, "items without magnet or link are skipped")
+
+	assert.Equal(t, "Severance S02 2160p WEB-DL DDP5.1 HDR DoVi Hybrid HEVC-FLUX", results[0].Title)
+	assert.Equal(t, "RuTracker", results[0].Indexer)
+	assert.Equal(t, "https://rutracker.org/forum/viewtopic.php?t=6810475", results[0].TrackerURL)
+	assert.Equal(t, "magnet:?xt=urn:btih:abc123def456&dn=Severance+S02", results[0].Magnet)
+	assert.Equal(t, types.TorrentInfo{Size: 52428800000, Seeders: 150, Leechers: 30, Category: "TV/UHD"}, results[0].Torrent)
+
+	assert.Equal(t, "Severance S02 720p", results[1].Title, "equal seeders are ranked by date")
+	assert.Empty(t, results[1].TrackerURL)
+	assert.Equal(t, "magnet:?xt=urn:btih:fedcba654321&dn=Severance+S02+720p", results[1].Source())
+
+	assert.Equal(t, "Kinozal", results[2].Indexer)
+	assert.Empty(t, results[2].Magnet)
+	assert.Equal(t, "https://jackett.example.com/dl/kinozal/?jackett_apikey=KEY&path=abc&file=Severance", results[2].Source())
+}
+
+func TestJackettProvider_Search_IndexerAndLimit(t *testing.T) {
+	var gotQuery url.Values
+	var gotPath string
+	server := newJackettSearchServer(t, "testdata/jackett_search.xml", &gotQuery, &gotPath)
+	defer server.Close()
+
+	provider := NewJackettProvider(server.URL+"/api/v2.0/indexers/all/results/torznab?apikey=URLKEY", "", nil)
+
+	results, err := provider.Search(context.Background(), types.SearchQuery{Query: "severance", Indexer: "rutracker", Limit: 1})
+	require.NoError(t, err)
+
+	assert.Equal(t, "/api/v2.0/indexers/rutracker/results/torznab/api", gotPath)
+	assert.Equal(t, "URLKEY", gotQuery.Get("apikey"), "api key falls back to the one in JACKETT_URL")
+	assert.False(t, gotQuery.Has("cat"))
+	require.Len(t, results, 1)
+	assert.Equal(t, "RuTracker", results[0].Indexer)
+}
+
+func TestJackettProvider_Search_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Invalid API Key" />`)
+	}))
+	defer server.Close()
+
+	_, err := NewJackettProvider(server.URL, "WRONG", nil).Search(context.Background(), types.SearchQuery{Query: "severance"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Invalid API Key")
+
+	_, err = NewJackettProvider(server.URL, "", nil).Search(context.Background(), types.SearchQuery{Query: "severance"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "api key is not configured")
+
+	_, err = NewJackettProvider(server.URL, "KEY", nil).Search(context.Background(), types.SearchQuery{Query: "  "})
+	require.Error(t, err)
+}
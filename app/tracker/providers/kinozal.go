@@ -0,0 +1,183 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+type KinozalProvider struct {
+	fetcher *Fetcher
+}
+
+const KinozalUrl = "https://kinozal.tv"
+
+var kinozalInfoHashRe = regexp.MustCompile(`(?i)инфо хеш:\s*([0-9a-f]{40})`)
+
+func NewKinozalProvider(fetcher *Fetcher) *KinozalProvider {
+	return &KinozalProvider{fetcher: fetcher}
+}
+
+func (p *KinozalProvider) CanHandle(u string) bool {
+	return strings.HasPrefix(u, KinozalUrl+"/details.php")
+}
+
+func (p *KinozalProvider) Parse(ctx context.Context, pageURL string) (*Result, error) {
+	ctx, span := otel.Tracer("tracker").Start(ctx, "KinozalProvider.Parse")
+	defer span.End()
+
+	body, err := fetcherOrDefault(p.fetcher).FetchIfChanged(ctx, pageURL)
+	if errors.Is(err, ErrNotModified) {
+		return nil, err
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to fetch kinozal page: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	if err != nil {
+		err = fmt.Errorf("failed to parse kinozal HTML: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	id := p.getID(pageURL)
+	hash, err := p.getInfoHash(ctx, pageURL, id)
+	if err != nil {
+		err = fmt.Errorf("failed to get kinozal info hash: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	title := p.getTitle(doc)
+
+	return &Result{
+		ID:        id,
+		Title:     title,
+		Magnet:    p.buildMagnet(hash, title),
+		UpdatedAt: p.getLastUpdatedDate(doc),
+		Comment:   p.getLastComment(doc),
+		Torrent:   p.getTorrentInfo(doc),
+	}, nil
+}
+
+func (p *KinozalProvider) getInfoHash(ctx context.Context, pageURL, id string) (string, error) {
+	if id == "" {
+		return "", fmt.Errorf("no torrent id in kinozal url")
+	}
+
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse kinozal url: %w", err)
+	}
+	u.Path = "/get_srv_details.php"
+	u.RawQuery = url.Values{"id": {id}, "action": {"2"}}.Encode()
+
+	body, err := fetcherOrDefault(p.fetcher).Fetch(ctx, u.String())
+	if err != nil {
+		return "", err
+	}
+
+	match := kinozalInfoHashRe.FindStringSubmatch(string(body))
+	if match == nil {
+		return "", fmt.Errorf("no info hash found in kinozal details")
+	}
+
+	return strings.ToUpper(match[1]), nil
+}
+
+func (p *KinozalProvider) buildMagnet(hash, title string) string {
+	magnet := "magnet:?xt=urn:btih:" + hash
+	if title != "" {
+		magnet += "&dn=" + url.QueryEscape(title)
+	}
+	return magnet
+}
+
+func (p *KinozalProvider) getTitle(doc *goquery.Document) string {
+	title := strings.TrimSpace(doc.Find("h1 a").First().Text())
+	if title == "" {
+		slog.Warn("title not found in kinozal page")
+	}
+	return title
+}
+
+func (p *KinozalProvider) getID(originalUrl string) string {
+	u, err := url.Parse(originalUrl)
+	if err != nil {
+		slog.Error("failed to parse kinozal url", "url", originalUrl, "error", err)
+		return ""
+	}
+	return u.Query().Get("id")
+}
+
+func (p *KinozalProvider) getMenuValues(doc *goquery.Document) map[string]string {
+	values := make(map[string]string)
+	doc.Find("ul.men li").Each(func(i int, s *goquery.Selection) {
+		label := strings.TrimSpace(s.Contents().First().Text())
+		values[label] = strings.TrimSpace(s.Find("span.floatright").Text())
+	})
+	return values
+}
+
+func (p *KinozalProvider) getTorrentInfo(doc *goquery.Document) types.TorrentInfo {
+	values := p.getMenuValues(doc)
+	info := types.TorrentInfo{
+		Seeders:  parseCount(values["Раздают"]),
+		Leechers: parseCount(values["Скачивают"]),
+	}
+
+	if category, ok := doc.Find("img.cat_img_r").Attr("title"); ok {
+		info.Category = strings.TrimSpace(category)
+	}
+
+	if rawSize := values["Размер"]; rawSize != "" {
+		size, err := utils.ParseSize(rawSize)
+		if err != nil {
+			slog.Error("failed to parse kinozal torrent size", "size", rawSize, "error", err)
+		}
+		info.Size = size
+	}
+
+	return info
+}
+
+func (p *KinozalProvider) getLastUpdatedDate(doc *goquery.Document) time.Time {
+	dates := p.getMenuValues(doc)
+	for _, label := range []string{"Обновлен", "Залит"} {
+		rawDate := dates[label]
+		if rawDate == "" {
+			continue
+		}
+
+		date, err := utils.ParseKinozalDate(rawDate, time.Now().UTC())
+		if err != nil {
+			slog.Error("failed to parse kinozal torrent date", "date", rawDate, "error", err)
+			continue
+		}
+		return date
+	}
+
+	slog.Warn("no date found in kinozal page")
+	return time.Time{}
+}
+
+func (p *KinozalProvider) getLastComment(doc *goquery.Document) string {
+	return strings.TrimSpace(doc.Find("#comments .cmm_t").Last().Text())
+}
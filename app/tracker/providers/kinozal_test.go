@@ -0,0 +1,140 @@
+package providers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/types"
+)
+
+func newKinozalServer(t *testing.T, detailsData []byte) *httptest.Server {
+	t.Helper()
+
+	pageData, err := os.ReadFile("testdata/kinozal_1977338.html")
+	require.NoError(t, err)
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		switch r.URL.Path {
+		case "/details.php":
+			_, _ = w.Write(pageData)
+		case "/get_srv_details.php":
+			if r.URL.Query().Get("id") != "1977338" || r.URL.Query().Get("action") != "2" {
+				http.NotFound(w, r)
+				return
+			}
+			_, _ = w.Write(detailsData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestKinozalProvider_CanHandle(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{
+			name: "valid kinozal url",
+			url:  "https://kinozal.tv/details.php?id=1977338",
+			want: true,
+		},
+		{
+			name: "kinozal browse url",
+			url:  "https://kinozal.tv/browse.php?s=last",
+			want: false,
+		},
+		{
+			name: "non-kinozal url",
+			url:  "https://rutracker.org/forum/viewtopic.php?t=6810475",
+			want: false,
+		},
+		{
+			name: "empty url",
+			url:  "",
+			want: false,
+		},
+	}
+
+	provider := &KinozalProvider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, provider.CanHandle(tt.url))
+		})
+	}
+}
+
+func TestKinozalProvider_Parse(t *testing.T) {
+	detailsData, err := os.ReadFile("testdata/kinozal_1977338_srv_details.html")
+	require.NoError(t, err)
+
+	server := newKinozalServer(t, detailsData)
+	defer server.Close()
+
+	provider := &KinozalProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/details.php?id=1977338")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1977338", result.ID)
+	assert.Equal(t, "Одни из нас (1 сезон: 1-9 серии из 9) / The Last of Us / 2023 / ПМ (LostFilm) / WEB-DLRip", result.Title)
+	assert.Contains(t, result.Magnet, "magnet:?xt=urn:btih:3E4D5C8F1A2B3C4D5E6F708192A3B4C5D6E7F809&dn=")
+	assert.Equal(t, time.Date(2023, 3, 14, 9, 47, 0, 0, time.UTC), result.UpdatedAt)
+	assert.Equal(t, "Добавлена 9 серия, перекачайте торрент-файл.", result.Comment)
+	assert.Equal(t, types.TorrentInfo{Size: 9086470144, Seeders: 214, Leechers: 12, Category: "Сериал - Буржуйский"}, result.Torrent)
+	assert.Empty(t, result.TrackerURL)
+}
+
+func TestKinozalProvider_Parse_NoInfoHash(t *testing.T) {
+	server := newKinozalServer(t, []byte("<ul><li>Торрент-файл недоступен</li></ul>"))
+	defer server.Close()
+
+	provider := &KinozalProvider{}
+
+	_, err := provider.Parse(context.Background(), server.URL+"/details.php?id=1977338")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no info hash found")
+}
+
+func TestKinozalProvider_Parse_NoID(t *testing.T) {
+	detailsData, err := os.ReadFile("testdata/kinozal_1977338_srv_details.html")
+	require.NoError(t, err)
+
+	server := newKinozalServer(t, detailsData)
+	defer server.Close()
+
+	provider := &KinozalProvider{}
+
+	_, err = provider.Parse(context.Background(), server.URL+"/details.php")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no torrent id")
+}
+
+func TestKinozalProvider_Parse_NotModified(t *testing.T) {
+	detailsData, err := os.ReadFile("testdata/kinozal_1977338_srv_details.html")
+	require.NoError(t, err)
+
+	server := newKinozalServer(t, detailsData)
+	defer server.Close()
+
+	provider := NewKinozalProvider(newCachedFetcher(t, t.TempDir()))
+	ctx := SkipUnchanged(context.Background())
+
+	_, err = provider.Parse(ctx, server.URL+"/details.php?id=1977338")
+	require.NoError(t, err)
+
+	_, err = provider.Parse(ctx, server.URL+"/details.php?id=1977338")
+	require.ErrorIs(t, err, ErrNotModified)
+
+	result, err := provider.Parse(context.Background(), server.URL+"/details.php?id=1977338")
+	require.NoError(t, err)
+	assert.Equal(t, "1977338", result.ID)
+}
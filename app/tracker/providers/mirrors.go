@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+type mirrorSet struct {
+	hosts     []string
+	preferred string
+}
+
+func normalizeHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
+
+func (m mirrorSet) match(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && slices.Contains(m.hosts, normalizeHost(u.Host))
+}
+
+func (m mirrorSet) canonical(u *url.URL) *url.URL {
+	c := *u
+	c.Fragment = ""
+	c.RawFragment = ""
+	if m.match(u) {
+		c.Scheme = "https"
+		c.Host = m.hosts[0]
+	}
+	return &c
+}
+
+func (m mirrorSet) candidates(u *url.URL) []string {
+	if !m.match(u) {
+		return []string{u.String()}
+	}
+
+	hosts := make([]string, 0, len(m.hosts)+1)
+	if preferred := normalizeHost(m.preferred); preferred != "" {
+		hosts = append(hosts, preferred)
+	}
+	hosts = append(hosts, normalizeHost(u.Host))
+	hosts = append(hosts, m.hosts...)
+
+	var urls []string
+	seen := make(map[string]bool)
+	for _, host := range hosts {
+		if seen[host] {
+			continue
+		}
+		seen[host] = true
+
+		c := *u
+		c.Scheme = "https"
+		c.Host = host
+		c.Fragment = ""
+		c.RawFragment = ""
+		urls = append(urls, c.String())
+	}
+	return urls
+}
+
+func fetchFromMirrors(ctx context.Context, fetcher *Fetcher, candidates []string) ([]byte, string, error) {
+	body, fetchedURL, unchanged, err := fetchChangedFromMirrors(ctx, fetcher, candidates)
+	if err == nil && unchanged && skipUnchanged(ctx) {
+		return nil, fetchedURL, ErrNotModified
+	}
+	return body, fetchedURL, err
+}
+
+func fetchChangedFromMirrors(ctx context.Context, fetcher *Fetcher, candidates []string) ([]byte, string, bool, error) {
+	var errs []error
+	for _, candidate := range candidates {
+		body, unchanged, err := fetcher.fetchChanged(ctx, candidate)
+		if err == nil {
+			return body, candidate, unchanged, nil
+		}
+		if ctx.Err() != nil {
+			return nil, "", false, ctx.Err()
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", candidate, err))
+	}
+	return nil, "", false, errors.Join(errs...)
+}
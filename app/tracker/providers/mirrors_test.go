@@ -0,0 +1,79 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirrorSet_Candidates(t *testing.T) {
+	tests := []struct {
+		name      string
+		preferred string
+		url       string
+		want      []string
+	}{
+		{
+			name: "canonical host first",
+			url:  "https://rutracker.org/forum/viewtopic.php?t=1",
+			want: []string{
+				"https://rutracker.org/forum/viewtopic.php?t=1",
+				"https://rutracker.net/forum/viewtopic.php?t=1",
+				"https://rutracker.nl/forum/viewtopic.php?t=1",
+			},
+		},
+		{
+			name:      "preferred mirror first",
+			preferred: "WWW.Rutracker.NET",
+			url:       "http://www.rutracker.org/forum/viewtopic.php?t=1#post",
+			want: []string{
+				"https://rutracker.net/forum/viewtopic.php?t=1",
+				"https://rutracker.org/forum/viewtopic.php?t=1",
+				"https://rutracker.nl/forum/viewtopic.php?t=1",
+			},
+		},
+		{
+			name: "unknown host is fetched as is",
+			url:  "http://127.0.0.1:8080/forum/viewtopic.php?t=1",
+			want: []string{"http://127.0.0.1:8080/forum/viewtopic.php?t=1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+
+			m := mirrorSet{hosts: rutrackerHosts, preferred: tt.preferred}
+			assert.Equal(t, tt.want, m.candidates(u))
+		})
+	}
+}
+
+func TestFetchFromMirrors_FallsBackWhenBlocked(t *testing.T) {
+	blocked := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "blocked", http.StatusForbidden)
+	}))
+	defer blocked.Close()
+
+	mirror := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, "ok")
+	}))
+	defer mirror.Close()
+
+	body, fetchedURL, err := fetchFromMirrors(context.Background(), defaultFetcher, []string{blocked.URL, mirror.URL})
+	require.NoError(t, err)
+	assert.Equal(t, "ok", string(body))
+	assert.Equal(t, mirror.URL, fetchedURL)
+
+	_, _, err = fetchFromMirrors(context.Background(), defaultFetcher, []string{blocked.URL})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "403")
+}
@@ -1,7 +1,9 @@
 package providers
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/url"
@@ -12,22 +14,44 @@ import (
 	"github.com/mmcdole/gofeed"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
+	"magnet-feed-sync/app/types"
 	"magnet-feed-sync/app/utils"
 )
 
-type NnmProvider struct{}
+type NnmProvider struct {
+	mirror  string
+	fetcher *Fetcher
+}
 
 const NnmUrl = "https://nnmclub.to/forum"
 
+var nnmHosts = []string{"nnmclub.to", "nnmclub.me", "nnm-club.me"}
+
+func NewNnmProvider(mirror string, fetcher *Fetcher) *NnmProvider {
+	return &NnmProvider{mirror: mirror, fetcher: fetcher}
+}
+
 func (p *NnmProvider) CanHandle(u string) bool {
-	return strings.HasPrefix(u, NnmUrl)
+	parsed, err := url.Parse(u)
+	return err == nil && p.mirrors().match(parsed) && strings.HasPrefix(parsed.Path, "/forum/")
 }
 
 func (p *NnmProvider) Parse(ctx context.Context, pageURL string) (*Result, error) {
 	ctx, span := otel.Tracer("tracker").Start(ctx, "NnmProvider.Parse")
 	defer span.End()
 
-	body, err := fetchPage(ctx, pageURL)
+	topicURL, err := p.canonicalURL(pageURL)
+	if err != nil {
+		err = fmt.Errorf("failed to parse nnm url: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	body, fetchedURL, err := fetchFromMirrors(ctx, fetcherOrDefault(p.fetcher), p.mirrors().candidates(topicURL))
+	if errors.Is(err, ErrNotModified) {
+		return nil, err
+	}
 	if err != nil {
 		err = fmt.Errorf("failed to fetch nnm page: %w", err)
 		span.RecordError(err)
@@ -51,13 +75,47 @@ func (p *NnmProvider) Parse(ctx context.Context, pageURL string) (*Result, error
 		return nil, err
 	}
 
-	return &Result{
-		ID:        p.getID(pageURL),
+	id := p.getID(topicURL.String())
+	if id == "" {
+		id = p.getTopicID(doc)
+	}
+
+	result := &Result{
+		ID:        id,
 		Title:     p.getTitle(doc),
 		Magnet:    magnet,
 		UpdatedAt: p.getLastUpdatedDate(doc),
-		Comment:   p.getLastComment(doc),
-	}, nil
+		Comment:   p.getLastComment(ctx, doc, fetchedURL),
+		Torrent:   p.getTorrentInfo(doc),
+	}
+	if p.mirrors().match(topicURL) && id != "" {
+		result.TrackerURL = fmt.Sprintf("%s/viewtopic.php?t=%s", NnmUrl, id)
+	}
+
+	return result, nil
+}
+
+func (p *NnmProvider) mirrors() mirrorSet {
+	return mirrorSet{hosts: nnmHosts, preferred: p.mirror}
+}
+
+func (p *NnmProvider) canonicalURL(pageURL string) (*url.URL, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c := p.mirrors().canonical(u)
+	if p.mirrors().match(u) {
+		query := url.Values{}
+		if t := c.Query().Get("t"); t != "" {
+			query.Set("t", t)
+		} else if post := c.Query().Get("p"); post != "" {
+			query.Set("p", post)
+		}
+		c.RawQuery = query.Encode()
+	}
+	return c, nil
 }
 
 func (p *NnmProvider) getMagnetLink(doc *goquery.Document) string {
@@ -92,6 +150,15 @@ func (p *NnmProvider) getID(originalUrl string) string {
 	return u.Query().Get("t")
 }
 
+func (p *NnmProvider) getTopicID(doc *goquery.Document) string {
+	href, _ := doc.Find("a.maintitle").Attr("href")
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("t")
+}
+
 func (p *NnmProvider) getLastUpdatedDate(doc *goquery.Document) (registrationDate time.Time) {
 	doc.Find("tr.row1").Each(func(i int, s *goquery.Selection) {
 		label := s.Find("td.genmed").First().Text()
@@ -108,15 +175,43 @@ func (p *NnmProvider) getLastUpdatedDate(doc *goquery.Document) (registrationDat
 	return registrationDate
 }
 
-func (p *NnmProvider) getLastComment(doc *goquery.Document) string {
-	rssLink := p.getRssLink(doc)
+func (p *NnmProvider) getTorrentInfo(doc *goquery.Document) types.TorrentInfo {
+	info := types.TorrentInfo{
+		Seeders:  parseCount(doc.Find("span.seed b").First().Text()),
+		Leechers: parseCount(doc.Find("span.leech b").First().Text()),
+		Category: strings.TrimSpace(doc.Find("span.nav a.nav").Last().Text()),
+	}
+
+	doc.Find("tr.row1").Each(func(i int, s *goquery.Selection) {
+		label := s.Find("td.genmed").First().Text()
+		if strings.Contains(label, "Размер:") {
+			rawSize := strings.TrimSpace(s.Find("td.genmed").Last().Text())
+			size, err := utils.ParseSize(rawSize)
+			if err != nil {
+				slog.Error("failed to parse nnm torrent size", "size", rawSize, "error", err)
+			}
+			info.Size = size
+		}
+	})
+
+	return info
+}
+
+func (p *NnmProvider) getLastComment(ctx context.Context, doc *goquery.Document, pageURL string) string {
+	rssLink := p.getRssLink(doc, pageURL)
 	if rssLink == "" {
 		slog.Warn("rss link not found in nnm page")
 		return ""
 	}
 
+	rss, _, err := fetcherOrDefault(p.fetcher).FetchRaw(ctx, rssLink)
+	if err != nil {
+		slog.Error("failed to fetch rss feed", "error", err)
+		return ""
+	}
+
 	fp := gofeed.NewParser()
-	feed, err := fp.ParseURL(rssLink)
+	feed, err := fp.Parse(bytes.NewReader(rss))
 	if err != nil || feed == nil {
 		slog.Error("failed to parse rss feed", "error", err)
 		return ""
@@ -149,7 +244,7 @@ func (p *NnmProvider) getLastComment(doc *goquery.Document) string {
 	return strings.TrimSpace(commentDoc.Find("span.postbody").Text())
 }
 
-func (p *NnmProvider) getRssLink(doc *goquery.Document) string {
+func (p *NnmProvider) getRssLink(doc *goquery.Document, pageURL string) string {
 	var rssLink string
 
 	doc.Find("td a").Each(func(index int, item *goquery.Selection) {
@@ -163,5 +258,13 @@ func (p *NnmProvider) getRssLink(doc *goquery.Document) string {
 		return ""
 	}
 
-	return fmt.Sprintf("%s/%s", NnmUrl, rssLink)
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	ref, err := base.Parse(rssLink)
+	if err != nil {
+		return ""
+	}
+	return ref.String()
 }
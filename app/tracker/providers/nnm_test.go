@@ -0,0 +1,77 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/types"
+)
+
+func TestNnmProvider_CanHandle(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{name: "valid nnm url", url: "https://nnmclub.to/forum/viewtopic.php?t=1234567", want: true},
+		{name: "mirror", url: "https://nnmclub.me/forum/viewtopic.php?t=1234567", want: true},
+		{name: "http and www", url: "http://www.nnm-club.me/forum/viewtopic.php?p=7654321#7654321", want: true},
+		{name: "non-nnm url", url: "https://rutracker.org/forum/viewtopic.php?t=123", want: false},
+		{name: "empty url", url: "", want: false},
+	}
+
+	provider := &NnmProvider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, provider.CanHandle(tt.url))
+		})
+	}
+}
+
+func TestNnmProvider_Parse_TopicIDFromPostLink(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body>
+			<a class="maintitle" href="viewtopic.php?t=1234567">Test NNM Torrent</a>
+			<a href="magnet:?xt=urn:btih:abc123&dn=test">magnet</a>
+		</body></html>`)
+	}))
+	defer server.Close()
+
+	provider := &NnmProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?p=7654321#7654321")
+	require.NoError(t, err)
+
+	assert.Equal(t, "1234567", result.ID)
+	assert.Empty(t, result.TrackerURL)
+}
+
+func TestNnmProvider_Parse_TorrentInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body>
+			<span class="nav"><a class="nav" href="index.php">Торрент-трекер</a> &raquo;
+				<a class="nav" href="viewforum.php?f=768">Зарубежные сериалы</a></span>
+			<a class="maintitle" href="viewtopic.php?t=1234567">Test NNM Torrent</a>
+			<table>
+				<tr class="row1"><td class="genmed">&nbsp;Размер:&nbsp;</td><td class="genmed">&nbsp;1.46 GB (1 567 663 104 bytes)</td></tr>
+			</table>
+			<span class="seed">Раздают: <b>12</b></span> <span class="leech">Качают: <b>3</b></span>
+			<a href="magnet:?xt=urn:btih:abc123&dn=test">magnet</a>
+		</body></html>`)
+	}))
+	defer server.Close()
+
+	provider := &NnmProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=1234567")
+	require.NoError(t, err)
+
+	assert.Equal(t, types.TorrentInfo{Size: 1567663104, Seeders: 12, Leechers: 3, Category: "Зарубежные сериалы"}, result.Torrent)
+}
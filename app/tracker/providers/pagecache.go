@@ -0,0 +1,301 @@
+package providers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var ErrNotModified = errors.New("tracker page not modified")
+
+type skipUnchangedKey struct{}
+
+func SkipUnchanged(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipUnchangedKey{}, true)
+}
+
+func skipUnchanged(ctx context.Context) bool {
+	skip, _ := ctx.Value(skipUnchangedKey{}).(bool)
+	return skip
+}
+
+type cacheSourceKey struct{}
+
+func WithCacheSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, cacheSourceKey{}, source)
+}
+
+func cacheSource(ctx context.Context) string {
+	source, _ := ctx.Value(cacheSourceKey{}).(string)
+	return source
+}
+
+type deferredPagesKey struct{}
+
+type deferredPages struct {
+	mu      sync.Mutex
+	entries []deferredPage
+}
+
+type deferredPage struct {
+	cache   *PageCache
+	pageURL string
+	entry   *cacheEntry
+}
+
+func DeferPageCache(ctx context.Context) (context.Context, func()) {
+	pages := &deferredPages{}
+	return context.WithValue(ctx, deferredPagesKey{}, pages), pages.commit
+}
+
+func (p *deferredPages) add(cache *PageCache, pageURL string, entry *cacheEntry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.entries = append(p.entries, deferredPage{cache: cache, pageURL: pageURL, entry: entry})
+}
+
+func (p *deferredPages) commit() {
+	p.mu.Lock()
+	entries := p.entries
+	p.entries = nil
+	p.mu.Unlock()
+
+	for _, page := range entries {
+		page.cache.store(page.pageURL, page.entry)
+	}
+}
+
+type cacheEntry struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	ContentType  string    `json:"content_type,omitempty"`
+	Source       string    `json:"source,omitempty"`
+	BodyHash     string    `json:"body_hash"`
+	Body         []byte    `json:"body"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type PageCacheConfig struct {
+	Dir        string
+	MaxAge     time.Duration
+	MaxEntries int
+}
+
+type PageCache struct {
+	dir        string
+	maxAge     time.Duration
+	maxEntries int
+	mu         sync.Mutex
+
+	notModified atomic.Int64
+	unchanged   atomic.Int64
+	misses      atomic.Int64
+}
+
+func NewPageCache(cfg PageCacheConfig) (*PageCache, error) {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create page cache directory: %w", err)
+	}
+
+	c := &PageCache{dir: cfg.Dir, maxAge: cfg.MaxAge, maxEntries: cfg.MaxEntries}
+	c.mu.Lock()
+	c.prune()
+	c.mu.Unlock()
+	return c, nil
+}
+
+func (c *PageCache) path(pageURL string) string {
+	key := sha256.Sum256([]byte(pageURL))
+	return filepath.Join(c.dir, hex.EncodeToString(key[:])+".json")
+}
+
+func (c *PageCache) load(pageURL string) *cacheEntry {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data, err := os.ReadFile(c.path(pageURL))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			slog.Warn("failed to read page cache entry", "error", err)
+		}
+		return nil
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		slog.Warn("failed to decode page cache entry", "error", err)
+		return nil
+	}
+	if c.expired(entry.UpdatedAt) {
+		return nil
+	}
+	return &entry
+}
+
+func (c *PageCache) touch(pageURL string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if err := os.Chtimes(c.path(pageURL), now, now); err != nil {
+		slog.Warn("failed to touch page cache entry", "error", err)
+	}
+}
+
+func (c *PageCache) store(pageURL string, entry *cacheEntry) {
+	if c == nil {
+		return
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		slog.Warn("failed to encode page cache entry", "error", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	path := c.path(pageURL)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		slog.Warn("failed to write page cache entry", "error", err)
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		slog.Warn("failed to write page cache entry", "error", err)
+	}
+	c.prune()
+}
+
+func (c *PageCache) Forget(source string) {
+	if c == nil || source == "" {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, file := range c.files() {
+		data, err := os.ReadFile(file.path)
+		if err != nil {
+			continue
+		}
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) != nil || entry.Source != source {
+			continue
+		}
+		c.remove(file.path)
+	}
+}
+
+type cacheFile struct {
+	path    string
+	modTime time.Time
+}
+
+func (c *PageCache) files() []cacheFile {
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		slog.Warn("failed to list page cache entries", "error", err)
+		return nil
+	}
+
+	var files []cacheFile
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, cacheFile{path: filepath.Join(c.dir, entry.Name()), modTime: info.ModTime()})
+	}
+	return files
+}
+
+func (c *PageCache) prune() {
+	if c.maxAge <= 0 && c.maxEntries <= 0 {
+		return
+	}
+
+	files := slices.DeleteFunc(c.files(), func(file cacheFile) bool {
+		if c.expired(file.modTime) {
+			c.remove(file.path)
+			return true
+		}
+		return false
+	})
+
+	if c.maxEntries <= 0 || len(files) <= c.maxEntries {
+		return
+	}
+	slices.SortFunc(files, func(a, b cacheFile) int {
+		return a.modTime.Compare(b.modTime)
+	})
+	for _, file := range files[:len(files)-c.maxEntries] {
+		c.remove(file.path)
+	}
+}
+
+func (c *PageCache) expired(updatedAt time.Time) bool {
+	return c.maxAge > 0 && time.Since(updatedAt) > c.maxAge
+}
+
+func (c *PageCache) remove(path string) {
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Warn("failed to remove page cache entry", "error", err)
+	}
+}
+
+func (c *PageCache) storeFor(ctx context.Context, pageURL string, entry *cacheEntry) {
+	if pages, ok := ctx.Value(deferredPagesKey{}).(*deferredPages); ok {
+		pages.add(c, pageURL, entry)
+		return
+	}
+	c.store(pageURL, entry)
+}
+
+func bodyHash(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+func (c *PageCache) LogStats() {
+	if c == nil {
+		return
+	}
+
+	notModified, unchanged, misses := c.notModified.Load(), c.unchanged.Load(), c.misses.Load()
+	total := notModified + unchanged + misses
+	hitRate := 0.0
+	if total > 0 {
+		hitRate = float64(notModified+unchanged) / float64(total)
+	}
+	slog.Info("tracker page cache stats",
+		"requests", total,
+		"not_modified", notModified,
+		"unchanged", unchanged,
+		"misses", misses,
+		"hit_rate", fmt.Sprintf("%.0f%%", hitRate*100),
+	)
+}
@@ -0,0 +1,231 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCachedFetcher(t *testing.T, dir string) *Fetcher {
+	t.Helper()
+	return newBoundedFetcher(t, PageCacheConfig{Dir: dir})
+}
+
+func newBoundedFetcher(t *testing.T, cfg PageCacheConfig) *Fetcher {
+	t.Helper()
+
+	cache, err := NewPageCache(cfg)
+	require.NoError(t, err)
+	fetcher, err := NewFetcher(FetcherConfig{Cache: cache})
+	require.NoError(t, err)
+	return fetcher
+}
+
+func TestPageCache_ConditionalRequest(t *testing.T) {
+	var ifNoneMatch, ifModifiedSince string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ifNoneMatch = r.Header.Get("If-None-Match")
+		ifModifiedSince = r.Header.Get("If-Modified-Since")
+		if ifNoneMatch == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Last-Modified", "Tue, 14 Mar 2023 09:47:00 GMT")
+		fmt.Fprint(w, "topic page")
+	}))
+	defer server.Close()
+
+	fetcher := newCachedFetcher(t, t.TempDir())
+	ctx := SkipUnchanged(context.Background())
+
+	body, err := fetcher.FetchIfChanged(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "topic page", string(body))
+	assert.Empty(t, ifNoneMatch)
+
+	_, err = fetcher.FetchIfChanged(ctx, server.URL)
+	require.ErrorIs(t, err, ErrNotModified)
+	assert.Equal(t, `"v1"`, ifNoneMatch)
+	assert.Equal(t, "Tue, 14 Mar 2023 09:47:00 GMT", ifModifiedSince)
+
+	body, err = fetcher.FetchIfChanged(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "topic page", string(body), "304 should be served from the cache")
+
+	assert.Equal(t, int64(2), fetcher.cache.notModified.Load())
+	assert.Equal(t, int64(1), fetcher.cache.misses.Load())
+}
+
+func TestPageCache_UnchangedBody(t *testing.T) {
+	page := "first version"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, page)
+	}))
+	defer server.Close()
+
+	fetcher := newCachedFetcher(t, t.TempDir())
+	ctx := SkipUnchanged(context.Background())
+
+	_, err := fetcher.FetchIfChanged(ctx, server.URL)
+	require.NoError(t, err)
+
+	body, err := fetcher.FetchIfChanged(context.Background(), server.URL)
+	require.NoError(t, err, "unchanged pages are only skipped when the context opts in")
+	assert.Equal(t, "first version", string(body))
+
+	_, err = fetcher.FetchIfChanged(ctx, server.URL)
+	require.ErrorIs(t, err, ErrNotModified)
+
+	page = "second version"
+	body, err = fetcher.FetchIfChanged(ctx, server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, "second version", string(body))
+
+	assert.Equal(t, int64(2), fetcher.cache.unchanged.Load())
+	assert.Equal(t, int64(2), fetcher.cache.misses.Load())
+}
+
+func TestPageCache_PersistsOnDisk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "topic page")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	_, err := newCachedFetcher(t, dir).FetchIfChanged(context.Background(), server.URL+"/?apikey=secret")
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	data, err := os.ReadFile(filepath.Join(dir, entries[0].Name()))
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+
+	_, err = newCachedFetcher(t, dir).FetchIfChanged(SkipUnchanged(context.Background()), server.URL+"/?apikey=secret")
+	require.ErrorIs(t, err, ErrNotModified)
+}
+
+func TestPageCache_DeferredUntilCommit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "topic page")
+	}))
+	defer server.Close()
+
+	fetcher := newCachedFetcher(t, t.TempDir())
+
+	ctx, _ := DeferPageCache(SkipUnchanged(context.Background()))
+	_, err := fetcher.FetchIfChanged(ctx, server.URL)
+	require.NoError(t, err)
+
+	ctx, commit := DeferPageCache(SkipUnchanged(context.Background()))
+	_, err = fetcher.FetchIfChanged(ctx, server.URL)
+	require.NoError(t, err, "uncommitted page should be fetched again")
+	commit()
+
+	_, err = fetcher.FetchIfChanged(SkipUnchanged(context.Background()), server.URL)
+	require.ErrorIs(t, err, ErrNotModified)
+}
+
+func TestPageCache_OnlyTopicPagesCached(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "search results")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	fetcher := newCachedFetcher(t, dir)
+
+	_, err := fetcher.Fetch(context.Background(), server.URL+"/search?q=test")
+	require.NoError(t, err)
+	_, _, err = fetcher.FetchRaw(context.Background(), server.URL+"/dl.php?t=1")
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestPageCache_MaxEntries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "topic page")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	fetcher := newBoundedFetcher(t, PageCacheConfig{Dir: dir, MaxEntries: 2})
+	ctx := SkipUnchanged(context.Background())
+
+	for i, page := range []string{"/a", "/b"} {
+		_, err := fetcher.FetchIfChanged(ctx, server.URL+page)
+		require.NoError(t, err)
+		old := time.Now().Add(time.Duration(i-10) * time.Minute)
+		require.NoError(t, os.Chtimes(fetcher.cache.path(server.URL+page), old, old))
+	}
+	_, err := fetcher.FetchIfChanged(ctx, server.URL+"/c")
+	require.NoError(t, err)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	_, err = fetcher.FetchIfChanged(ctx, server.URL+"/a")
+	require.NoError(t, err, "the oldest entry should have been evicted")
+	_, err = fetcher.FetchIfChanged(ctx, server.URL+"/c")
+	require.ErrorIs(t, err, ErrNotModified)
+}
+
+func TestPageCache_MaxAge(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "topic page")
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	fetcher := newCachedFetcher(t, dir)
+	_, err := fetcher.FetchIfChanged(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(fetcher.cache.path(server.URL), old, old))
+
+	newBoundedFetcher(t, PageCacheConfig{Dir: dir, MaxAge: time.Hour})
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "expired entries should be pruned on startup")
+}
+
+func TestPageCache_Forget(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "topic page")
+	}))
+	defer server.Close()
+
+	fetcher := newCachedFetcher(t, t.TempDir())
+	removed := WithCacheSource(context.Background(), "https://rutracker.org/forum/viewtopic.php?t=1")
+	kept := WithCacheSource(context.Background(), "https://rutracker.org/forum/viewtopic.php?t=2")
+
+	for _, page := range []string{"/t1", "/t1?start=30"} {
+		_, err := fetcher.FetchIfChanged(removed, server.URL+page)
+		require.NoError(t, err)
+	}
+	_, err := fetcher.FetchIfChanged(kept, server.URL+"/t2")
+	require.NoError(t, err)
+
+	fetcher.cache.Forget("https://rutracker.org/forum/viewtopic.php?t=1")
+
+	_, err = fetcher.FetchIfChanged(SkipUnchanged(removed), server.URL+"/t1")
+	require.NoError(t, err, "forgotten page should be fetched again")
+	_, err = fetcher.FetchIfChanged(SkipUnchanged(kept), server.URL+"/t2")
+	require.ErrorIs(t, err, ErrNotModified)
+}
@@ -0,0 +1,110 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+)
+
+var (
+	prowlarrIndexerPath   = regexp.MustCompile(`^/\d+/api/?$`)
+	prowlarrIndexerSuffix = regexp.MustCompile(`/\d+/api$`)
+)
+
+type ProwlarrProvider struct {
+	torznabParser
+	baseURL string
+	apiKey  string
+	fetcher *Fetcher
+}
+
+func NewProwlarrProvider(baseURL, apiKey string, fetcher *Fetcher) *ProwlarrProvider {
+	u, err := url.Parse(baseURL)
+	if err != nil || u.Host == "" {
+		return &ProwlarrProvider{torznabParser: torznabParser{source: "prowlarr"}, baseURL: strings.TrimRight(baseURL, "/"), apiKey: apiKey, fetcher: fetcher}
+	}
+	if apiKey == "" {
+		apiKey = u.Query().Get("apikey")
+	}
+	u.User = nil
+	u.RawQuery = ""
+	u.Fragment = ""
+	u.Path = prowlarrIndexerSuffix.ReplaceAllString(strings.TrimRight(u.Path, "/"), "")
+	return &ProwlarrProvider{torznabParser: torznabParser{source: "prowlarr"}, baseURL: u.String(), apiKey: apiKey, fetcher: fetcher}
+}
+
+func (p *ProwlarrProvider) CanHandle(u string) bool {
+	if p.baseURL == "" {
+		return false
+	}
+	parsed, err := url.Parse(u)
+	if err != nil || parsed.Host == "" || parsed.User != nil {
+		return false
+	}
+	base, err := url.Parse(p.baseURL)
+	if err != nil || base.Host == "" {
+		return false
+	}
+	if parsed.Scheme != base.Scheme || parsed.Host != base.Host {
+		return false
+	}
+	if base.Path != "" && !strings.HasPrefix(parsed.Path, base.Path+"/") {
+		return false
+	}
+	return prowlarrIndexerPath.MatchString(strings.TrimPrefix(parsed.Path, base.Path))
+}
+
+func (p *ProwlarrProvider) RestoresAPIKey() bool {
+	return p.apiKey != ""
+}
+
+func (p *ProwlarrProvider) Parse(ctx context.Context, pageURL string) (*Result, error) {
+	ctx, span := otel.Tracer("tracker").Start(ctx, "ProwlarrProvider.Parse")
+	defer span.End()
+
+	body, err := fetcherOrDefault(p.fetcher).FetchIfChanged(ctx, p.withAPIKey(pageURL))
+	if errors.Is(err, ErrNotModified) {
+		return nil, err
+	}
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = pageURL
+		}
+		err = fmt.Errorf("failed to fetch prowlarr page: %w", err)
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	result, err := p.parseXML(body, pageURL)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	return result, nil
+}
+
+func (p *ProwlarrProvider) withAPIKey(pageURL string) string {
+	if p.apiKey == "" {
+		return pageURL
+	}
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return pageURL
+	}
+	q := u.Query()
+	if q.Get("apikey") != "" {
+		return pageURL
+	}
+	q.Set("apikey", p.apiKey)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
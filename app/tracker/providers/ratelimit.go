@@ -0,0 +1,107 @@
+package providers
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type RateLimit struct {
+	RequestsPerMinute int
+	MinInterval       time.Duration
+	Concurrency       int
+}
+
+type rateLimiter struct {
+	limit  RateLimit
+	window time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+type hostLimiter struct {
+	mu     sync.Mutex
+	next   time.Time
+	starts []time.Time
+	slots  chan struct{}
+}
+
+func newRateLimiter(limit RateLimit) *rateLimiter {
+	if limit == (RateLimit{}) {
+		return nil
+	}
+	return &rateLimiter{limit: limit, window: time.Minute, hosts: make(map[string]*hostLimiter)}
+}
+
+func (r *rateLimiter) host(host string) *hostLimiter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	h, ok := r.hosts[host]
+	if !ok {
+		h = &hostLimiter{}
+		if r.limit.Concurrency > 0 {
+			h.slots = make(chan struct{}, r.limit.Concurrency)
+		}
+		r.hosts[host] = h
+	}
+	return h
+}
+
+func (r *rateLimiter) wait(ctx context.Context, host string) (func(), error) {
+	if r == nil {
+		return func() {}, nil
+	}
+
+	h := r.host(host)
+	if h.slots != nil {
+		select {
+		case h.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if h.slots != nil {
+			<-h.slots
+		}
+	}
+
+	for {
+		delay := r.reserve(h, time.Now())
+		if delay <= 0 {
+			var once sync.Once
+			return func() { once.Do(release) }, nil
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (r *rateLimiter) reserve(h *hostLimiter, now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	cutoff := now.Add(-r.window)
+	for len(h.starts) > 0 && !h.starts[0].After(cutoff) {
+		h.starts = h.starts[1:]
+	}
+
+	delay := h.next.Sub(now)
+	if r.limit.RequestsPerMinute > 0 && len(h.starts) >= r.limit.RequestsPerMinute {
+		delay = max(delay, h.starts[0].Add(r.window).Sub(now))
+	}
+	if delay > 0 {
+		return delay
+	}
+
+	h.starts = append(h.starts, now)
+	h.next = now.Add(r.limit.MinInterval)
+	return 0
+}
@@ -0,0 +1,129 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter_Reserve(t *testing.T) {
+	start := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("min interval", func(t *testing.T) {
+		r := newRateLimiter(RateLimit{MinInterval: 2 * time.Second})
+		h := r.host("rutracker.org")
+
+		assert.Zero(t, r.reserve(h, start))
+		assert.Equal(t, 2*time.Second, r.reserve(h, start))
+		assert.Equal(t, 500*time.Millisecond, r.reserve(h, start.Add(1500*time.Millisecond)))
+		assert.Zero(t, r.reserve(h, start.Add(2*time.Second)))
+	})
+
+	t.Run("requests per minute", func(t *testing.T) {
+		r := newRateLimiter(RateLimit{RequestsPerMinute: 2})
+		h := r.host("rutracker.org")
+
+		assert.Zero(t, r.reserve(h, start))
+		assert.Zero(t, r.reserve(h, start.Add(10*time.Second)))
+		assert.Equal(t, 40*time.Second, r.reserve(h, start.Add(20*time.Second)))
+		assert.Zero(t, r.reserve(h, start.Add(time.Minute)))
+		assert.Equal(t, 10*time.Second, r.reserve(h, start.Add(time.Minute)))
+	})
+
+	t.Run("hosts are limited separately", func(t *testing.T) {
+		r := newRateLimiter(RateLimit{MinInterval: time.Hour})
+
+		assert.Zero(t, r.reserve(r.host("rutracker.org"), start))
+		assert.Zero(t, r.reserve(r.host("nnmclub.to"), start))
+		assert.Equal(t, time.Hour, r.reserve(r.host("rutracker.org"), start))
+	})
+
+	t.Run("no limits", func(t *testing.T) {
+		assert.Nil(t, newRateLimiter(RateLimit{}))
+	})
+}
+
+func TestFetcher_RateLimit_Concurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if current <= seen || maxInFlight.CompareAndSwap(seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	fetcher, err := NewFetcher(FetcherConfig{RateLimit: RateLimit{Concurrency: 1}})
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := fetcher.Fetch(context.Background(), server.URL)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), maxInFlight.Load())
+}
+
+func TestFetcher_RateLimit_MinInterval(t *testing.T) {
+	var mu sync.Mutex
+	var starts []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		starts = append(starts, time.Now())
+		mu.Unlock()
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	fetcher, err := NewFetcher(FetcherConfig{RateLimit: RateLimit{MinInterval: 50 * time.Millisecond}})
+	require.NoError(t, err)
+
+	for range 3 {
+		_, err := fetcher.Fetch(context.Background(), server.URL)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, starts, 3)
+	for i := 1; i < len(starts); i++ {
+		assert.GreaterOrEqual(t, starts[i].Sub(starts[i-1]), 45*time.Millisecond)
+	}
+}
+
+func TestFetcher_RateLimit_ContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "ok")
+	}))
+	defer server.Close()
+
+	fetcher, err := NewFetcher(FetcherConfig{RateLimit: RateLimit{MinInterval: time.Hour}})
+	require.NoError(t, err)
+
+	_, err = fetcher.Fetch(context.Background(), server.URL)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = fetcher.Fetch(ctx, server.URL)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
@@ -0,0 +1,204 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+type RutorProvider struct {
+	fetcher *Fetcher
+}
+
+const (
+	RutorUrl             = "https://rutor.info/torrent/"
+	maxRutorRelatedHops  = 5
+	rutorDateLayout      = "02-01-2006 15:04:05"
+	rutorRelatedHeader   = "Связанные раздачи"
+	rutorAddedDateHeader = "Добавлен"
+)
+
+func NewRutorProvider(fetcher *Fetcher) *RutorProvider {
+	return &RutorProvider{fetcher: fetcher}
+}
+
+func (p *RutorProvider) CanHandle(u string) bool {
+	return strings.HasPrefix(u, RutorUrl)
+}
+
+func (p *RutorProvider) Parse(ctx context.Context, pageURL string) (*Result, error) {
+	ctx, span := otel.Tracer("tracker").Start(ctx, "RutorProvider.Parse")
+	defer span.End()
+
+	id := p.getID(pageURL)
+	topicURL := pageURL
+
+	var doc *goquery.Document
+	for hop := 0; ; hop++ {
+		var err error
+		doc, err = p.fetchDocument(ctx, topicURL)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+
+		newer := p.getNewestRelatedURL(doc, topicURL)
+		if newer == "" || hop == maxRutorRelatedHops {
+			break
+		}
+		slog.InfoContext(ctx, "following rutor related topic", "from", topicURL, "to", newer)
+		topicURL = newer
+	}
+
+	magnet := p.getMagnetLink(doc)
+	if magnet == "" {
+		err := fmt.Errorf("no magnet link found in rutor page")
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+
+	return &Result{
+		ID:        id,
+		Title:     p.getTitle(doc),
+		Magnet:    magnet,
+		UpdatedAt: p.getAddedDate(doc),
+		Comment:   p.getLastComment(doc),
+		Torrent:   p.getTorrentInfo(doc),
+	}, nil
+}
+
+func (p *RutorProvider) fetchDocument(ctx context.Context, pageURL string) (*goquery.Document, error) {
+	body, err := fetcherOrDefault(p.fetcher).Fetch(ctx, pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch rutor page: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rutor HTML: %w", err)
+	}
+
+	return doc, nil
+}
+
+func (p *RutorProvider) getMagnetLink(doc *goquery.Document) string {
+	magnetLink, exists := doc.Find(`div#download a[href^="magnet:"]`).Attr("href")
+	if !exists {
+		slog.Warn("magnet link not found in rutor page")
+	}
+	return magnetLink
+}
+
+func (p *RutorProvider) getTitle(doc *goquery.Document) string {
+	title := strings.TrimSpace(doc.Find("div#all h1").First().Text())
+	if title == "" {
+		slog.Warn("title not found in rutor page")
+	}
+	return title
+}
+
+func (p *RutorProvider) getID(originalUrl string) string {
+	u, err := url.Parse(originalUrl)
+	if err != nil {
+		slog.Error("failed to parse rutor url", "url", originalUrl, "error", err)
+		return ""
+	}
+
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) < 2 || parts[0] != "torrent" {
+		return ""
+	}
+	return parts[1]
+}
+
+func (p *RutorProvider) getDetailsRow(doc *goquery.Document, header string) *goquery.Selection {
+	return doc.Find("table#details tr").FilterFunction(func(i int, s *goquery.Selection) bool {
+		return strings.TrimSpace(s.Find("td.header").First().Text()) == header
+	}).First()
+}
+
+func (p *RutorProvider) getDetailsValue(doc *goquery.Document, header string) string {
+	row := p.getDetailsRow(doc, header)
+	if row.Length() == 0 {
+		return ""
+	}
+	return strings.TrimSpace(row.Find("td").Last().Text())
+}
+
+func (p *RutorProvider) getTorrentInfo(doc *goquery.Document) types.TorrentInfo {
+	info := types.TorrentInfo{
+		Seeders:  parseCount(p.getDetailsValue(doc, "Раздают")),
+		Leechers: parseCount(p.getDetailsValue(doc, "Качают")),
+		Category: p.getDetailsValue(doc, "Категория"),
+	}
+
+	if rawSize := p.getDetailsValue(doc, "Размер"); rawSize != "" {
+		size, err := utils.ParseSize(rawSize)
+		if err != nil {
+			slog.Error("failed to parse rutor torrent size", "size", rawSize, "error", err)
+		}
+		info.Size = size
+	}
+
+	return info
+}
+
+func (p *RutorProvider) getAddedDate(doc *goquery.Document) time.Time {
+	fields := strings.Fields(p.getDetailsRow(doc, rutorAddedDateHeader).Find("td").Last().Text())
+	if len(fields) < 2 {
+		slog.Warn("no date found in rutor page")
+		return time.Time{}
+	}
+
+	rawDate := fields[0] + " " + fields[1]
+	date, err := time.Parse(rutorDateLayout, rawDate)
+	if err != nil {
+		slog.Error("failed to parse rutor torrent added date", "date", rawDate, "error", err)
+		return time.Time{}
+	}
+	return date
+}
+
+func (p *RutorProvider) getNewestRelatedURL(doc *goquery.Document, pageURL string) string {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+
+	currentID, _ := strconv.Atoi(p.getID(pageURL))
+	newestID := currentID
+	var newestURL string
+
+	p.getDetailsRow(doc, rutorRelatedHeader).Find(`a[href*="/torrent/"]`).Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		ref, err := base.Parse(href)
+		if err != nil {
+			return
+		}
+
+		id, err := strconv.Atoi(p.getID(ref.String()))
+		if err != nil || id <= newestID {
+			return
+		}
+		newestID = id
+		newestURL = ref.String()
+	})
+
+	return newestURL
+}
+
+func (p *RutorProvider) getLastComment(doc *goquery.Document) string {
+	return strings.TrimSpace(doc.Find("#comments td.c_p").Last().Text())
+}
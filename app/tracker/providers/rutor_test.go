@@ -0,0 +1,161 @@
+package providers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/types"
+)
+
+func newRutorServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	pages := make(map[string][]byte)
+	for _, id := range []string{"900001", "905555"} {
+		data, err := os.ReadFile("testdata/rutor_" + id + ".html")
+		require.NoError(t, err)
+		pages[id] = data
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) < 2 || pages[parts[1]] == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(pages[parts[1]])
+	}))
+}
+
+func TestRutorProvider_CanHandle(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{
+			name: "valid rutor url",
+			url:  "https://rutor.info/torrent/905555/odni-iz-nas-1-sezon-1-9-serii",
+			want: true,
+		},
+		{
+			name: "rutor search url",
+			url:  "https://rutor.info/search/0/0/100/0/The%20Last%20of%20Us",
+			want: false,
+		},
+		{
+			name: "non-rutor url",
+			url:  "https://nnmclub.to/forum/viewtopic.php?t=123",
+			want: false,
+		},
+		{
+			name: "empty url",
+			url:  "",
+			want: false,
+		},
+	}
+
+	provider := &RutorProvider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, provider.CanHandle(tt.url))
+		})
+	}
+}
+
+func TestRutorProvider_Parse(t *testing.T) {
+	server := newRutorServer(t)
+	defer server.Close()
+
+	provider := &RutorProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/torrent/905555/odni-iz-nas-1-sezon-1-9-serii")
+	require.NoError(t, err)
+
+	assert.Equal(t, "905555", result.ID)
+	assert.Equal(t, "Одни из нас / The Last of Us [S01E01-09] (2023) WEB-DLRip | LostFilm", result.Title)
+	assert.Equal(t, "magnet:?xt=urn:btih:5555555555555555555555555555555555555555&dn=rutor.info&tr=udp://opentor.net:6969", result.Magnet)
+	assert.Equal(t, time.Date(2023, 3, 14, 9, 47, 12, 0, time.UTC), result.UpdatedAt)
+	assert.Equal(t, "Сезон завершён, отличное качество", result.Comment)
+	assert.Equal(t, types.TorrentInfo{Size: 7891934003, Seeders: 128, Leechers: 15, Category: "Сериалы"}, result.Torrent)
+	assert.Empty(t, result.TrackerURL)
+}
+
+func TestRutorProvider_Parse_FollowsRelatedTopic(t *testing.T) {
+	server := newRutorServer(t)
+	defer server.Close()
+
+	provider := &RutorProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/torrent/900001/odni-iz-nas-1-sezon-1-5-serii")
+	require.NoError(t, err)
+
+	assert.Equal(t, "900001", result.ID)
+	assert.Equal(t, "Одни из нас / The Last of Us [S01E01-09] (2023) WEB-DLRip | LostFilm", result.Title)
+	assert.Contains(t, result.Magnet, "urn:btih:5555555555555555555555555555555555555555")
+	assert.Equal(t, time.Date(2023, 3, 14, 9, 47, 12, 0, time.UTC), result.UpdatedAt)
+}
+
+func TestRutorProvider_Parse_LimitsRelatedHops(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<html><body><div id="all"><h1>Topic %d</h1>
+			<div id="download"><a href="magnet:?xt=urn:btih:%040d">m</a></div>
+			<table id="details"><tr><td class="header">Связанные раздачи</td><td><a href="/torrent/%d/next">next</a></td></tr></table>
+			</div></body></html>`, requests, requests, 100+requests)
+	}))
+	defer server.Close()
+
+	provider := &RutorProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/torrent/100/first")
+	require.NoError(t, err)
+
+	assert.Equal(t, maxRutorRelatedHops+1, requests)
+	assert.Equal(t, "100", result.ID)
+	assert.Equal(t, fmt.Sprintf("Topic %d", requests), result.Title)
+}
+
+func TestRutorProvider_Parse_NoMagnet(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body><div id="all"><h1>Раздача не найдена</h1></div></body></html>`)
+	}))
+	defer server.Close()
+
+	provider := &RutorProvider{}
+
+	_, err := provider.Parse(context.Background(), server.URL+"/torrent/1/missing")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no magnet link found")
+}
+
+func TestRutorProvider_GetID(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "with slug", url: "https://rutor.info/torrent/905555/odni-iz-nas", want: "905555"},
+		{name: "without slug", url: "https://rutor.info/torrent/905555", want: "905555"},
+		{name: "not a topic", url: "https://rutor.info/search/0/0/100/0/test", want: ""},
+	}
+
+	provider := &RutorProvider{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, provider.getID(tt.url))
+		})
+	}
+}
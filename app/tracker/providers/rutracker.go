@@ -4,45 +4,93 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net/http"
+	"net/http/cookiejar"
 	"net/url"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/PuerkitoBio/goquery"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/codes"
+	"magnet-feed-sync/app/types"
 	"magnet-feed-sync/app/utils"
 )
 
-type RutrackerProvider struct{}
+type RutrackerProvider struct {
+	username string
+	password string
+	mirror   string
+	fetcher  *Fetcher
+	jar      http.CookieJar
+	loginMu  sync.Mutex
+}
 
 const RutrackerUrl = "https://rutracker.org/forum"
 
+const rutrackerSessionCookie = "bb_session"
+
+var rutrackerHosts = []string{"rutracker.org", "rutracker.net", "rutracker.nl"}
+
+func NewRutrackerProvider(username, password, mirror string, fetcher *Fetcher) *RutrackerProvider {
+	jar, _ := cookiejar.New(nil)
+	return &RutrackerProvider{
+		username: username,
+		password: password,
+		mirror:   mirror,
+		fetcher:  fetcherOrDefault(fetcher).withCookieJar(jar),
+		jar:      jar,
+	}
+}
+
 func (p *RutrackerProvider) CanHandle(u string) bool {
-	return strings.HasPrefix(u, RutrackerUrl)
+	parsed, err := url.Parse(u)
+	return err == nil && p.mirrors().match(parsed) && strings.HasPrefix(parsed.Path, "/forum/")
 }
 
 func (p *RutrackerProvider) Parse(ctx context.Context, pageURL string) (*Result, error) {
 	ctx, span := otel.Tracer("tracker").Start(ctx, "RutrackerProvider.Parse")
 	defer span.End()
 
-	body, err := fetchPage(ctx, pageURL)
+	topicURL, err := p.canonicalURL(pageURL)
 	if err != nil {
-		err = fmt.Errorf("failed to fetch rutracker page: %w", err)
+		err = fmt.Errorf("failed to parse rutracker url: %w", err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	doc, fetchedURL, unchanged, err := p.fetchTopic(ctx, topicURL)
 	if err != nil {
-		err = fmt.Errorf("failed to parse rutracker HTML: %w", err)
 		span.RecordError(err)
 		span.SetStatus(codes.Error, err.Error())
 		return nil, err
 	}
 
+	comment, commentChanged := p.getLastComment(ctx, doc, fetchedURL)
+	if unchanged && !commentChanged && skipUnchanged(ctx) {
+		return nil, ErrNotModified
+	}
+
+	id := p.getID(topicURL.String())
+	if id == "" {
+		id = p.getTopicID(doc)
+	}
+
 	magnet := p.getMagnetLink(doc)
+	info := p.getTorrentInfo(doc)
+	var torrentFile []byte
+	if magnet == "" && p.authenticated() {
+		torrentFile, magnet, info.FileCount, err = p.getTorrentFile(ctx, fetchedURL, id)
+		if err != nil {
+			err = fmt.Errorf("failed to fetch rutracker torrent: %w", err)
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+			return nil, err
+		}
+	}
 	if magnet == "" {
 		err = fmt.Errorf("no magnet link found in rutracker page")
 		span.RecordError(err)
@@ -50,13 +98,171 @@ func (p *RutrackerProvider) Parse(ctx context.Context, pageURL string) (*Result,
 		return nil, err
 	}
 
-	return &Result{
-		ID:        p.getID(pageURL),
-		Title:     p.getTitle(doc),
-		Magnet:    magnet,
-		UpdatedAt: p.getLastUpdatedDate(doc),
-		Comment:   p.getLastComment(doc),
-	}, nil
+	result := &Result{
+		ID:          id,
+		Title:       p.getTitle(doc),
+		Magnet:      magnet,
+		TorrentFile: torrentFile,
+		UpdatedAt:   p.getLastUpdatedDate(doc),
+		Comment:     comment,
+		Torrent:     info,
+	}
+	if p.mirrors().match(topicURL) && id != "" {
+		result.TrackerURL = fmt.Sprintf("%s/viewtopic.php?t=%s", RutrackerUrl, id)
+	}
+
+	return result, nil
+}
+
+func (p *RutrackerProvider) mirrors() mirrorSet {
+	return mirrorSet{hosts: rutrackerHosts, preferred: p.mirror}
+}
+
+func (p *RutrackerProvider) canonicalURL(pageURL string) (*url.URL, error) {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	c := p.mirrors().canonical(u)
+	if p.mirrors().match(u) {
+		query := url.Values{}
+		if t := c.Query().Get("t"); t != "" {
+			query.Set("t", t)
+		} else if post := c.Query().Get("p"); post != "" {
+			query.Set("p", post)
+		}
+		c.RawQuery = query.Encode()
+	}
+	return c, nil
+}
+
+func (p *RutrackerProvider) authenticated() bool {
+	return p.username != "" && p.jar != nil
+}
+
+func (p *RutrackerProvider) fetchTopic(ctx context.Context, topicURL *url.URL) (*goquery.Document, string, bool, error) {
+	candidates := p.mirrors().candidates(topicURL)
+	doc, fetchedURL, unchanged, err := p.fetchDocument(ctx, candidates)
+	if !p.authenticated() {
+		return doc, fetchedURL, unchanged, err
+	}
+	if err != nil || p.isLoggedIn(doc) {
+		return doc, fetchedURL, unchanged, err
+	}
+
+	slog.InfoContext(ctx, "rutracker session is missing or expired, logging in")
+	if err := p.login(ctx, fetchedURL); err != nil {
+		return nil, "", false, err
+	}
+
+	return p.fetchDocument(ctx, []string{fetchedURL})
+}
+
+func (p *RutrackerProvider) fetchDocument(ctx context.Context, candidates []string) (*goquery.Document, string, bool, error) {
+	body, fetchedURL, unchanged, err := fetchChangedFromMirrors(ctx, fetcherOrDefault(p.fetcher), candidates)
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to fetch rutracker page: %w", err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+	if err != nil {
+		return nil, "", false, fmt.Errorf("failed to parse rutracker HTML: %w", err)
+	}
+
+	return doc, fetchedURL, unchanged, nil
+}
+
+func (p *RutrackerProvider) isLoggedIn(doc *goquery.Document) bool {
+	return doc.Find("#logged-in-username").Length() > 0
+}
+
+func (p *RutrackerProvider) login(ctx context.Context, pageURL string) error {
+	p.loginMu.Lock()
+	defer p.loginMu.Unlock()
+
+	loginURL, err := resolveRutrackerURL(pageURL, "login.php")
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{"login_username": {p.username}, "login_password": {p.password}}
+	// "вход" in windows-1251, the value rutracker expects from its login button
+	body := form.Encode() + "&login=%E2%F5%EE%E4"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, loginURL.String(), strings.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create rutracker login request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := p.fetcher.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to log in to rutracker: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			slog.Error("error closing response body", "error", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to log in to rutracker: bad status: %s", resp.Status)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to parse rutracker login response: %w", err)
+	}
+
+	if !p.hasSession(loginURL) || !p.isLoggedIn(doc) {
+		return fmt.Errorf("rutracker login failed, check RUTRACKER_USERNAME and RUTRACKER_PASSWORD")
+	}
+
+	return nil
+}
+
+func (p *RutrackerProvider) hasSession(u *url.URL) bool {
+	for _, cookie := range p.jar.Cookies(u) {
+		if cookie.Name == rutrackerSessionCookie && cookie.Value != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *RutrackerProvider) getTorrentFile(ctx context.Context, pageURL, id string) ([]byte, string, int, error) {
+	dlURL, err := resolveRutrackerURL(pageURL, "dl.php?t="+url.QueryEscape(id))
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	data, _, err := p.fetcher.FetchRaw(ctx, dlURL.String())
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if !utils.IsTorrentFile(data) {
+		return nil, "", 0, fmt.Errorf("response is not a torrent file")
+	}
+
+	hash, err := utils.TorrentInfoHash(data)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	files, err := utils.TorrentFileCount(data)
+	if err != nil {
+		return nil, "", 0, err
+	}
+
+	return data, "magnet:?xt=urn:btih:" + hash, files, nil
+}
+
+func resolveRutrackerURL(pageURL, ref string) (*url.URL, error) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rutracker url: %w", err)
+	}
+	return base.Parse(ref)
 }
 
 func (p *RutrackerProvider) getMagnetLink(doc *goquery.Document) string {
@@ -67,6 +273,28 @@ func (p *RutrackerProvider) getMagnetLink(doc *goquery.Document) string {
 	return magnetLink
 }
 
+func (p *RutrackerProvider) getTorrentInfo(doc *goquery.Document) types.TorrentInfo {
+	info := types.TorrentInfo{
+		Seeders:  parseCount(doc.Find("span.seed b").First().Text()),
+		Leechers: parseCount(doc.Find("span.leech b").First().Text()),
+		Category: strings.TrimSpace(doc.Find("td.t-breadcrumb-top a").Last().Text()),
+	}
+
+	rawSize := doc.Find("#tor-size-humn").First().Text()
+	if rawSize == "" {
+		rawSize = doc.Find("fieldset.attach .attach_link li").Last().Text()
+	}
+	if rawSize != "" {
+		size, err := utils.ParseSize(rawSize)
+		if err != nil {
+			slog.Warn("failed to parse rutracker torrent size", "size", rawSize, "error", err)
+		}
+		info.Size = size
+	}
+
+	return info
+}
+
 func (p *RutrackerProvider) getTitle(doc *goquery.Document) string {
 	attempt1 := doc.Find("a#topic-title").Text()
 	if len(attempt1) > 0 {
@@ -96,6 +324,15 @@ func (p *RutrackerProvider) getID(originalUrl string) string {
 	return u.Query().Get("t")
 }
 
+func (p *RutrackerProvider) getTopicID(doc *goquery.Document) string {
+	href, _ := doc.Find("a#topic-title").Attr("href")
+	u, err := url.Parse(href)
+	if err != nil {
+		return ""
+	}
+	return u.Query().Get("t")
+}
+
 func (p *RutrackerProvider) getLastUpdatedDate(doc *goquery.Document) time.Time {
 	firstPost := doc.Find("table#topic_main > tbody").FilterFunction(func(i int, s *goquery.Selection) bool {
 		_, exists := s.Attr("id")
@@ -136,6 +373,67 @@ func (p *RutrackerProvider) getLastUpdatedDate(doc *goquery.Document) time.Time
 	return time.Time{}
 }
 
-func (p *RutrackerProvider) getLastComment(doc *goquery.Document) string {
-	return ""
+func (p *RutrackerProvider) getLastComment(ctx context.Context, doc *goquery.Document, pageURL string) (string, bool) {
+	changed := false
+	lastPageURL := p.getLastPageURL(doc, pageURL)
+	if lastPageURL != "" {
+		body, unchanged, err := fetcherOrDefault(p.fetcher).fetchChanged(ctx, lastPageURL)
+		if err != nil {
+			slog.Error("failed to fetch rutracker last page", "url", lastPageURL, "error", err)
+			return "", false
+		}
+		lastPage, err := goquery.NewDocumentFromReader(strings.NewReader(string(body)))
+		if err != nil {
+			slog.Error("failed to parse rutracker last page", "url", lastPageURL, "error", err)
+			return "", false
+		}
+		doc = lastPage
+		changed = !unchanged
+	}
+
+	posts := doc.Find("table#topic_main > tbody").FilterFunction(func(i int, s *goquery.Selection) bool {
+		id, _ := s.Attr("id")
+		return strings.HasPrefix(id, "post_")
+	})
+	if lastPageURL == "" && p.isFirstPage(pageURL) && posts.Length() < 2 {
+		return "", changed
+	}
+
+	return strings.Join(strings.Fields(posts.Last().Find("div.post_body").Text()), " "), changed
+}
+
+func (p *RutrackerProvider) isFirstPage(pageURL string) bool {
+	u, err := url.Parse(pageURL)
+	if err != nil {
+		return true
+	}
+	start, _ := strconv.Atoi(u.Query().Get("start"))
+	return start == 0
+}
+
+func (p *RutrackerProvider) getLastPageURL(doc *goquery.Document, pageURL string) string {
+	current, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	currentStart, _ := strconv.Atoi(current.Query().Get("start"))
+
+	lastStart := currentStart
+	var lastPageURL string
+	doc.Find("a.pg").Each(func(i int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		ref, err := current.Parse(href)
+		if err != nil {
+			return
+		}
+
+		start, err := strconv.Atoi(ref.Query().Get("start"))
+		if err != nil || start <= lastStart {
+			return
+		}
+		lastStart = start
+		lastPageURL = ref.String()
+	})
+
+	return lastPageURL
 }
@@ -2,14 +2,19 @@ package providers
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
 )
 
 func TestRutrackerProvider_CanHandle(t *testing.T) {
@@ -23,6 +28,26 @@ func TestRutrackerProvider_CanHandle(t *testing.T) {
 			url:  "https://rutracker.org/forum/viewtopic.php?t=6810475",
 			want: true,
 		},
+		{
+			name: "mirror with http and www",
+			url:  "http://www.rutracker.net/forum/viewtopic.php?t=6810475",
+			want: true,
+		},
+		{
+			name: "post link",
+			url:  "https://rutracker.nl/forum/viewtopic.php?p=88773447#88773447",
+			want: true,
+		},
+		{
+			name: "rutracker non-forum url",
+			url:  "https://rutracker.org/static/logo.png",
+			want: false,
+		},
+		{
+			name: "lookalike host",
+			url:  "https://rutracker.org.example.com/forum/viewtopic.php?t=1",
+			want: false,
+		},
 		{
 			name: "non-rutracker url",
 			url:  "https://nnmclub.to/forum/viewtopic.php?t=123",
@@ -63,7 +88,9 @@ func TestRutrackerProvider_Parse(t *testing.T) {
 	assert.NotEmpty(t, result.Magnet)
 	assert.False(t, result.UpdatedAt.IsZero())
 	assert.True(t, result.UpdatedAt.Before(time.Now().Add(-time.Minute)))
+	assert.Empty(t, result.Comment)
 	assert.Empty(t, result.TrackerURL)
+	assert.Equal(t, types.TorrentInfo{Size: 12541304504, Category: "Зарубежные сериалы (HD Video)"}, result.Torrent)
 }
 
 func TestRutrackerProvider_Parse_StableDate(t *testing.T) {
@@ -112,6 +139,93 @@ func TestRutrackerProvider_Parse_3304959(t *testing.T) {
 	assert.Empty(t, result.TrackerURL)
 }
 
+func TestRutrackerProvider_Parse_LastComment(t *testing.T) {
+	firstPage, err := os.ReadFile("testdata/rutracker_3304959.html")
+	require.NoError(t, err)
+	lastPage, err := os.ReadFile("testdata/rutracker_3304959_last.html")
+	require.NoError(t, err)
+
+	var requestedStarts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		start := r.URL.Query().Get("start")
+		requestedStarts = append(requestedStarts, start)
+		if start == "1560" {
+			_, _ = w.Write(lastPage)
+			return
+		}
+		_, _ = w.Write(firstPage)
+	}))
+	defer server.Close()
+
+	provider := &RutrackerProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=3304959")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Добавлены номера за декабрь. Перекачайте торрент-файл, пожалуйста.", result.Comment)
+	assert.Equal(t, []string{"", "1560"}, requestedStarts)
+}
+
+func TestRutrackerProvider_Parse_LastCommentSinglePage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><body>
+			<a class="magnet-link" href="magnet:?xt=urn:btih:abc123">magnet</a>
+			<table id="topic_main">
+				<tbody id="post_1"><tr><td><div class="post_body">Описание раздачи</div></td></tr></tbody>
+				<tbody id="post_2"><tr><td><div class="post_body">Первый ответ</div></td></tr></tbody>
+				<tbody id="post_3"><tr><td><div class="post_body">
+					Последний   ответ
+				</div></td></tr></tbody>
+			</table>
+		</body></html>`)
+	}))
+	defer server.Close()
+
+	provider := &RutrackerProvider{}
+
+	result, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=1")
+	require.NoError(t, err)
+
+	assert.Equal(t, "Последний ответ", result.Comment)
+}
+
+func TestRutrackerProvider_Parse_NewReplyOnLastPage(t *testing.T) {
+	firstPage, err := os.ReadFile("testdata/rutracker_3304959.html")
+	require.NoError(t, err)
+	lastPage, err := os.ReadFile("testdata/rutracker_3304959_last.html")
+	require.NoError(t, err)
+	lastPageReply, err := os.ReadFile("testdata/rutracker_3304959_last_reply.html")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=windows-1251")
+		if r.URL.Query().Get("start") == "1560" {
+			_, _ = w.Write(lastPage)
+			return
+		}
+		_, _ = w.Write(firstPage)
+	}))
+	defer server.Close()
+
+	provider := &RutrackerProvider{fetcher: newCachedFetcher(t, t.TempDir())}
+	ctx := SkipUnchanged(context.Background())
+	topicURL := server.URL + "/forum/viewtopic.php?t=3304959"
+
+	result, err := provider.Parse(ctx, topicURL)
+	require.NoError(t, err)
+	assert.Equal(t, "Добавлены номера за декабрь. Перекачайте торрент-файл, пожалуйста.", result.Comment)
+
+	_, err = provider.Parse(ctx, topicURL)
+	assert.ErrorIs(t, err, ErrNotModified)
+
+	lastPage = lastPageReply
+	result, err = provider.Parse(ctx, topicURL)
+	require.NoError(t, err)
+	assert.Equal(t, "Добавлен номер за январь.", result.Comment)
+}
+
 func TestRutrackerProvider_Parse_3304959_StableDate(t *testing.T) {
 	fixtureData, err := os.ReadFile("testdata/rutracker_3304959.html")
 	require.NoError(t, err)
@@ -163,3 +277,198 @@ func TestRutrackerProvider_GetID(t *testing.T) {
 		})
 	}
 }
+
+type fakeRutracker struct {
+	*httptest.Server
+	mu       sync.Mutex
+	sessions map[string]bool
+	logins   int
+	torrent  []byte
+}
+
+func newFakeRutracker(t *testing.T, torrent []byte) *fakeRutracker {
+	t.Helper()
+
+	f := &fakeRutracker{sessions: make(map[string]bool), torrent: torrent}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeRutracker) expireSessions() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	clear(f.sessions)
+}
+
+func (f *fakeRutracker) loggedIn(r *http.Request) bool {
+	cookie, err := r.Cookie("bb_session")
+	if err != nil {
+		return false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.sessions[cookie.Value]
+}
+
+func (f *fakeRutracker) handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	switch r.URL.Path {
+	case "/forum/login.php":
+		if r.Method != http.MethodPost || r.FormValue("login_username") != "user" || r.FormValue("login_password") != "secret" {
+			fmt.Fprint(w, `<html><body><form id="login-form-full"><input name="login_username"></form></body></html>`)
+			return
+		}
+		f.mu.Lock()
+		f.logins++
+		session := fmt.Sprintf("session-%d", f.logins)
+		f.sessions[session] = true
+		f.mu.Unlock()
+		http.SetCookie(w, &http.Cookie{Name: "bb_session", Value: session, Path: "/forum/"})
+		http.Redirect(w, r, "/forum/index.php", http.StatusFound)
+	case "/forum/index.php", "/forum/viewtopic.php":
+		if !f.loggedIn(r) {
+			fmt.Fprint(w, `<html><body><form id="top-login-box"><input name="login_username"></form>
+				<div class="mrg_16">Тема доступна только зарегистрированным пользователям</div></body></html>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><a id="logged-in-username" href="profile.php">user</a>
+			<a id="topic-title" href="viewtopic.php?t=42">Private Topic</a>
+			<span class="seed">Сиды:&nbsp; <b>51</b></span> <span class="leech">Личи:&nbsp; <b>2</b></span>
+			<span id="tor-size-humn">1.5&nbsp;GB</span></body></html>`)
+	case "/forum/dl.php":
+		if !f.loggedIn(r) || r.URL.Query().Get("t") != "42" {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "application/x-bittorrent")
+		_, _ = w.Write(f.torrent)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestRutrackerProvider_Parse_LogsInAndDownloadsTorrent(t *testing.T) {
+	torrent := []byte("d8:announce19:http://t.example/an4:infod6:lengthi5e4:name7:private12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaaee")
+	hash, err := utils.TorrentInfoHash(torrent)
+	require.NoError(t, err)
+
+	server := newFakeRutracker(t, torrent)
+	provider := NewRutrackerProvider("user", "secret", "", nil)
+
+	result, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=42")
+	require.NoError(t, err)
+
+	assert.Equal(t, "42", result.ID)
+	assert.Equal(t, "Private Topic", result.Title)
+	assert.Equal(t, torrent, result.TorrentFile, "the tracker's own torrent file should be handed to the download client")
+	assert.Equal(t, "magnet:?xt=urn:btih:"+hash, result.Magnet)
+	assert.Equal(t, types.TorrentInfo{Size: 1610612736, Seeders: 51, Leechers: 2, FileCount: 1}, result.Torrent)
+	assert.Equal(t, 1, server.logins)
+
+	_, err = provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=42")
+	require.NoError(t, err)
+	assert.Equal(t, 1, server.logins, "valid session must be reused")
+}
+
+func TestRutrackerProvider_Parse_RelogsInOnExpiredSession(t *testing.T) {
+	torrent := []byte("d4:infod6:lengthi5e4:name7:private12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaaee")
+	server := newFakeRutracker(t, torrent)
+	provider := NewRutrackerProvider("user", "secret", "", nil)
+
+	_, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=42")
+	require.NoError(t, err)
+
+	server.expireSessions()
+
+	result, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=42")
+	require.NoError(t, err)
+	assert.Equal(t, "Private Topic", result.Title)
+	assert.Equal(t, 2, server.logins)
+}
+
+func TestRutrackerProvider_Parse_LoginFailed(t *testing.T) {
+	server := newFakeRutracker(t, nil)
+	provider := NewRutrackerProvider("user", "wrong", "", nil)
+
+	_, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=42")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rutracker login failed")
+	assert.Equal(t, 0, server.logins)
+}
+
+func TestRutrackerProvider_Parse_AnonymousDoesNotLogIn(t *testing.T) {
+	server := newFakeRutracker(t, nil)
+	provider := NewRutrackerProvider("", "", "", nil)
+
+	_, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=42")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no magnet link found")
+	assert.Equal(t, 0, server.logins)
+}
+
+func TestRutrackerProvider_Parse_RejectsNonTorrentDownload(t *testing.T) {
+	server := newFakeRutracker(t, []byte("<html><body>Ошибка: torrent не найден</body></html>"))
+	provider := NewRutrackerProvider("user", "secret", "", nil)
+
+	_, err := provider.Parse(context.Background(), server.URL+"/forum/viewtopic.php?t=42")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a torrent file")
+}
+
+type mirrorTransport struct {
+	target  *url.URL
+	blocked map[string]bool
+	mu      sync.Mutex
+	hosts   []string
+}
+
+func (m *mirrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.mu.Lock()
+	m.hosts = append(m.hosts, req.URL.Host)
+	m.mu.Unlock()
+
+	if m.blocked[req.URL.Host] {
+		return nil, fmt.Errorf("connection reset by peer")
+	}
+
+	out := req.Clone(req.Context())
+	out.URL.Scheme = m.target.Scheme
+	out.URL.Host = m.target.Host
+	out.Host = ""
+	return http.DefaultTransport.RoundTrip(out)
+}
+
+func TestRutrackerProvider_Parse_CanonicalizesMirrorURL(t *testing.T) {
+	torrent := []byte("d4:infod6:lengthi5e4:name7:private12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaaee")
+	server := newFakeRutracker(t, torrent)
+	target, err := url.Parse(server.URL)
+	require.NoError(t, err)
+
+	transport := &mirrorTransport{target: target, blocked: map[string]bool{"rutracker.net": true}}
+	provider := NewRutrackerProvider("user", "secret", "rutracker.net", nil)
+	provider.fetcher.client.Transport = transport
+
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "topic link on a mirror", url: "http://www.rutracker.nl/forum/viewtopic.php?t=42&start=30#pagestart"},
+		{name: "post link", url: "https://rutracker.org/forum/viewtopic.php?p=777#777"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := provider.Parse(context.Background(), tt.url)
+			require.NoError(t, err)
+
+			assert.Equal(t, "42", result.ID)
+			assert.Equal(t, "https://rutracker.org/forum/viewtopic.php?t=42", result.TrackerURL)
+		})
+	}
+
+	assert.Equal(t, "rutracker.net", transport.hosts[0], "preferred mirror must be tried first")
+	assert.NotContains(t, transport.hosts[1:], "rutracker.nl", "fallback must stop at the first working mirror")
+	assert.Equal(t, 1, server.logins)
+}
@@ -0,0 +1,229 @@
+package providers
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"magnet-feed-sync/app/types"
+	"magnet-feed-sync/app/utils"
+)
+
+var newznabCategories = map[int]string{
+	1000: "Console",
+	2000: "Movies", 2010: "Movies/Foreign", 2030: "Movies/SD", 2040: "Movies/HD", 2045: "Movies/UHD",
+	2050: "Movies/BluRay", 2060: "Movies/3D", 2070: "Movies/DVD",
+	3000: "Audio", 3010: "Audio/MP3", 3030: "Audio/Audiobook", 3040: "Audio/Lossless",
+	4000: "PC", 5000: "TV", 5020: "TV/Foreign", 5030: "TV/SD", 5040: "TV/HD", 5045: "TV/UHD",
+	5060: "TV/Sport", 5070: "TV/Anime", 5080: "TV/Documentary",
+	6000: "XXX", 7000: "Books", 7010: "Books/Mags", 7020: "Books/EBook", 7030: "Books/Comics",
+	8000: "Other",
+}
+
+type torznabParser struct {
+	source string
+}
+
+func (p torznabParser) parseXML(data []byte, originalURL string) (*Result, error) {
+	var rss torznabRSS
+	if err := xml.Unmarshal(data, &rss); err != nil {
+		return nil, fmt.Errorf("failed to parse %s XML: %w", p.source, err)
+	}
+
+	if len(rss.Channel.Items) == 0 {
+		return nil, fmt.Errorf("no items found in %s response", p.source)
+	}
+
+	item := rss.Channel.Items[0]
+
+	magnet := p.extractMagnet(item)
+	if magnet == "" {
+		return nil, fmt.Errorf("no magnet link found in %s response", p.source)
+	}
+
+	trackerURL := p.extractTrackerURL(item)
+	id := p.extractID(trackerURL, originalURL)
+	if id == "" {
+		if parsed, err := utils.ParseMagnet(magnet); err == nil {
+			id = parsed.Hash()
+		}
+	}
+
+	return &Result{
+		ID:         id,
+		Title:      item.Title,
+		Magnet:     magnet,
+		UpdatedAt:  p.parsePubDate(item.PubDate),
+		TrackerURL: trackerURL,
+		Torrent:    p.extractTorrentInfo(item),
+	}, nil
+}
+
+func (p torznabParser) extractTorrentInfo(item torznabItem) types.TorrentInfo {
+	attrs := make(map[string][]string)
+	for _, attr := range item.Attrs {
+		attrs[attr.Name] = append(attrs[attr.Name], attr.Value)
+	}
+	first := func(name string) string {
+		if values := attrs[name]; len(values) > 0 {
+			return values[0]
+		}
+		return ""
+	}
+
+	info := types.TorrentInfo{
+		Size:      item.Size,
+		Seeders:   parseCount(first("seeders")),
+		FileCount: parseCount(first("files")),
+		Category:  p.categoryName(append(item.Categories, attrs["category"]...)),
+	}
+	if info.Size == 0 {
+		info.Size, _ = strconv.ParseInt(first("size"), 10, 64)
+	}
+	if leechers := first("leechers"); leechers != "" {
+		info.Leechers = parseCount(leechers)
+	} else if peers := parseCount(first("peers")); peers > info.Seeders {
+		info.Leechers = peers - info.Seeders
+	}
+
+	return info
+}
+
+func (p torznabParser) categoryName(categories []string) string {
+	best := 0
+	for _, raw := range categories {
+		id, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || id >= 100000 {
+			continue
+		}
+		if id > best {
+			best = id
+		}
+	}
+	if best == 0 {
+		return ""
+	}
+	if name, ok := newznabCategories[best]; ok {
+		return name
+	}
+	if name, ok := newznabCategories[best/1000*1000]; ok {
+		return name
+	}
+	return strconv.Itoa(best)
+}
+
+func (p torznabParser) parsePubDate(pubDate string) time.Time {
+	if pubDate == "" {
+		return time.Time{}
+	}
+	parsed, err := time.Parse(time.RFC1123Z, pubDate)
+	if err != nil {
+		parsed, _ = time.Parse(time.RFC1123, pubDate)
+	}
+	return parsed
+}
+
+func (p torznabParser) indexerName(item torznabItem) string {
+	if name := strings.TrimSpace(item.Indexer.Name); name != "" {
+		return name
+	}
+	return item.Indexer.ID
+}
+
+func (p torznabParser) extractMagnet(item torznabItem) string {
+	if strings.HasPrefix(item.Link, "magnet:") {
+		return item.Link
+	}
+	if item.Enclosure.URL != "" && strings.HasPrefix(item.Enclosure.URL, "magnet:") {
+		return item.Enclosure.URL
+	}
+	for _, attr := range item.Attrs {
+		if attr.Name == "magneturl" && strings.HasPrefix(attr.Value, "magnet:") {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+func (p torznabParser) extractLink(item torznabItem) string {
+	if strings.HasPrefix(item.Link, "http") {
+		return item.Link
+	}
+	if strings.HasPrefix(item.Enclosure.URL, "http") {
+		return item.Enclosure.URL
+	}
+	return ""
+}
+
+func (p torznabParser) extractTrackerURL(item torznabItem) string {
+	if item.Comments != "" && strings.HasPrefix(item.Comments, "http") {
+		return item.Comments
+	}
+	if item.GUID != "" && strings.HasPrefix(item.GUID, "http") {
+		return item.GUID
+	}
+	return ""
+}
+
+func (p torznabParser) extractID(trackerURL, originalURL string) string {
+	if trackerURL != "" {
+		if u, err := url.Parse(trackerURL); err == nil {
+			if t := u.Query().Get("t"); t != "" {
+				return t
+			}
+		}
+	}
+
+	if u, err := url.Parse(originalURL); err == nil {
+		if id := u.Query().Get("id"); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
+
+type torznabRSS struct {
+	XMLName xml.Name       `xml:"rss"`
+	Channel torznabChannel `xml:"channel"`
+}
+
+type torznabChannel struct {
+	Items []torznabItem `xml:"item"`
+}
+
+type torznabItem struct {
+	Title      string           `xml:"title"`
+	GUID       string           `xml:"guid"`
+	Comments   string           `xml:"comments"`
+	Link       string           `xml:"link"`
+	PubDate    string           `xml:"pubDate"`
+	Size       int64            `xml:"size"`
+	Categories []string         `xml:"category"`
+	Enclosure  torznabEnclosure `xml:"enclosure"`
+	Attrs      []torznabAttr    `xml:"http://torznab.com/schemas/2015/feed attr"`
+	Indexer    torznabIndexer   `xml:"jackettindexer"`
+}
+
+type torznabIndexer struct {
+	ID   string `xml:"id,attr"`
+	Name string `xml:",chardata"`
+}
+
+type torznabError struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        string   `xml:"code,attr"`
+	Description string   `xml:"description,attr"`
+}
+
+type torznabAttr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type torznabEnclosure struct {
+	URL string `xml:"url,attr"`
+}
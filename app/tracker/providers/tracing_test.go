@@ -48,7 +48,7 @@ func TestJackettProvider_Parse_CreatesTracingSpan(t *testing.T) {
 	}))
 	defer server.Close()
 
-	provider := NewJackettProvider(server.URL)
+	provider := NewJackettProvider(server.URL, "", nil)
 	result, err := provider.Parse(context.Background(), server.URL+"/api/v2.0/indexers/test/results?q=test")
 	require.NoError(t, err)
 	assert.Equal(t, "Test Torrent", result.Title)
@@ -121,6 +121,63 @@ func TestNnmProvider_Parse_CreatesTracingSpan(t *testing.T) {
 	assert.Contains(t, spanNames, "NnmProvider.Parse")
 }
 
+func TestKinozalProvider_Parse_CreatesTracingSpan(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if r.URL.Path == "/get_srv_details.php" {
+			fmt.Fprint(w, `<li>Инфо хеш: 0123456789ABCDEF0123456789ABCDEF01234567</li>`)
+			return
+		}
+		fmt.Fprint(w, `<html><body><h1><a href="/details.php?id=789">Test Kinozal Torrent</a></h1></body></html>`)
+	}))
+	defer server.Close()
+
+	provider := &KinozalProvider{}
+	result, err := provider.Parse(context.Background(), server.URL+"/details.php?id=789")
+	require.NoError(t, err)
+	assert.Equal(t, "Test Kinozal Torrent", result.Title)
+
+	spans := exporter.GetSpans()
+	require.GreaterOrEqual(t, len(spans), 1)
+
+	spanNames := make([]string, len(spans))
+	for i, s := range spans {
+		spanNames[i] = s.Name
+	}
+	assert.Contains(t, spanNames, "KinozalProvider.Parse")
+}
+
+func TestRutorProvider_Parse_CreatesTracingSpan(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	htmlResponse := `<html><body><div id="all">
+		<h1>Test Rutor Torrent</h1>
+		<div id="download"><a href="magnet:?xt=urn:btih:abc123&dn=test">magnet</a></div>
+	</div></body></html>`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, htmlResponse)
+	}))
+	defer server.Close()
+
+	provider := &RutorProvider{}
+	result, err := provider.Parse(context.Background(), server.URL+"/torrent/321/test")
+	require.NoError(t, err)
+	assert.Equal(t, "Test Rutor Torrent", result.Title)
+
+	spans := exporter.GetSpans()
+	require.GreaterOrEqual(t, len(spans), 1)
+
+	spanNames := make([]string, len(spans))
+	for i, s := range spans {
+		spanNames[i] = s.Name
+	}
+	assert.Contains(t, spanNames, "RutorProvider.Parse")
+}
+
 func TestProviderParse_NoopTracingNoCrash(t *testing.T) {
 	otel.SetTracerProvider(otel.GetTracerProvider())
 
@@ -0,0 +1,43 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrFeedExists   = errors.New("feed already exists")
+	ErrFeedNotFound = errors.New("feed not found")
+	ErrInvalidFeed  = errors.New("invalid feed")
+)
+
+type FeedMode string
+
+const (
+	FeedModeOnce  FeedMode = "once"
+	FeedModeTrack FeedMode = "track"
+)
+
+func ParseFeedMode(value string) (FeedMode, error) {
+	switch mode := FeedMode(value); mode {
+	case FeedModeOnce, FeedModeTrack:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown feed mode %q, expected one of: once, track", value)
+	}
+}
+
+type Feed struct {
+	ID            int64
+	URL           string
+	Name          string
+	Include       string
+	Exclude       string
+	Accept        string
+	Reject        string
+	Location      string
+	Mode          FeedMode
+	LastCheckedAt time.Time
+	CreatedAt     time.Time
+}
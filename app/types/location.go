@@ -1,6 +1,32 @@
 package types
 
+import "slices"
+
 type Location struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Command  string `json:"command,omitempty"`
+	Category string `json:"category,omitempty"`
+}
+
+func DefaultLocations() []Location {
+	return []Location{
+		{ID: "/downloads/tv shows", Name: "TV Shows"},
+		{ID: "/downloads/other", Name: "Other", Command: "other"},
+		{ID: "/downloads/movies", Name: "Movies", Command: "movies"},
+		{ID: "/downloads/me", Name: "Me"},
+		{ID: "/downloads/books", Name: "Books", Command: "books"},
+		{ID: "/downloads/audiobooks", Name: "Audiobooks", Command: "audiobooks"},
+		{ID: "/downloads/music", Name: "Music", Command: "music"},
+		{ID: "/downloads/comics", Name: "Comics", Command: "comics"},
+		{ID: "/downloads/podcasts", Name: "Podcasts", Command: "podcasts"},
+		{ID: "/downloads/anime", Name: "Anime", Command: "anime"},
+	}
+}
+
+func LocationsOrDefault(locations []Location) []Location {
+	if len(locations) == 0 {
+		return DefaultLocations()
+	}
+	return slices.Clone(locations)
 }
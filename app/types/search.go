@@ -0,0 +1,35 @@
+package types
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrSearchNotConfigured = errors.New("search is not configured, set JACKETT_URL and JACKETT_API_KEY")
+
+type SearchQuery struct {
+	Query    string
+	Indexer  string
+	Category string
+	Limit    int
+	Accept   string
+	Reject   string
+}
+
+type SearchResult struct {
+	Title       string
+	Indexer     string
+	TrackerURL  string
+	Magnet      string
+	Link        string
+	Torrent     TorrentInfo
+	PublishedAt time.Time
+	Trackable   bool
+}
+
+func (r SearchResult) Source() string {
+	if r.Magnet != "" {
+		return r.Magnet
+	}
+	return r.Link
+}
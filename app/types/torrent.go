@@ -0,0 +1,95 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrTorrentNotFound = errors.New("torrent not found")
+	ErrUnknownInstance = errors.New("unknown download client instance")
+)
+
+type UpdatePolicy string
+
+const (
+	UpdatePolicyKeep           UpdatePolicy = "keep"
+	UpdatePolicyRemove         UpdatePolicy = "remove"
+	UpdatePolicyRemoveWithData UpdatePolicy = "remove_with_data"
+)
+
+func ParseUpdatePolicy(value string) (UpdatePolicy, error) {
+	switch policy := UpdatePolicy(value); policy {
+	case UpdatePolicyKeep, UpdatePolicyRemove, UpdatePolicyRemoveWithData:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown update policy %q, expected one of: keep, remove, remove_with_data", value)
+	}
+}
+
+func (p *UpdatePolicy) SetValue(value string) error {
+	policy, err := ParseUpdatePolicy(value)
+	if err != nil {
+		return err
+	}
+
+	*p = policy
+	return nil
+}
+
+type TorrentState string
+
+const (
+	TorrentStateUnknown     TorrentState = "unknown"
+	TorrentStateQueued      TorrentState = "queued"
+	TorrentStateMetadata    TorrentState = "metadata"
+	TorrentStateChecking    TorrentState = "checking"
+	TorrentStateDownloading TorrentState = "downloading"
+	TorrentStateStalled     TorrentState = "stalled"
+	TorrentStatePaused      TorrentState = "paused"
+	TorrentStateCompleted   TorrentState = "completed"
+	TorrentStateError       TorrentState = "error"
+	TorrentStateMissing     TorrentState = "missing"
+)
+
+type TorrentStatus struct {
+	State       TorrentState `json:"state"`
+	Progress    float64      `json:"progress"`
+	Size        int64        `json:"size"`
+	ETA         int64        `json:"eta"`
+	CompletedAt time.Time    `json:"completed_at,omitzero"`
+}
+
+type TorrentInfo struct {
+	Size      int64  `json:"size"`
+	Seeders   int    `json:"seeders"`
+	Leechers  int    `json:"leechers"`
+	Category  string `json:"category"`
+	FileCount int    `json:"file_count"`
+}
+
+type EpisodeProgress struct {
+	Current int `json:"current"`
+	Total   int `json:"total"`
+}
+
+func (p EpisodeProgress) Complete() bool {
+	return p.Total > 0 && p.Current >= p.Total
+}
+
+func (s TorrentStatus) Verified() bool {
+	switch s.State {
+	case TorrentStateDownloading, TorrentStateStalled, TorrentStatePaused, TorrentStateCompleted:
+		return true
+	default:
+		return false
+	}
+}
+
+type DownloadOptions struct {
+	Destination string
+	Category    string
+	Tags        []string
+	Instance    string
+}
@@ -43,3 +43,43 @@ func ParseRussianDate(dateStr string) (time.Time, error) {
 
 	return parsedDate, nil
 }
+
+func ParseKinozalDate(dateStr string, now time.Time) (time.Time, error) {
+	russianMonths := map[string]time.Month{
+		"января": time.January, "февраля": time.February, "марта": time.March,
+		"апреля": time.April, "мая": time.May, "июня": time.June,
+		"июля": time.July, "августа": time.August, "сентября": time.September,
+		"октября": time.October, "ноября": time.November, "декабря": time.December,
+	}
+
+	parts := strings.Fields(strings.ToLower(dateStr))
+	if len(parts) < 2 || parts[len(parts)-2] != "в" {
+		return time.Time{}, fmt.Errorf("incorrect date format")
+	}
+
+	clock, err := time.Parse("15:04", parts[len(parts)-1])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not parse time: %v", err)
+	}
+
+	var year, day int
+	var month time.Month
+	switch dateParts := parts[:len(parts)-2]; {
+	case len(dateParts) == 1 && dateParts[0] == "сегодня":
+		year, month, day = now.Date()
+	case len(dateParts) == 1 && dateParts[0] == "вчера":
+		year, month, day = now.AddDate(0, 0, -1).Date()
+	case len(dateParts) == 3:
+		var ok bool
+		if month, ok = russianMonths[dateParts[1]]; !ok {
+			return time.Time{}, fmt.Errorf("invalid month")
+		}
+		if _, err := fmt.Sscanf(dateParts[0]+" "+dateParts[2], "%d %d", &day, &year); err != nil {
+			return time.Time{}, fmt.Errorf("could not parse date: %v", err)
+		}
+	default:
+		return time.Time{}, fmt.Errorf("incorrect date format")
+	}
+
+	return time.Date(year, month, day, clock.Hour(), clock.Minute(), 0, 0, time.UTC), nil
+}
@@ -0,0 +1,39 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKinozalDate(t *testing.T) {
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		date    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "full date", date: "15 марта 2024 в 22:58", want: time.Date(2024, 3, 15, 22, 58, 0, 0, time.UTC)},
+		{name: "today", date: "сегодня в 12:34", want: time.Date(2026, 3, 1, 12, 34, 0, 0, time.UTC)},
+		{name: "yesterday crosses month", date: "вчера в 23:05", want: time.Date(2026, 2, 28, 23, 5, 0, 0, time.UTC)},
+		{name: "unknown month", date: "15 мартобря 2024 в 22:58", wantErr: true},
+		{name: "missing time", date: "15 марта 2024", wantErr: true},
+		{name: "empty", date: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKinozalDate(tt.date, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
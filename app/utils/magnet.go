@@ -1,32 +1,98 @@
 package utils
 
-import "strings"
+import (
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
 
-func ExtractBtihHash(magnet string) string {
-	lower := strings.ToLower(magnet)
-	idx := strings.Index(lower, "urn:btih:")
-	if idx == -1 {
-		return ""
+var ErrNoInfoHash = errors.New("magnet link has no info hash")
+
+type Magnet struct {
+	InfoHash   string
+	InfoHashV2 string
+	Name       string
+	Trackers   []string
+	Size       int64
+	WebSeeds   []string
+}
+
+func ParseMagnet(raw string) (Magnet, error) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return Magnet{}, fmt.Errorf("parse magnet: %w", err)
 	}
-	hash := magnet[idx+len("urn:btih:"):]
-	if ampIdx := strings.Index(hash, "&"); ampIdx != -1 {
-		hash = hash[:ampIdx]
+	if !strings.EqualFold(u.Scheme, "magnet") {
+		return Magnet{}, fmt.Errorf("not a magnet link: %q", u.Scheme)
 	}
-	return strings.ToLower(hash)
-}
 
-func ExtractXtParam(magnet string) string {
-	lower := strings.ToLower(magnet)
-	for _, prefix := range []string{"?xt=", "&xt="} {
-		idx := strings.Index(lower, prefix)
-		if idx == -1 {
-			continue
+	params, _ := url.ParseQuery(u.RawQuery)
+
+	var m Magnet
+	for key, values := range params {
+		switch key = strings.ToLower(key); {
+		case key == "xt" || strings.HasPrefix(key, "xt."):
+			for _, value := range values {
+				m.addExactTopic(value)
+			}
+		case key == "dn":
+			m.Name = values[0]
+		case key == "tr":
+			m.Trackers = append(m.Trackers, values...)
+		case key == "ws":
+			m.WebSeeds = append(m.WebSeeds, values...)
+		case key == "xl":
+			m.Size, _ = strconv.ParseInt(values[0], 10, 64)
 		}
-		value := magnet[idx+len(prefix):]
-		if ampIdx := strings.Index(value, "&"); ampIdx != -1 {
-			value = value[:ampIdx]
+	}
+
+	if m.InfoHash == "" && m.InfoHashV2 == "" {
+		return Magnet{}, ErrNoInfoHash
+	}
+
+	return m, nil
+}
+
+func (m *Magnet) addExactTopic(value string) {
+	lower := strings.ToLower(value)
+	switch {
+	case strings.HasPrefix(lower, "urn:btih:"):
+		m.InfoHash = normalizeBtih(lower[len("urn:btih:"):])
+	case strings.HasPrefix(lower, "urn:btmh:"):
+		m.InfoHashV2 = lower[len("urn:btmh:"):]
+	}
+}
+
+func normalizeBtih(hash string) string {
+	if len(hash) == 32 {
+		if decoded, err := base32.StdEncoding.DecodeString(strings.ToUpper(hash)); err == nil {
+			return hex.EncodeToString(decoded)
 		}
-		return strings.ToLower(value)
 	}
-	return ""
+	return hash
+}
+
+func (m Magnet) Hash() string {
+	if m.InfoHash != "" {
+		return m.InfoHash
+	}
+	digest := strings.TrimPrefix(m.InfoHashV2, "1220")
+	if len(digest) > 40 {
+		digest = digest[:40]
+	}
+	return digest
+}
+
+func (m Magnet) SameTorrent(other Magnet) bool {
+	if m.InfoHash != "" && other.InfoHash != "" {
+		return m.InfoHash == other.InfoHash
+	}
+	if m.InfoHashV2 != "" && other.InfoHashV2 != "" {
+		return m.InfoHashV2 == other.InfoHashV2
+	}
+	return false
 }
@@ -4,66 +4,110 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestExtractBtihHash(t *testing.T) {
+func TestParseMagnet(t *testing.T) {
 	tests := []struct {
-		name     string
-		magnet   string
-		expected string
+		name   string
+		magnet string
+		want   Magnet
 	}{
 		{
-			name:     "standard magnet",
-			magnet:   "magnet:?xt=urn:btih:abc123def456&dn=test",
-			expected: "abc123def456",
+			name:   "standard magnet",
+			magnet: "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de&dn=Severance+S02&tr=http%3A%2F%2Fbt3.t-ru.org%2Fann&tr=udp://tracker.example.com:80",
+			want: Magnet{
+				InfoHash: "2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+				Name:     "Severance S02",
+				Trackers: []string{"http://bt3.t-ru.org/ann", "udp://tracker.example.com:80"},
+			},
 		},
 		{
-			name:     "magnet without extra params",
-			magnet:   "magnet:?xt=urn:btih:abc123def456",
-			expected: "abc123def456",
+			name:   "uppercase URN",
+			magnet: "magnet:?xt=URN:BTIH:2566E2B012EA1EF9087465BC97A7AC4449F4F0DE&dn=test",
+			want:   Magnet{InfoHash: "2566e2b012ea1ef9087465bc97a7ac4449f4f0de", Name: "test"},
 		},
 		{
-			name:     "no btih",
-			magnet:   "magnet:?dn=test",
-			expected: "",
+			name:   "base32 hash",
+			magnet: "magnet:?xt=urn:btih:EVTOFMAS5IPPSCDUMW6JPJ5MIRE7J4G6",
+			want:   Magnet{InfoHash: "2566e2b012ea1ef9087465bc97a7ac4449f4f0de"},
 		},
 		{
-			name:     "empty string",
-			magnet:   "",
-			expected: "",
+			name:   "hybrid v1 and v2",
+			magnet: "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de&xt=urn:btmh:1220CAF1E1C30E81CB361B9EE167C4AA64228A7FA4FA9F6105232B28AD099F3A302E&dn=hybrid",
+			want: Magnet{
+				InfoHash:   "2566e2b012ea1ef9087465bc97a7ac4449f4f0de",
+				InfoHashV2: "1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e",
+				Name:       "hybrid",
+			},
 		},
 		{
-			name:     "uppercase URN",
-			magnet:   "magnet:?xt=URN:BTIH:ABC123&dn=test",
-			expected: "abc123",
+			name:   "size and web seeds",
+			magnet: "magnet:?dn=movie&xl=52428800000&ws=https%3A%2F%2Fcdn.example.com%2Fmovie.mkv&xt=urn:btih:abc123",
+			want: Magnet{
+				InfoHash: "abc123",
+				Name:     "movie",
+				Size:     52428800000,
+				WebSeeds: []string{"https://cdn.example.com/movie.mkv"},
+			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.expected, ExtractBtihHash(tt.magnet))
+			got, err := ParseMagnet(tt.magnet)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
 
-func TestExtractXtParam(t *testing.T) {
+func TestParseMagnet_Invalid(t *testing.T) {
+	for _, magnet := range []string{"", "magnet:?dn=test", "https://rutracker.org/forum/viewtopic.php?t=1", "magnet:?xt=urn:sha1:abc"} {
+		_, err := ParseMagnet(magnet)
+		assert.Error(t, err, magnet)
+	}
+}
+
+func TestMagnet_Hash(t *testing.T) {
+	v1, err := ParseMagnet("magnet:?xt=urn:btih:EVTOFMAS5IPPSCDUMW6JPJ5MIRE7J4G6")
+	require.NoError(t, err)
+	assert.Equal(t, "2566e2b012ea1ef9087465bc97a7ac4449f4f0de", v1.Hash())
+
+	v2, err := ParseMagnet("magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e")
+	require.NoError(t, err)
+	assert.Equal(t, "caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa", v2.Hash(), "v2-only torrents use the truncated v2 hash")
+}
+
+func TestMagnet_SameTorrent(t *testing.T) {
+	const (
+		v1     = "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de&tr=http://a.com"
+		base32 = "magnet:?xt=urn:btih:EVTOFMAS5IPPSCDUMW6JPJ5MIRE7J4G6&tr=http://b.com"
+		v2     = "magnet:?xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e"
+		hybrid = "magnet:?xt=urn:btih:2566e2b012ea1ef9087465bc97a7ac4449f4f0de&xt=urn:btmh:1220caf1e1c30e81cb361b9ee167c4aa64228a7fa4fa9f6105232b28ad099f3a302e"
+		other  = "magnet:?xt=urn:btih:5555555555555555555555555555555555555555"
+	)
+
 	tests := []struct {
-		name     string
-		magnet   string
-		expected string
+		name string
+		a    string
+		b    string
+		want bool
 	}{
-		{"btih xt", "magnet:?xt=urn:btih:abc123&tr=http://a.com", "urn:btih:abc123"},
-		{"btmh xt", "magnet:?xt=urn:btmh:1220abc&tr=http://a.com", "urn:btmh:1220abc"},
-		{"xt without extra params", "magnet:?xt=urn:btmh:1220abc", "urn:btmh:1220abc"},
-		{"no xt param", "magnet:?dn=test&tr=http://a.com", ""},
-		{"empty string", "", ""},
-		{"uppercase", "magnet:?xt=URN:BTMH:1220ABC", "urn:btmh:1220abc"},
-		{"xt after other params", "magnet:?dn=test&xt=urn:btih:abc123&tr=http://a.com", "urn:btih:abc123"},
+		{name: "hex and base32", a: v1, b: base32, want: true},
+		{name: "hybrid and v1", a: hybrid, b: v1, want: true},
+		{name: "hybrid and v2", a: v2, b: hybrid, want: true},
+		{name: "v1 and v2 only", a: v1, b: v2, want: false},
+		{name: "different hashes", a: v1, b: other, want: false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.expected, ExtractXtParam(tt.magnet))
+			a, err := ParseMagnet(tt.a)
+			require.NoError(t, err)
+			b, err := ParseMagnet(tt.b)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, a.SameTorrent(b))
 		})
 	}
 }
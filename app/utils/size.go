@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var sizeUnits = map[string]int64{
+	"b": 1, "bytes": 1, "б": 1, "байт": 1,
+	"kb": 1 << 10, "kib": 1 << 10, "кб": 1 << 10,
+	"mb": 1 << 20, "mib": 1 << 20, "мб": 1 << 20,
+	"gb": 1 << 30, "gib": 1 << 30, "гб": 1 << 30,
+	"tb": 1 << 40, "tib": 1 << 40, "тб": 1 << 40,
+}
+
+func ParseSize(sizeStr string) (int64, error) {
+	sizeStr = strings.TrimSpace(sizeStr)
+
+	if start := strings.Index(sizeStr, "("); start >= 0 {
+		if end := strings.Index(sizeStr[start:], ")"); end > 0 {
+			if size, ok := parseExactSize(sizeStr[start+1 : start+end]); ok {
+				return size, nil
+			}
+		}
+		sizeStr = strings.TrimSpace(sizeStr[:start])
+	}
+
+	numEnd := strings.IndexFunc(sizeStr, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	if numEnd <= 0 {
+		return 0, fmt.Errorf("incorrect size format")
+	}
+
+	value, err := strconv.ParseFloat(strings.ReplaceAll(sizeStr[:numEnd], ",", "."), 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse size: %v", err)
+	}
+
+	multiplier, ok := sizeUnits[strings.ToLower(strings.TrimSpace(sizeStr[numEnd:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size unit")
+	}
+
+	return int64(value * float64(multiplier)), nil
+}
+
+func parseExactSize(s string) (int64, bool) {
+	var digits strings.Builder
+	for _, field := range strings.Fields(s) {
+		if _, isUnit := sizeUnits[strings.ToLower(field)]; isUnit {
+			continue
+		}
+		digits.WriteString(field)
+	}
+
+	size, err := strconv.ParseInt(digits.String(), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return size, true
+}
+
+func FormatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,46 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    int64
+		wantErr bool
+	}{
+		{name: "rutracker", input: "11.68 GB", want: 12541304504},
+		{name: "exact bytes in parentheses", input: "4.12 GB  (4423680000 Bytes)", want: 4423680000},
+		{name: "kinozal", input: "8.46 ГБ (9 086 470 144)", want: 9086470144},
+		{name: "comma decimal", input: "1,5 МБ", want: 1572864},
+		{name: "no space", input: "700MB", want: 734003200},
+		{name: "bytes", input: "512 B", want: 512},
+		{name: "unknown unit", input: "12 parsecs", wantErr: true},
+		{name: "no number", input: "GB", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSize(tt.input)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	assert.Equal(t, "512 B", FormatSize(512))
+	assert.Equal(t, "1.5 KiB", FormatSize(1536))
+	assert.Equal(t, "700.0 MiB", FormatSize(700<<20))
+	assert.Equal(t, "1.3 TiB", FormatSize(1300<<30))
+}
@@ -0,0 +1,139 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+const MaxTorrentFileSize = 10 * 1024 * 1024
+
+func IsTorrentFile(data []byte) bool {
+	return len(data) > 2 && data[0] == 'd' && data[len(data)-1] == 'e' && bytes.Contains(data, []byte("4:info"))
+}
+
+func TorrentInfoHash(data []byte) (string, error) {
+	d := &bencodeDecoder{data: data}
+	if d.peek() != 'd' {
+		return "", fmt.Errorf("torrent is not a bencoded dictionary")
+	}
+	d.pos++
+
+	for d.peek() != 'e' {
+		key, err := d.decodeString()
+		if err != nil {
+			return "", err
+		}
+		start := d.pos
+		value, err := d.decode()
+		if err != nil {
+			return "", err
+		}
+
+		if _, ok := value.(map[string]any); ok && key == "info" {
+			hash := sha1.Sum(data[start:d.pos])
+			return hex.EncodeToString(hash[:]), nil
+		}
+	}
+
+	return "", fmt.Errorf("torrent has no info dictionary")
+}
+
+func TorrentFileCount(data []byte) (int, error) {
+	d := &bencodeDecoder{data: data}
+	value, err := d.decode()
+	if err != nil {
+		return 0, err
+	}
+
+	torrent, _ := value.(map[string]any)
+	info, ok := torrent["info"].(map[string]any)
+	if !ok {
+		return 0, fmt.Errorf("torrent has no info dictionary")
+	}
+
+	if files, ok := info["files"].([]any); ok {
+		return len(files), nil
+	}
+	return 1, nil
+}
+
+type bencodeDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *bencodeDecoder) peek() byte {
+	if d.pos >= len(d.data) {
+		return 0
+	}
+	return d.data[d.pos]
+}
+
+func (d *bencodeDecoder) decode() (any, error) {
+	switch c := d.peek(); {
+	case c == 'i':
+		end := bytes.IndexByte(d.data[d.pos:], 'e')
+		if end == -1 {
+			return nil, fmt.Errorf("unterminated integer at %d", d.pos)
+		}
+		n, err := strconv.ParseInt(string(d.data[d.pos+1:d.pos+end]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer at %d: %w", d.pos, err)
+		}
+		d.pos += end + 1
+		return n, nil
+	case c == 'l':
+		d.pos++
+		var list []any
+		for d.peek() != 'e' {
+			value, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		d.pos++
+		return list, nil
+	case c == 'd':
+		d.pos++
+		dict := make(map[string]any)
+		for d.peek() != 'e' {
+			key, err := d.decodeString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.decode()
+			if err != nil {
+				return nil, err
+			}
+			dict[key] = value
+		}
+		d.pos++
+		return dict, nil
+	case c >= '0' && c <= '9':
+		return d.decodeString()
+	default:
+		return nil, fmt.Errorf("unexpected token at %d", d.pos)
+	}
+}
+
+func (d *bencodeDecoder) decodeString() (string, error) {
+	colon := bytes.IndexByte(d.data[d.pos:], ':')
+	if colon == -1 {
+		return "", fmt.Errorf("invalid string at %d", d.pos)
+	}
+	length, err := strconv.Atoi(string(d.data[d.pos : d.pos+colon]))
+	if err != nil || length < 0 {
+		return "", fmt.Errorf("invalid string length at %d", d.pos)
+	}
+
+	start := d.pos + colon + 1
+	if start+length > len(d.data) {
+		return "", fmt.Errorf("string at %d exceeds data", d.pos)
+	}
+	d.pos = start + length
+	return string(d.data[start:d.pos]), nil
+}
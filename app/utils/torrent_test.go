@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsTorrentFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{name: "torrent", data: "d8:announce3:url4:infod4:name4:testee", want: true},
+		{name: "empty", data: ""},
+		{name: "html page", data: "<html><body>login required</body></html>"},
+		{name: "dictionary without info", data: "d8:announce3:urle"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsTorrentFile([]byte(tt.data)))
+		})
+	}
+}
+
+func TestTorrentInfoHash(t *testing.T) {
+	info := "d6:lengthi5e4:name9:file name12:piece lengthi16384e6:pieces20:aaaaaaaaaaaaaaaaaaaae"
+	hash := sha1.Sum([]byte(info))
+	wantHash := hex.EncodeToString(hash[:])
+
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr bool
+	}{
+		{name: "announce", data: "d8:announce19:http://t.example/an4:info" + info + "e", want: wantHash},
+		{name: "info only", data: "d4:info" + info + "e", want: wantHash},
+		{name: "info before other keys", data: "d4:info" + info + "7:comment4:teste", want: wantHash},
+		{name: "no info", data: "d8:announce19:http://t.example/ane", wantErr: true},
+		{name: "truncated", data: "d4:info" + info[:30], wantErr: true},
+		{name: "not a dictionary", data: "<html></html>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TorrentInfoHash([]byte(tt.data))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTorrentFileCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "single file",
+			data: "d4:infod6:lengthi5e4:name9:file nameee",
+			want: 1,
+		},
+		{
+			name: "multiple files",
+			data: "d4:infod5:filesld6:lengthi1e4:pathl5:a.mkveed6:lengthi2e4:pathl5:b.mkveee4:name6:seasonee",
+			want: 2,
+		},
+		{name: "no info", data: "d8:announce19:http://t.example/ane", wantErr: true},
+		{name: "not a dictionary", data: "<html></html>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := TorrentFileCount([]byte(tt.data))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
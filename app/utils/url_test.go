@@ -0,0 +1,27 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{name: "jackett feed", url: "https://jackett.example.com/api/v2.0/indexers/all/results/torznab/api?apikey=secret&t=search", want: "https://jackett.example.com/api/v2.0/indexers/all/results/torznab/api?apikey=%2A%2A%2A&t=search"},
+		{name: "jackett download link", url: "https://jackett.example.com/dl/tpb/?jackett_apikey=secret&path=abc", want: "https://jackett.example.com/dl/tpb/?jackett_apikey=%2A%2A%2A&path=abc"},
+		{name: "api_key", url: "http://prowlarr:9696/1/api?API_KEY=secret", want: "http://prowlarr:9696/1/api?API_KEY=%2A%2A%2A"},
+		{name: "no secret", url: "https://rutracker.org/forum/viewtopic.php?t=1", want: "https://rutracker.org/forum/viewtopic.php?t=1"},
+		{name: "invalid", url: "://bad", want: "<invalid url>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RedactURL(tt.url))
+		})
+	}
+}
@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE files ADD COLUMN update_policy TEXT NOT NULL DEFAULT 'keep';
+
+-- +migrate Down
+ALTER TABLE files DROP COLUMN update_policy;
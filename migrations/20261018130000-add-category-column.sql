@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE files ADD COLUMN category TEXT NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE files DROP COLUMN category;
@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE files ADD COLUMN download_state TEXT NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN download_progress REAL NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN download_size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN download_eta INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN download_completed_at TIMESTAMP DEFAULT NULL;
+
+-- +migrate Down
+ALTER TABLE files DROP COLUMN download_completed_at;
+ALTER TABLE files DROP COLUMN download_eta;
+ALTER TABLE files DROP COLUMN download_size;
+ALTER TABLE files DROP COLUMN download_progress;
+ALTER TABLE files DROP COLUMN download_state;
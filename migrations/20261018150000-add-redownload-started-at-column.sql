@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE files ADD COLUMN redownload_started_at TIMESTAMP DEFAULT NULL;
+
+-- +migrate Down
+ALTER TABLE files DROP COLUMN redownload_started_at;
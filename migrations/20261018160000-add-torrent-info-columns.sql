@@ -0,0 +1,13 @@
+-- +migrate Up
+ALTER TABLE files ADD COLUMN torrent_size INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN torrent_seeders INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN torrent_leechers INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN torrent_category TEXT NOT NULL DEFAULT '';
+ALTER TABLE files ADD COLUMN torrent_file_count INTEGER NOT NULL DEFAULT 0;
+
+-- +migrate Down
+ALTER TABLE files DROP COLUMN torrent_file_count;
+ALTER TABLE files DROP COLUMN torrent_category;
+ALTER TABLE files DROP COLUMN torrent_leechers;
+ALTER TABLE files DROP COLUMN torrent_seeders;
+ALTER TABLE files DROP COLUMN torrent_size;
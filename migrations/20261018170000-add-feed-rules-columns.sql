@@ -0,0 +1,7 @@
+-- +migrate Up
+ALTER TABLE feeds ADD COLUMN accept_rules TEXT NOT NULL DEFAULT '';
+ALTER TABLE feeds ADD COLUMN reject_rules TEXT NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE feeds DROP COLUMN reject_rules;
+ALTER TABLE feeds DROP COLUMN accept_rules;
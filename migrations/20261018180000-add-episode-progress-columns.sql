@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE files ADD COLUMN episodes_current INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN episodes_total INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE files ADD COLUMN completed_at TIMESTAMP DEFAULT NULL;
+
+-- +migrate Down
+ALTER TABLE files DROP COLUMN completed_at;
+ALTER TABLE files DROP COLUMN episodes_total;
+ALTER TABLE files DROP COLUMN episodes_current;